@@ -18,6 +18,7 @@ package addon
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/docker/machine/libmachine"
 	"github.com/docker/machine/libmachine/provision"
@@ -33,7 +34,15 @@ import (
 	"github.com/spf13/viper"
 )
 
+const (
+	dryRunFlag = "dry-run"
+	atomicFlag = "atomic"
+)
+
 var (
+	applyDryRun bool
+	atomic      bool
+
 	addonsApplyCmd = &cobra.Command{
 		Use:   "apply ADDON_NAME ...",
 		Short: "Applies the specified add-ons.",
@@ -44,6 +53,9 @@ var (
 
 func init() {
 	addonsApplyCmd.Flags().AddFlag(util.AddOnEnvFlag)
+	addonsApplyCmd.Flags().AddFlag(util.AllowHostCommandsFlag)
+	addonsApplyCmd.Flags().BoolVar(&applyDryRun, dryRunFlag, false, "Prints the interpolated commands of the add-ons without executing them.")
+	addonsApplyCmd.Flags().BoolVar(&atomic, atomicFlag, false, "Runs the remove commands of an add-on to roll back its changes if one of its commands fails.")
 	AddonsCmd.AddCommand(addonsApplyCmd)
 }
 
@@ -92,9 +104,23 @@ func runApplyAddon(cmd *cobra.Command, args []string) {
 		if err != nil {
 			atexit.ExitWithMessage(1, fmt.Sprint("Error applying the add-on: ", err))
 		}
-		err = addOnManager.ApplyAddOn(addon, addonContext)
+		addonContext.SetDryRun(applyDryRun)
+		addonContext.SetAllowHostCommands(viper.GetBool(configCmd.AllowHostCommands.Name))
+		if atomic && !applyDryRun {
+			err = addOnManager.ApplyAddOnAtomic(addon, addonContext)
+		} else {
+			err = addOnManager.ApplyAddOn(addon, addonContext)
+		}
+		if !applyDryRun {
+			if writeErr := minishiftConfig.InstanceConfig.Write(); writeErr != nil {
+				atexit.ExitWithMessage(1, fmt.Sprintf("Error writing addon config data: %v", writeErr))
+			}
+		}
 		if err != nil {
 			atexit.ExitWithMessage(1, fmt.Sprint("Error applying the add-on: ", err))
 		}
+		if applyDryRun {
+			printPlan(os.Stdout, addonContext.Plan())
+		}
 	}
 }
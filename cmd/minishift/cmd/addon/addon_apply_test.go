@@ -24,6 +24,7 @@ import (
 
 	"github.com/minishift/minishift/cmd/testing/cli"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_addon_name_must_be_specified_for_apply_command(t *testing.T) {
@@ -49,3 +50,11 @@ func Test_unknown_name_for_apply_command_returns_error(t *testing.T) {
 
 	runApplyAddon(nil, []string{testAddOnName})
 }
+
+func Test_dry_run_flags_of_apply_and_remove_are_independent(t *testing.T) {
+	defer addonsRemoveCmd.Flags().Set(dryRunFlag, "false")
+
+	assert.NoError(t, addonsRemoveCmd.Flags().Set(dryRunFlag, "true"))
+	assert.True(t, removeDryRun)
+	assert.False(t, applyDryRun, "the dry run flag of remove must not affect apply")
+}
@@ -0,0 +1,330 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
+	"github.com/minishift/minishift/pkg/minishift/addon/manager"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	outputFlag = "output"
+
+	jsonOutput = "json"
+	yamlOutput = "yaml"
+)
+
+var (
+	infoOutput string
+
+	addonsInfoCmd = &cobra.Command{
+		Use:   "info ADDON_NAME",
+		Short: "Prints the details of the specified add-on.",
+		Long: `Prints the details of the specified add-on: its metadata, the variables it declares together with their types and descriptions,
+the install path, its enabled state and priority, the parsed commands of the add-on and its remove file as well as its transitive dependencies.`,
+		Run: runInfoAddon,
+	}
+)
+
+// AddOnInfo contains the details of an installed add-on.
+type AddOnInfo struct {
+	Name             string            `json:"name" yaml:"name"`
+	Description      []string          `json:"description" yaml:"description"`
+	Url              string            `json:"url,omitempty" yaml:"url,omitempty"`
+	OpenShiftVersion string            `json:"openshiftVersion,omitempty" yaml:"openshiftVersion,omitempty"`
+	MinishiftVersion string            `json:"minishiftVersion,omitempty" yaml:"minishiftVersion,omitempty"`
+	DependsOn        []string          `json:"dependsOn,omitempty" yaml:"dependsOn,omitempty"`
+	RequiredVars     []string          `json:"requiredVars,omitempty" yaml:"requiredVars,omitempty"`
+	VarDefaults      map[string]string `json:"varDefaults,omitempty" yaml:"varDefaults,omitempty"`
+	Variables        []AddOnVariable   `json:"variables,omitempty" yaml:"variables,omitempty"`
+	InstallPath      string            `json:"installPath" yaml:"installPath"`
+	Enabled          bool              `json:"enabled" yaml:"enabled"`
+	Priority         int               `json:"priority" yaml:"priority"`
+	Commands         []CommandInfo     `json:"commands" yaml:"commands"`
+	RemoveCommands   []CommandInfo     `json:"removeCommands,omitempty" yaml:"removeCommands,omitempty"`
+
+	DependencyTree []*manager.DependencyNode `json:"dependencyTree,omitempty" yaml:"dependencyTree,omitempty"`
+}
+
+// AddOnVariable describes a variable of an add-on, either declared via a 'Var' header or referenced by the
+// Required-Vars or Var-Defaults headers.
+type AddOnVariable struct {
+	Name        string `json:"name" yaml:"name"`
+	Type        string `json:"type" yaml:"type"`
+	Required    bool   `json:"required" yaml:"required"`
+	Default     string `json:"default,omitempty" yaml:"default,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// CommandInfo describes a parsed add-on command. Conditional commands contain the commands of their branches.
+type CommandInfo struct {
+	Type           string        `json:"type" yaml:"type"`
+	Command        string        `json:"command" yaml:"command"`
+	IgnoreError    bool          `json:"ignoreError,omitempty" yaml:"ignoreError,omitempty"`
+	OutputVariable string        `json:"outputVariable,omitempty" yaml:"outputVariable,omitempty"`
+	Commands       []CommandInfo `json:"commands,omitempty" yaml:"commands,omitempty"`
+	ElseCommands   []CommandInfo `json:"elseCommands,omitempty" yaml:"elseCommands,omitempty"`
+}
+
+func init() {
+	addonsInfoCmd.Flags().StringVarP(&infoOutput, outputFlag, "o", "", "The output format. One of: json, yaml. By default a human readable format is used.")
+	AddonsCmd.AddCommand(addonsInfoCmd)
+}
+
+func runInfoAddon(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		atexit.ExitWithMessage(1, emptyAddOnError)
+	}
+	if infoOutput != "" && infoOutput != jsonOutput && infoOutput != yamlOutput {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Invalid output format '%s'. Use one of: %s, %s", infoOutput, jsonOutput, yamlOutput))
+	}
+
+	addOnName := args[0]
+	addOnManager := GetAddOnManager()
+	if !addOnManager.IsInstalled(addOnName) {
+		atexit.ExitWithMessage(0, fmt.Sprintf(noAddOnMessage, addOnName))
+	}
+
+	info, err := getAddOnInfo(addOnManager, addOnName)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot determine the details of the add-on '%s': %s", addOnName, err.Error()))
+	}
+
+	switch infoOutput {
+	case jsonOutput:
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			atexit.ExitWithMessage(1, fmt.Sprintf("Cannot serialize the details of the add-on '%s': %s", addOnName, err.Error()))
+		}
+		fmt.Println(string(data))
+	case yamlOutput:
+		data, err := yaml.Marshal(info)
+		if err != nil {
+			atexit.ExitWithMessage(1, fmt.Sprintf("Cannot serialize the details of the add-on '%s': %s", addOnName, err.Error()))
+		}
+		fmt.Print(string(data))
+	default:
+		printAddOnInfo(os.Stdout, info)
+	}
+}
+
+// getAddOnInfo collects the details of the specified add-on.
+func getAddOnInfo(addOnManager *manager.AddOnManager, addOnName string) (*AddOnInfo, error) {
+	addOn := addOnManager.Get(addOnName)
+	meta := addOn.MetaData()
+
+	dependencies, err := meta.Dependency()
+	if err != nil {
+		return nil, err
+	}
+	requiredVars, err := meta.RequiredVars()
+	if err != nil {
+		return nil, err
+	}
+	variables, err := addOnVariables(meta)
+	if err != nil {
+		return nil, err
+	}
+	dependencyTree, err := addOnManager.DependencyTree(addOnName)
+	if err != nil {
+		return nil, err
+	}
+
+	varDefaults := make(map[string]string)
+	for _, variable := range variables {
+		if variable.Default != "" {
+			varDefaults[variable.Name] = variable.Default
+		}
+	}
+
+	return &AddOnInfo{
+		Name:             meta.Name(),
+		Description:      meta.Description(),
+		Url:              meta.Url(),
+		OpenShiftVersion: meta.OpenShiftVersion(),
+		MinishiftVersion: meta.MinishiftVersion(),
+		DependsOn:        dependencies,
+		RequiredVars:     requiredVars,
+		VarDefaults:      varDefaults,
+		Variables:        variables,
+		InstallPath:      addOn.InstallPath(),
+		Enabled:          addOn.IsEnabled(),
+		Priority:         addOn.GetPriority(),
+		Commands:         commandInfos(addOn.Commands()),
+		RemoveCommands:   commandInfos(addOn.RemoveCommands()),
+		DependencyTree:   dependencyTree,
+	}, nil
+}
+
+// addOnVariables merges the variable declarations with the required variables and the variable defaults of the
+// specified metadata. Variables which are not declared are of type string.
+func addOnVariables(meta addon.AddOnMeta) ([]AddOnVariable, error) {
+	declarations, err := meta.VarDeclarations()
+	if err != nil {
+		return nil, err
+	}
+	requiredVars, err := meta.RequiredVars()
+	if err != nil {
+		return nil, err
+	}
+	varDefaults, err := meta.VarDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	var variables []AddOnVariable
+	index := make(map[string]int)
+	add := func(name string) *AddOnVariable {
+		if i, ok := index[name]; ok {
+			return &variables[i]
+		}
+		index[name] = len(variables)
+		variables = append(variables, AddOnVariable{Name: name, Type: addon.StringVarType})
+		return &variables[len(variables)-1]
+	}
+
+	for _, declaration := range declarations {
+		variable := add(declaration.Name)
+		variable.Type = declaration.TypeString()
+		variable.Description = declaration.Description
+	}
+	for _, requiredVar := range requiredVars {
+		add(requiredVar).Required = true
+	}
+	for _, varDefault := range varDefaults {
+		variable := add(varDefault.Key)
+		variable.Default = varDefault.Value
+		if variable.Type == addon.SecretVarType {
+			variable.Default = "*****"
+		}
+	}
+	return variables, nil
+}
+
+// commandInfos describes the specified parsed commands.
+func commandInfos(commands []command.Command) []CommandInfo {
+	var infos []CommandInfo
+	for _, c := range commands {
+		info := CommandInfo{Command: c.String()}
+		if fields := strings.Fields(c.String()); len(fields) > 0 {
+			info.Type = fields[0]
+		}
+		if details, ok := c.(interface {
+			IgnoreError() bool
+			OutputVariable() string
+		}); ok {
+			info.IgnoreError = details.IgnoreError()
+			info.OutputVariable = details.OutputVariable()
+		}
+		if conditional, ok := c.(*command.ConditionalCommand); ok {
+			info.Commands = commandInfos(conditional.Commands())
+			info.ElseCommands = commandInfos(conditional.ElseCommands())
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+func printAddOnInfo(writer io.Writer, info *AddOnInfo) {
+	fmt.Fprintln(writer, fmt.Sprintf("Name              : %s", info.Name))
+	fmt.Fprintln(writer, fmt.Sprintf("Description       : %s", strings.Join(info.Description, fmt.Sprintf("\n%20s", " "))))
+	fmt.Fprintln(writer, fmt.Sprintf("Url               : %s", info.Url))
+	fmt.Fprintln(writer, fmt.Sprintf("OpenShift Version : %s", info.OpenShiftVersion))
+	fmt.Fprintln(writer, fmt.Sprintf("Minishift Version : %s", info.MinishiftVersion))
+	fmt.Fprintln(writer, fmt.Sprintf("Depends On        : %s", strings.Join(info.DependsOn, ", ")))
+	fmt.Fprintln(writer, fmt.Sprintf("Install Path      : %s", info.InstallPath))
+	fmt.Fprintln(writer, fmt.Sprintf("Enabled           : %t", info.Enabled))
+	fmt.Fprintln(writer, fmt.Sprintf("Priority          : %d", info.Priority))
+
+	if len(info.Variables) == 0 {
+		fmt.Fprintln(writer, "Variables         : none")
+	} else {
+		fmt.Fprintln(writer, "Variables         :")
+		display := new(tabwriter.Writer)
+		display.Init(writer, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(display, "  NAME\tTYPE\tREQUIRED\tDEFAULT\tDESCRIPTION")
+		for _, variable := range info.Variables {
+			required := "no"
+			if variable.Required {
+				required = "yes"
+			}
+			fmt.Fprintln(display, fmt.Sprintf("  %s\t%s\t%s\t%s\t%s", variable.Name, variable.Type, required, variable.Default, variable.Description))
+		}
+		display.Flush()
+	}
+
+	fmt.Fprintln(writer, "Commands          :")
+	printCommandInfos(writer, info.Commands, 1)
+	if len(info.RemoveCommands) > 0 {
+		fmt.Fprintln(writer, "Remove Commands   :")
+		printCommandInfos(writer, info.RemoveCommands, 1)
+	}
+
+	if len(info.DependencyTree) > 0 {
+		fmt.Fprintln(writer, "Dependency Tree   :")
+		printDependencyNodes(writer, info.DependencyTree, 1)
+	}
+}
+
+func printCommandInfos(writer io.Writer, commands []CommandInfo, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, c := range commands {
+		line := c.Command
+		if c.OutputVariable != "" {
+			line = fmt.Sprintf("%s := %s", c.OutputVariable, line)
+		}
+		if c.IgnoreError {
+			line = "!" + line
+		}
+		fmt.Fprintln(writer, indent+line)
+
+		if c.Type == "if" {
+			printCommandInfos(writer, c.Commands, depth+1)
+			if len(c.ElseCommands) > 0 {
+				fmt.Fprintln(writer, indent+"else")
+				printCommandInfos(writer, c.ElseCommands, depth+1)
+			}
+			fmt.Fprintln(writer, indent+"end")
+		}
+	}
+}
+
+func printDependencyNodes(writer io.Writer, nodes []*manager.DependencyNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, node := range nodes {
+		var note string
+		switch {
+		case !node.Installed:
+			note = " (not installed)"
+		case node.Cycle:
+			note = " (cycle)"
+		}
+		fmt.Fprintln(writer, fmt.Sprintf("%s- %s%s", indent, node.Name, note))
+		printDependencyNodes(writer, node.Dependencies, depth+1)
+	}
+}
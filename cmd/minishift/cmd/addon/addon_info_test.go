@@ -0,0 +1,118 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minishift/minishift/cmd/testing/cli"
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/config"
+	"github.com/minishift/minishift/pkg/minishift/addon/manager"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_addon_name_must_be_specified_for_info_command(t *testing.T) {
+	tmpMinishiftHomeDir := cli.SetupTmpMinishiftHome(t)
+	tee := cli.CreateTee(t, true)
+	defer cli.TearDown(tmpMinishiftHomeDir, tee)
+
+	atexit.RegisterExitHandler(cli.VerifyExitCodeAndMessage(t, tee, 1, emptyAddOnError))
+
+	runInfoAddon(nil, nil)
+}
+
+func Test_addon_variables_merge_declarations_required_vars_and_defaults(t *testing.T) {
+	meta, err := addon.NewAddOnMeta(map[string]interface{}{
+		"Name":               "typed",
+		"Description":        []string{"Addon with typed variables"},
+		"Required-Vars":      "HTTP_PORT, USER",
+		"Var-Defaults":       "HTTP_PORT=8080, TOKEN=changeme",
+		addon.VarMetaTagName: []string{"HTTP_PORT int The proxy port", "TOKEN secret The registry token"},
+	})
+	assert.NoError(t, err)
+
+	variables, err := addOnVariables(meta)
+	assert.NoError(t, err)
+
+	expected := []AddOnVariable{
+		{Name: "HTTP_PORT", Type: "int", Required: true, Default: "8080", Description: "The proxy port"},
+		{Name: "TOKEN", Type: "secret", Default: "*****", Description: "The registry token"},
+		{Name: "USER", Type: "string", Required: true},
+	}
+	assert.Equal(t, expected, variables)
+}
+
+func Test_addon_info_contains_commands_and_dependency_tree(t *testing.T) {
+	addOnDir, err := ioutil.TempDir("", "minishift-addon-info-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(addOnDir)
+
+	writeInfoTestAddOn(t, addOnDir, "base", "", "echo base\n", "")
+	writeInfoTestAddOn(t, addOnDir, "app", "# Depends-On: base\n", "!oc project myproject\nversion := oc version\nif defined FOO\n  echo foo\nelse\n  echo bar\nend\n", "oc delete project myproject\n")
+
+	addOnManager, err := manager.NewAddOnManager(addOnDir, map[string]*config.AddOnConfig{})
+	assert.NoError(t, err)
+
+	info, err := getAddOnInfo(addOnManager, "app")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"base"}, info.DependsOn)
+	assert.Equal(t, filepath.Join(addOnDir, "app"), info.InstallPath)
+	assert.Equal(t, []*manager.DependencyNode{{Name: "base", Installed: true}}, info.DependencyTree)
+
+	expectedCommands := []CommandInfo{
+		{Type: "oc", Command: "oc project myproject", IgnoreError: true},
+		{Type: "oc", Command: "oc version", OutputVariable: "version"},
+		{Type: "if", Command: "if defined FOO",
+			Commands:     []CommandInfo{{Type: "echo", Command: "echo foo"}},
+			ElseCommands: []CommandInfo{{Type: "echo", Command: "echo bar"}},
+		},
+	}
+	assert.Equal(t, expectedCommands, info.Commands)
+	assert.Equal(t, []CommandInfo{{Type: "oc", Command: "oc delete project myproject"}}, info.RemoveCommands)
+
+	data, err := json.Marshal(info)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"installPath":`)
+	assert.Contains(t, string(data), `"dependencyTree":[{"name":"base","installed":true}]`)
+
+	output := new(bytes.Buffer)
+	printAddOnInfo(output, info)
+	assert.Contains(t, output.String(), "Depends On        : base\n")
+	assert.Contains(t, output.String(), "  !oc project myproject\n  version := oc version\n  if defined FOO\n    echo foo\n  else\n    echo bar\n  end\n")
+	assert.Contains(t, output.String(), "Dependency Tree   :\n  - base\n")
+}
+
+func writeInfoTestAddOn(t *testing.T, baseDir string, name string, headers string, commands string, removeCommands string) {
+	dir := filepath.Join(baseDir, name)
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+
+	content := fmt.Sprintf("# Name: %s\n# Description: Test addon %s\n%s\n%s", name, name, headers, commands)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".addon"), []byte(content), 0644))
+	if removeCommands != "" {
+		content = fmt.Sprintf("# Name: %s\n# Description: Removes test addon %s\n\n%s", name, name, removeCommands)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".addon.remove"), []byte(content), 0644))
+	}
+}
@@ -44,8 +44,11 @@ var (
 var addonsInstallCmd = &cobra.Command{
 	Use:   "install [SOURCE]",
 	Short: "Installs the specified add-on.",
-	Long:  "Installs the add-on from the specified file path and verifies the installation.",
-	Run:   runInstallAddon,
+	Long: `Installs the add-on from the specified source and verifies the installation.
+The source can be a local directory, a Git repository or a tar.gz/zip archive served via http(s):// or file://.
+Use '//<sub-dir>' to select the add-on directory within a repository or archive and '?ref=<ref>' to select the Git branch, tag or commit,
+for example https://github.com/minishift/minishift-addons.git//add-ons/che?ref=master`,
+	Run: runInstallAddon,
 }
 
 func init() {
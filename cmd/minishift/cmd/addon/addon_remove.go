@@ -18,6 +18,7 @@ package addon
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/docker/machine/libmachine"
 	"github.com/docker/machine/libmachine/provision"
@@ -34,6 +35,8 @@ import (
 )
 
 var (
+	removeDryRun bool
+
 	addonsRemoveCmd = &cobra.Command{
 		Use:   "remove ADDON_NAME ...",
 		Short: "Removes the specified add-ons.",
@@ -44,6 +47,8 @@ var (
 
 func init() {
 	addonsRemoveCmd.Flags().AddFlag(util.AddOnEnvFlag)
+	addonsRemoveCmd.Flags().AddFlag(util.AllowHostCommandsFlag)
+	addonsRemoveCmd.Flags().BoolVar(&removeDryRun, dryRunFlag, false, "Prints the interpolated remove commands of the add-ons without executing them.")
 	AddonsCmd.AddCommand(addonsRemoveCmd)
 }
 
@@ -95,9 +100,14 @@ func runRemoveAddon(cmd *cobra.Command, args []string) {
 		if err != nil {
 			atexit.ExitWithMessage(1, fmt.Sprint("Error removing the add-on: ", err))
 		}
+		addonContext.SetDryRun(removeDryRun)
+		addonContext.SetAllowHostCommands(viper.GetBool(configCmd.AllowHostCommands.Name))
 		err = addOnManager.RemoveAddOn(addon, addonContext)
 		if err != nil {
 			atexit.ExitWithMessage(1, fmt.Sprint("Error removing the add-on: ", err))
 		}
+		if removeDryRun {
+			printPlan(os.Stdout, addonContext.Plan())
+		}
 	}
 }
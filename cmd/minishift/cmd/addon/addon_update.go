@@ -0,0 +1,98 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/manager"
+	"github.com/minishift/minishift/pkg/util/filehelper"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+var addonsUpdateCmd = &cobra.Command{
+	Use:   "update [ADDON_NAME ...]",
+	Short: "Updates the specified add-ons from their source.",
+	Long: `Updates the specified add-ons from the source they were installed from and reports the changes of the add-on metadata.
+If no add-on is specified, all add-ons with a recorded source are updated. If the updated add-on cannot be parsed, the previously installed version is restored.`,
+	Run: runUpdateAddon,
+}
+
+func init() {
+	AddonsCmd.AddCommand(addonsUpdateCmd)
+}
+
+func runUpdateAddon(cmd *cobra.Command, args []string) {
+	addOnManager := GetAddOnManager()
+
+	addOnNames := args
+	if len(addOnNames) == 0 {
+		addOnNames = addOnsWithRecordedSource(addOnManager.List())
+		if len(addOnNames) == 0 {
+			fmt.Println("No add-on with a recorded source is installed.")
+			return
+		}
+	}
+
+	for _, addOnName := range addOnNames {
+		if !addOnManager.IsInstalled(addOnName) {
+			atexit.ExitWithMessage(0, fmt.Sprintf(noAddOnMessage, addOnName))
+		}
+	}
+
+	failed := false
+	for _, addOnName := range addOnNames {
+		changes, err := addOnManager.Update(addOnName)
+		if err != nil {
+			fmt.Println(fmt.Sprintf("Cannot update the add-on '%s': %s", addOnName, err.Error()))
+			failed = true
+			continue
+		}
+		printMetaDataChanges(addOnName, changes)
+	}
+
+	if failed {
+		atexit.Exit(1)
+	}
+}
+
+func addOnsWithRecordedSource(addOns []addon.AddOn) []string {
+	var names []string
+	for _, addOn := range addOns {
+		if filehelper.Exists(filepath.Join(addOn.InstallPath(), manager.AddOnSourceFile)) {
+			names = append(names, addOn.MetaData().Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func printMetaDataChanges(addOnName string, changes []manager.MetaDataChange) {
+	if len(changes) == 0 {
+		fmt.Println(fmt.Sprintf("Add-on '%s' updated", addOnName))
+		return
+	}
+
+	fmt.Println(fmt.Sprintf("Add-on '%s' updated with metadata changes:", addOnName))
+	for _, change := range changes {
+		fmt.Println(fmt.Sprintf("  %s: '%s' -> '%s'", change.Field, change.OldValue, change.NewValue))
+	}
+}
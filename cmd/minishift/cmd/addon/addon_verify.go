@@ -0,0 +1,84 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/minishift/minishift/pkg/minishift/addon/parser"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+const (
+	emptyAddOnPathError = "You must specify the path of at least one add-on directory."
+
+	addOnsDirFlag = "addons-dir"
+)
+
+var (
+	addOnsDir string
+
+	addonsVerifyCmd = &cobra.Command{
+		Use:   "verify PATH...",
+		Short: "Verifies the specified add-on directories without applying them.",
+		Long: `Verifies the specified add-on directories without applying them and without the need of a running VM.
+Besides parsing the add-ons, it checks that every interpolated variable is declared or assigned by a preceding command,
+that every command is known, that the add-ons listed in Depends-On exist and that the metadata of the remove file matches the add-on.
+The problems found are printed as JSON. The command exits with a non-zero exit code if any error is found.`,
+		Run: runVerifyAddon,
+	}
+)
+
+func init() {
+	addonsVerifyCmd.Flags().StringVar(&addOnsDir, addOnsDirFlag, "", "The directory in which the dependencies of the add-ons are looked up. Defaults to the parent directory of each add-on.")
+	AddonsCmd.AddCommand(addonsVerifyCmd)
+}
+
+func runVerifyAddon(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		atexit.ExitWithMessage(1, emptyAddOnPathError)
+	}
+
+	diagnostics := verifyAddOns(args, addOnsDir)
+	data, err := json.MarshalIndent(diagnostics, "", "  ")
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot serialize the verification result: %s", err.Error()))
+	}
+	fmt.Println(string(data))
+
+	if parser.HasErrors(diagnostics) {
+		atexit.Exit(1)
+	}
+}
+
+// verifyAddOns verifies the specified add-on directories and returns the diagnostics of all of them.
+func verifyAddOns(paths []string, addOnsDir string) []parser.Diagnostic {
+	addOnParser := parser.NewAddOnParser()
+	diagnostics := []parser.Diagnostic{}
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		dependencyDir := addOnsDir
+		if dependencyDir == "" {
+			dependencyDir = filepath.Dir(path)
+		}
+		diagnostics = append(diagnostics, addOnParser.Verify(path, dependencyDir)...)
+	}
+	return diagnostics
+}
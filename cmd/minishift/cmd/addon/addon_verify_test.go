@@ -0,0 +1,60 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minishift/minishift/cmd/testing/cli"
+	"github.com/minishift/minishift/pkg/minishift/addon/parser"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_addon_path_must_be_specified_for_verify_command(t *testing.T) {
+	tmpMinishiftHomeDir := cli.SetupTmpMinishiftHome(t)
+	tee := cli.CreateTee(t, true)
+	defer cli.TearDown(tmpMinishiftHomeDir, tee)
+
+	atexit.RegisterExitHandler(cli.VerifyExitCodeAndMessage(t, tee, 1, emptyAddOnPathError))
+
+	runVerifyAddon(nil, nil)
+}
+
+func Test_verify_resolves_dependencies_against_parent_directory(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-verify-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	writeInfoTestAddOn(t, testDir, "base", "", "echo base\n", "")
+	writeInfoTestAddOn(t, testDir, "app", "# Depends-On: base\n", "echo #{addon-name}\n", "")
+
+	diagnostics := verifyAddOns([]string{filepath.Join(testDir, "app"), filepath.Join(testDir, "base")}, "")
+	assert.Equal(t, []parser.Diagnostic{}, diagnostics)
+
+	otherDir, err := ioutil.TempDir("", "minishift-test-addon-verify-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(otherDir)
+
+	diagnostics = verifyAddOns([]string{filepath.Join(testDir, "app")}, otherDir)
+	assert.Len(t, diagnostics, 1)
+	assert.Equal(t, "Dependency 'base' cannot be found in '"+otherDir+"'", diagnostics[0].Message)
+	assert.Equal(t, 3, diagnostics[0].Line)
+}
@@ -25,6 +25,7 @@ import (
 	"io"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/minishift/minishift/pkg/minishift/addon"
 	"github.com/minishift/minishift/pkg/minishift/addon/manager"
@@ -40,6 +41,8 @@ Url               : {{.Url}}
 Openshift Version : {{.RequiredOpenshiftVerison}}
 Enabled           : {{.Status}}
 Priority          : {{.Priority}}
+Apply Status      : {{.ApplyStatus}}
+Last Applied      : {{.LastApplied}}
 
 `
 var verboseListTemplate *template.Template
@@ -51,12 +54,14 @@ type DisplayAddOn struct {
 	Priority                 int
 	Url                      string
 	RequiredOpenshiftVerison string
+	ApplyStatus              string
+	LastApplied              string
 }
 
 var addonsListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "Lists all installed Minishift add-ons.",
-	Long:  "Lists all installed Minishift add-ons and their current status, such as enabled/disabled.",
+	Long:  "Lists all installed Minishift add-ons and their current status, such as enabled/disabled, as well as whether they are applied, stale or failed. An add-on is stale if its content changed since it was last applied.",
 	Run:   runListCommand,
 }
 
@@ -84,14 +89,20 @@ func printAddOnList(manager *manager.AddOnManager, writer io.Writer, template *t
 		description := strings.Join(addon.MetaData().Description(), fmt.Sprintf("\n%13s", " "))
 		addonInfo := DisplayAddOn{addon.MetaData().Name(), description,
 			stringFromStatus(addon.IsEnabled()), addon.GetPriority(),
-			addon.MetaData().Url(), addon.MetaData().OpenShiftVersion()}
+			addon.MetaData().Url(), addon.MetaData().OpenShiftVersion(), "", ""}
+		applyStatus, lastApply := manager.ApplyStatus(addon)
+		addonInfo.ApplyStatus = applyStatus
+		addonInfo.LastApplied = "-"
+		if lastApply != nil {
+			addonInfo.LastApplied = lastApply.Timestamp.Local().Format(time.RFC1123)
+		}
 		if verbose {
 			err := template.Execute(writer, addonInfo)
 			if err != nil {
 				atexit.ExitWithMessage(1, fmt.Sprintf("Error executing the template: %s", err.Error()))
 			}
 		} else {
-			fmt.Fprintln(display, fmt.Sprintf("- %s\t : %s\tP(%v)", addonInfo.Name, addonInfo.Status, addonInfo.Priority))
+			fmt.Fprintln(display, fmt.Sprintf("- %s\t : %s\tP(%v)\t %s", addonInfo.Name, addonInfo.Status, addonInfo.Priority, addonInfo.ApplyStatus))
 		}
 	}
 	display.Flush()
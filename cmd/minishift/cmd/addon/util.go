@@ -18,12 +18,16 @@ package addon
 
 import (
 	"fmt"
+	"io"
 	"reflect"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/docker/machine/libmachine/drivers"
 	"github.com/docker/machine/libmachine/provision"
 	"github.com/minishift/minishift/cmd/minishift/state"
 	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
 	addOnConfig "github.com/minishift/minishift/pkg/minishift/addon/config"
 	"github.com/minishift/minishift/pkg/minishift/addon/manager"
 	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
@@ -103,3 +107,24 @@ func determineRoutingSuffix(driver drivers.Driver) string {
 	// making assumptions about the master config here. In case the config structure changes, the code might panic here
 	return config["routingConfig"].(map[interface{}]interface{})["subdomain"].(string)
 }
+
+// printPlan prints the commands recorded during a dry-run of an add-on
+func printPlan(writer io.Writer, plan []command.PlannedCommand) {
+	display := new(tabwriter.Writer)
+	display.Init(writer, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(display, "TYPE\tIGNORE ERROR\tCOMMAND")
+	for _, c := range plan {
+		ignoreError := "no"
+		if c.IgnoreError {
+			ignoreError = "yes"
+		}
+		line := strings.Repeat("  ", c.Depth) + c.Command
+		if len(c.Unresolved) > 0 {
+			line = fmt.Sprintf("%s (unresolved: %s)", line, strings.Join(c.Unresolved, ", "))
+		}
+		fmt.Fprintln(display, fmt.Sprintf("%s\t%s\t%s", c.Type, ignoreError, line))
+	}
+	display.Flush()
+	fmt.Fprintln(writer)
+}
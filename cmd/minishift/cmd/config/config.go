@@ -59,6 +59,8 @@ var (
 	InsecureRegistry      = createConfigSetting("insecure-registry", SetSlice, nil, nil, true, nil)
 	RegistryMirror        = createConfigSetting("registry-mirror", SetSlice, nil, nil, true, nil)
 	AddonEnv              = createConfigSetting("addon-env", SetSlice, nil, nil, true, nil)
+	AddonWorkers          = createConfigSetting("addon-workers", SetInt, []setFn{validations.IsPositive}, nil, true, nil)
+	AllowHostCommands     = createConfigSetting("allow-host-commands", SetBool, nil, nil, true, nil)
 	RemoteIPAddress       = createConfigSetting("remote-ipaddress", SetString, nil, nil, true, nil)
 	RemoteSSHUser         = createConfigSetting("remote-ssh-user", SetString, nil, nil, true, nil)
 	SSHKeyToConnectRemote = createConfigSetting("remote-ssh-key", SetString, nil, nil, true, nil)
@@ -98,8 +100,11 @@ var (
 	HostFoldersAutoMount = createConfigSetting("hostfolders-automount", SetBool, nil, nil, true, nil)
 
 	// Services
-	ServicesSftpPort       = createConfigSetting("hostfolders-sftp-port", SetInt, []setFn{validations.IsValidPort}, nil, true, nil)
-	ServicesLocalProxyPort = createConfigSetting("services-proxy-port", SetInt, []setFn{validations.IsValidPort}, nil, true, nil)
+	ServicesSftpPort                = createConfigSetting("hostfolders-sftp-port", SetInt, []setFn{validations.IsValidPort}, nil, true, nil)
+	ServicesSftpIdleGracePeriod     = createConfigSetting("hostfolders-sftp-idle-grace-period", SetInt, []setFn{validations.IsPositive}, nil, true, nil)
+	ServicesHostFolderWatchInterval = createConfigSetting("hostfolders-watch-interval", SetInt, []setFn{validations.IsPositive}, nil, true, nil)
+	ServicesNfsPort                 = createConfigSetting("hostfolders-nfs-port", SetInt, []setFn{validations.IsValidPort}, nil, true, nil)
+	ServicesLocalProxyPort          = createConfigSetting("services-proxy-port", SetInt, []setFn{validations.IsValidPort}, nil, true, nil)
 
 	// No Provision
 	NoProvision = createConfigSetting("no-provision", SetBool, nil, nil, true, nil)
@@ -107,6 +112,10 @@ var (
 	// Image caching
 	ImageCaching = createConfigSetting("image-caching", SetBool, nil, nil, true, true)
 	CacheImages  = createConfigSetting("cache-images", SetSlice, nil, nil, false, nil)
+	ImageWorkers = createConfigSetting("image-workers", SetInt, []setFn{validations.IsPositive}, nil, true, nil)
+
+	ImageCacheMaxSize              = createConfigSetting("image-cache-max-size", SetString, []setFn{validations.IsValidDiskSize}, nil, true, nil)
+	ImageCacheMaxOpenShiftVersions = createConfigSetting("image-cache-max-openshift-versions", SetInt, []setFn{validations.IsPositive}, nil, true, nil)
 
 	// Pre-flight checks (before start)
 	SkipDeprecationCheck      = createConfigSetting("skip-check-deprecation", SetBool, nil, nil, true, nil)
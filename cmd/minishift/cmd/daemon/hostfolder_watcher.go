@@ -0,0 +1,141 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/docker/machine/libmachine/drivers"
+	machineState "github.com/docker/machine/libmachine/state"
+	"github.com/minishift/minishift/cmd/minishift/cmd/config"
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	hostFolderWatcherIntervalFlag = "interval"
+	hostFolderWatcherTimeoutFlag  = "timeout"
+
+	hostFolderWatcherLogFile = "hostfolder-watcher.log"
+)
+
+var (
+	hostFolderWatcherInterval int
+	hostFolderWatcherTimeout  int
+
+	daemonHostFolderWatcherCmd = &cobra.Command{
+		Use:    "hostfolder-watcher",
+		Short:  "Remounts stale host folders of the Minishift VM.",
+		Long:   `Periodically probes the host folders of the Minishift VM and remounts the stale ones. Events are logged to the logs directory of the instance.`,
+		Run:    runHostFolderWatcher,
+		Hidden: true,
+	}
+)
+
+func init() {
+	daemonHostFolderWatcherCmd.Flags().IntVar(&hostFolderWatcherInterval, hostFolderWatcherIntervalFlag, int(hostfolder.DefaultWatchInterval/time.Second), "The number of seconds between two probes of the host folders.")
+	daemonHostFolderWatcherCmd.Flags().IntVar(&hostFolderWatcherTimeout, hostFolderWatcherTimeoutFlag, int(hostfolder.DefaultProbeTimeout/time.Second), "The number of seconds after which a host folder not responding is stale.")
+	DaemonCmd.AddCommand(daemonHostFolderWatcherCmd)
+}
+
+func runHostFolderWatcher(cmd *cobra.Command, args []string) {
+	if minishiftConfig.InstanceConfig == nil || minishiftConfig.AllInstancesConfig == nil {
+		atexit.ExitWithMessage(1, "Usage: minishift daemon hostfolder-watcher --profile PROFILE")
+	}
+
+	interval := hostFolderWatcherInterval
+	if !cmd.Flags().Changed(hostFolderWatcherIntervalFlag) && viper.GetInt(config.ServicesHostFolderWatchInterval.Name) > 0 {
+		interval = viper.GetInt(config.ServicesHostFolderWatchInterval.Name)
+	}
+
+	logWriter, err := openHostFolderWatcherLog()
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Unable to open the log of the host folder watcher: %v", err))
+	}
+	defer logWriter.Close()
+	logger := log.New(logWriter, "", log.LstdFlags)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	logger.Printf("Watching the host folders of profile '%s' every %d seconds", constants.ProfileName, interval)
+	watcher := hostfolder.NewMountWatcher(time.Duration(hostFolderWatcherTimeout)*time.Second, logger)
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		checkHostFolders(watcher, logger)
+		select {
+		case <-ticker.C:
+		case <-signals:
+			logger.Printf("Stopped watching the host folders of profile '%s'", constants.ProfileName)
+			return
+		}
+	}
+}
+
+// checkHostFolders reloads the configuration and the VM, since host folders might have been added or the VM
+// re-created since the last check, and checks the host folders if the VM is running.
+func checkHostFolders(watcher *hostfolder.MountWatcher, logger *log.Logger) {
+	instanceConfig, err := minishiftConfig.NewInstanceConfig(minishiftConfig.InstanceConfig.FilePath)
+	if err != nil {
+		logger.Printf("Unable to read the instance configuration: %v", err)
+		return
+	}
+	allInstancesConfig, err := minishiftConfig.NewAllInstancesConfig(minishiftConfig.AllInstancesConfig.FilePath)
+	if err != nil {
+		logger.Printf("Unable to read the all instances configuration: %v", err)
+		return
+	}
+	// the manager looks up host folders in the global configurations
+	minishiftConfig.InstanceConfig, minishiftConfig.AllInstancesConfig = instanceConfig, allInstancesConfig
+
+	manager, _ := hostfolder.NewManager(instanceConfig, allInstancesConfig)
+	if !manager.ExistAny() {
+		return
+	}
+
+	api := libmachine.NewClient(state.InstanceDirs.Home, state.InstanceDirs.Certs)
+	defer api.Close()
+	host, err := api.Load(constants.MachineName)
+	if err != nil {
+		return
+	}
+	if !drivers.MachineInState(host.Driver, machineState.Running)() {
+		return
+	}
+
+	watcher.Check(manager, host.Driver)
+}
+
+func openHostFolderWatcherLog() (*os.File, error) {
+	if err := os.MkdirAll(state.InstanceDirs.Logs, 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(state.InstanceDirs.Logs, hostFolderWatcherLogFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/golang/glog"
+	"github.com/minishift/minishift/cmd/minishift/cmd/config"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	nfsdServerPortFlag    = "port"
+	nfsdServerAddressFlag = "address"
+)
+
+var (
+	nfsdServerPort    int
+	nfsdServerAddress string
+
+	daemonNfsdCmd = &cobra.Command{
+		Use:    "nfsd",
+		Short:  "Starts NFS server on host for nfs based host folders.",
+		Long:   `Starts NFS server on host for nfs based host folders. Only the VMs registered as clients can connect.`,
+		Run:    runNfsd,
+		Hidden: true,
+	}
+)
+
+func init() {
+	daemonNfsdCmd.Flags().IntVarP(&nfsdServerPort, nfsdServerPortFlag, "p", 2023, "The server port.")
+	daemonNfsdCmd.Flags().StringVar(&nfsdServerAddress, nfsdServerAddressFlag, "", "The IP of the host-only interface to listen on.")
+	DaemonCmd.AddCommand(daemonNfsdCmd)
+}
+
+func runNfsd(cmd *cobra.Command, args []string) {
+	port := viper.GetInt(config.ServicesNfsPort.Name)
+	if port == 0 {
+		port = nfsdServerPort
+	}
+
+	if net.ParseIP(nfsdServerAddress) == nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Invalid address '%s'. The IP of the host-only interface is required", nfsdServerAddress))
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(nfsdServerAddress, strconv.Itoa(port)))
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Failed to listen on %s port %d: %v", nfsdServerAddress, port, err))
+	}
+	glog.Infof("listening on %v", listener.Addr())
+
+	nfsServer := hostfolder.NewNfsServer(configuredHostFolders)
+	rpcServer := nfsServer.RPCServer()
+	rpcServer.Accept = func(addr net.Addr) bool {
+		if !isNfsdClient(addr, nfsdClients()) {
+			glog.Errorf("Rejecting connection from %v, which is not a registered client", addr)
+			return false
+		}
+		// host folders might have been added or removed since the last connection
+		nfsServer.Reload()
+		glog.Infof("Connection established with %v", addr)
+		return true
+	}
+
+	if err := rpcServer.Serve(listener); err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Failed to accept connections: %v", err))
+	}
+}
+
+// nfsdClients reads the IP addresses of the VMs allowed to connect. They are re-read for each connection, since the
+// IP of a VM changes when it is re-created.
+func nfsdClients() []string {
+	if minishiftConfig.AllInstancesConfig == nil {
+		return nil
+	}
+
+	allInstancesConfig, err := minishiftConfig.NewAllInstancesConfig(minishiftConfig.AllInstancesConfig.FilePath)
+	if err != nil {
+		glog.Errorf("Unable to read the clients of the NFS daemon: %v", err)
+		return nil
+	}
+
+	var clients []string
+	for _, client := range allInstancesConfig.NfsdClients {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// isNfsdClient returns whether the remote address is one of the specified client IPs.
+func isNfsdClient(addr net.Addr, clients []string) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+
+	for _, client := range clients {
+		if ip := net.ParseIP(client); ip != nil && ip.Equal(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_only_registered_clients_are_accepted(t *testing.T) {
+	clients := []string{"192.168.99.100", "not-an-ip"}
+
+	assert.True(t, isNfsdClient(&net.TCPAddr{IP: net.ParseIP("192.168.99.100"), Port: 812}, clients))
+	assert.False(t, isNfsdClient(&net.TCPAddr{IP: net.ParseIP("192.168.99.101"), Port: 812}, clients))
+	assert.False(t, isNfsdClient(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 812}, clients))
+	assert.False(t, isNfsdClient(&net.UnixAddr{Name: "/tmp/nfsd", Net: "unix"}, clients))
+	assert.False(t, isNfsdClient(&net.TCPAddr{IP: net.ParseIP("192.168.99.100"), Port: 812}, nil))
+}
@@ -18,29 +18,40 @@ package daemon
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/golang/glog"
 	"github.com/minishift/minishift/cmd/minishift/cmd/config"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
 	minishiftConstants "github.com/minishift/minishift/pkg/minishift/constants"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	hostFolderConfig "github.com/minishift/minishift/pkg/minishift/hostfolder/config"
 	"github.com/minishift/minishift/pkg/util/filehelper"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
 	"github.com/pkg/sftp"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"golang.org/x/crypto/ssh"
-	"io"
-	"io/ioutil"
-	"net"
-	"os"
-	"sync/atomic"
 )
 
 const (
-	sftpdServerPortFlag = "port"
+	sftpdServerPortFlag      = "port"
+	sftpdIdleGracePeriodFlag = "idle-grace-period"
+
+	// sftpdHandshakeTimeout limits the time a client may take for the SSH handshake, so that clients which never
+	// complete it do not keep their connection open
+	sftpdHandshakeTimeout = 30 * time.Second
 )
 
 var (
-	connectionCount uint64
-	sftpdServerPort int
+	sftpdServerPort      int
+	sftpdIdleGracePeriod int
 
 	daemonSftpdCmd = &cobra.Command{
 		Use:    "sftpd",
@@ -49,119 +60,314 @@ var (
 		Run:    runSftp,
 		Hidden: true,
 	}
-
-	authorizedKeysMap map[string]bool
 )
 
 func init() {
-	authorizedKeysMap = make(map[string]bool)
 	daemonSftpdCmd.Flags().IntVarP(&sftpdServerPort, sftpdServerPortFlag, "p", 2022, "The server port.")
+	daemonSftpdCmd.Flags().IntVar(&sftpdIdleGracePeriod, sftpdIdleGracePeriodFlag, 60, "The number of seconds the server keeps running without any sftp session.")
 	DaemonCmd.AddCommand(daemonSftpdCmd)
 }
 
+// sftpServer accepts SSH connections and serves the sftp subsystem of each of them in its own goroutine. Errors of a
+// connection are logged and only close this connection. The server exits once no sftp session has been active for
+// the idle grace period.
+type sftpServer struct {
+	config          *ssh.ServerConfig
+	idleGracePeriod time.Duration
+	statusPath      string
+	status          hostfolder.SftpdStatus
+
+	// authorizedKeys is re-read for each connection, since the keys of the VM change when it is re-created
+	authorizedKeys      map[string]bool
+	authorizedKeysMutex sync.RWMutex
+
+	activeSessions    int64
+	totalConnections  uint64
+	failedConnections uint64
+
+	idleMutex sync.Mutex
+	idleTimer *time.Timer
+}
+
 func runSftp(cmd *cobra.Command, args []string) {
-	serverConfig := serverConfig()
 	port := viper.GetInt(config.ServicesSftpPort.Name)
 	if port == 0 {
 		port = sftpdServerPort
 	}
+	idleGracePeriod := viper.GetInt(config.ServicesSftpIdleGracePeriod.Name)
+	if idleGracePeriod == 0 {
+		idleGracePeriod = sftpdIdleGracePeriod
+	}
 
 	// Once a ServerConfig has been configured, connections can be accepted.
 	listener, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", port))
 	if err != nil {
-		glog.Fatal("failed to listen for connection", err)
+		atexit.ExitWithMessage(1, fmt.Sprintf("Failed to listen on port %d: %v", port, err))
 	}
 	glog.Infof("listening on %v", listener.Addr())
 
-	serveConnections(listener, serverConfig)
+	server := newSftpServer(port, time.Duration(idleGracePeriod)*time.Second)
+	server.config = server.serverConfig()
+	go server.heartbeat()
+
+	if err := server.serveConnections(listener); err != nil {
+		server.shutdown(1, fmt.Sprintf("Failed to accept connections: %v", err))
+	}
+}
+
+func newSftpServer(port int, idleGracePeriod time.Duration) *sftpServer {
+	server := &sftpServer{
+		idleGracePeriod: idleGracePeriod,
+		authorizedKeys:  make(map[string]bool),
+		status: hostfolder.SftpdStatus{
+			PID:     os.Getpid(),
+			Port:    port,
+			Started: time.Now(),
+		},
+	}
+	if minishiftConfig.AllInstancesConfig != nil {
+		server.statusPath = hostfolder.SftpdStatusPath(minishiftConfig.AllInstancesConfig.FilePath)
+	}
+	// a daemon to which no client ever connects must not run forever either
+	server.startIdleTimer()
+	return server
 }
 
-func serveConnections(listener net.Listener, serverConfig *ssh.ServerConfig) {
+// serveConnections accepts connections until the listener fails permanently.
+func (s *sftpServer) serveConnections(listener net.Listener) error {
 	for {
-		nConn, err := listener.Accept()
+		conn, err := listener.Accept()
 		if err != nil {
-			glog.Fatal("failed to accept incoming connection", err)
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				glog.Errorf("Failed to accept incoming connection: %v", err)
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
 		}
 
-		// populate authorized keys for handshake
-		populateAuthorizedKeysMap()
-		// Before use, a handshake must be performed on the incoming net.Conn.
-		_, channels, requests, err := ssh.NewServerConn(nConn, serverConfig)
+		atomic.AddUint64(&s.totalConnections, 1)
+		go s.handleConnection(conn)
+	}
+}
+
+// handleConnection performs the SSH handshake and serves the channels of a single connection.
+func (s *sftpServer) handleConnection(conn net.Conn) {
+	defer conn.Close()
+	remote := conn.RemoteAddr()
+
+	// populate authorized keys for handshake
+	if err := s.populateAuthorizedKeys(); err != nil {
+		glog.Errorf("Rejecting connection from %v: %v", remote, err)
+		atomic.AddUint64(&s.failedConnections, 1)
+		return
+	}
+
+	// Before use, a handshake must be performed on the incoming net.Conn.
+	conn.SetDeadline(time.Now().Add(sftpdHandshakeTimeout))
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		glog.Errorf("Failed to handshake with %v: %v", remote, err)
+		atomic.AddUint64(&s.failedConnections, 1)
+		return
+	}
+	conn.SetDeadline(time.Time{})
+	defer serverConn.Close()
+	glog.Infof("SSH connection established with %v", remote)
+
+	// The incoming Request channel must be serviced.
+	go ssh.DiscardRequests(requests)
+
+	// Service the incoming Channel channel.
+	var wg sync.WaitGroup
+	for newChannel := range channels {
+		// Channels have a type, depending on the application level
+		// protocol intended. In the case of an SFTP session, this is "subsystem"
+		// with a payload string of "<length=4>sftp"
+		glog.Infof("Incoming channel from %v: %s", remote, newChannel.ChannelType())
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
 		if err != nil {
-			glog.Fatal("failed to handshake", err)
+			glog.Errorf("Could not accept channel from %v: %v", remote, err)
+			continue
 		}
-		glog.Info("SSH server established")
 
-		// The incoming Request channel must be serviced.
-		go ssh.DiscardRequests(requests)
-
-		// Service the incoming Channel channel.
+		wg.Add(1)
 		go func() {
-			for newChannel := range channels {
-				// Channels have a type, depending on the application level
-				// protocol intended. In the case of an SFTP session, this is "subsystem"
-				// with a payload string of "<length=4>sftp"
-				glog.Infof("Incoming channel: %s", newChannel.ChannelType())
-				if newChannel.ChannelType() != "session" {
-					newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
-					continue
-				}
-				channel, requests, err := newChannel.Accept()
-				if err != nil {
-					glog.Fatal("could not accept channel.", err)
-				}
+			defer wg.Done()
+			s.handleSession(remote, channel, requests)
+		}()
+	}
+	wg.Wait()
+	glog.Infof("SSH connection with %v closed", remote)
+}
 
-				// Sessions have out-of-band requests such as "shell",
-				// "pty-req" and "env".  Here we handle only the
-				// "subsystem" request.
-				go func(in <-chan *ssh.Request) {
-					for req := range in {
-						glog.Infof("Request: %v", req.Type)
-						ok := false
-						switch req.Type {
-						case "subsystem":
-							glog.Infof("Subsystem: %s", req.Payload[4:])
-							if string(req.Payload[4:]) == "sftp" {
-								ok = true
-								atomic.AddUint64(&connectionCount, 1)
-							}
-						}
-						req.Reply(ok, nil)
-					}
-				}(requests)
-
-				serverOptions := []sftp.ServerOption{
-					sftp.WithDebug(os.Stderr),
-				}
-				server, err := sftp.NewServer(
-					channel,
-					serverOptions...,
-				)
-				if err != nil {
-					glog.Fatal(err)
-				}
-				if err := server.Serve(); err == io.EOF {
-					server.Close()
-					atomic.AddUint64(&connectionCount, ^uint64(0))
-					currentCount := atomic.LoadUint64(&connectionCount)
-					if currentCount == 0 {
-						glog.Info("last sftp client exited.")
-						os.Exit(0)
-					} else {
-						glog.Info("sftp client exited session.")
-					}
-				} else if err != nil {
-					glog.Fatal("sftp server completed with error:", err)
+// handleSession serves the sftp subsystem once the client requested it. Sessions have out-of-band requests such as
+// "shell", "pty-req" and "env", which are rejected.
+func (s *sftpServer) handleSession(remote net.Addr, channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+
+	serving := false
+	done := make(chan struct{})
+	for {
+		select {
+		case req, ok := <-requests:
+			if !ok {
+				if serving {
+					<-done
 				}
+				return
 			}
-		}()
+			glog.Infof("Request from %v: %v", remote, req.Type)
+			accepted := !serving && req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+			req.Reply(accepted, nil)
+			if accepted {
+				serving = true
+				go func() {
+					defer close(done)
+					s.serveSftp(remote, channel)
+				}()
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+func (s *sftpServer) serveSftp(remote net.Addr, channel ssh.Channel) {
+	s.sessionStarted()
+	defer s.sessionEnded()
+
+	// only the configured host folders are served, each of them jailed to its source
+	fileSystem := hostfolder.NewSftpFileSystem(configuredHostFolders())
+	server := sftp.NewRequestServer(channel, fileSystem.Handlers())
+	if err := server.Serve(); err != nil && err != io.EOF {
+		glog.Errorf("sftp session with %v completed with error: %v", remote, err)
+	} else {
+		glog.Infof("sftp client %v exited session.", remote)
+	}
+	server.Close()
+}
+
+func (s *sftpServer) sessionStarted() {
+	s.idleMutex.Lock()
+	defer s.idleMutex.Unlock()
+
+	s.activeSessions++
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+		s.idleTimer = nil
+	}
+}
+
+func (s *sftpServer) sessionEnded() {
+	s.idleMutex.Lock()
+	defer s.idleMutex.Unlock()
+
+	s.activeSessions--
+	if s.activeSessions == 0 {
+		glog.Infof("last sftp client exited, shutting down in %v unless a new session is started.", s.idleGracePeriod)
+		s.startIdleTimerLocked()
 	}
 }
 
-func serverConfig() *ssh.ServerConfig {
+func (s *sftpServer) startIdleTimer() {
+	s.idleMutex.Lock()
+	defer s.idleMutex.Unlock()
+	s.startIdleTimerLocked()
+}
+
+func (s *sftpServer) startIdleTimerLocked() {
+	var timer *time.Timer
+	timer = time.AfterFunc(s.idleGracePeriod, func() {
+		s.idleMutex.Lock()
+		// the timer might have been replaced or stopped after it fired
+		idle := s.idleTimer == timer && s.activeSessions == 0
+		s.idleMutex.Unlock()
+		if idle {
+			s.shutdown(0, "")
+		}
+	})
+	s.idleTimer = timer
+}
+
+// heartbeat writes the status file of the daemon periodically, so that 'minishift services list' can tell whether
+// the daemon is alive.
+func (s *sftpServer) heartbeat() {
+	if s.statusPath == "" {
+		return
+	}
+	for {
+		if err := hostfolder.WriteSftpdStatus(s.statusPath, s.currentStatus()); err != nil {
+			glog.Errorf("Failed to write the sftpd status: %v", err)
+		}
+		time.Sleep(hostfolder.SftpdHeartbeatInterval)
+	}
+}
+
+func (s *sftpServer) currentStatus() *hostfolder.SftpdStatus {
+	s.idleMutex.Lock()
+	activeSessions := s.activeSessions
+	s.idleMutex.Unlock()
+
+	status := s.status
+	status.Heartbeat = time.Now()
+	status.ActiveSessions = activeSessions
+	status.TotalConnections = atomic.LoadUint64(&s.totalConnections)
+	status.FailedConnections = atomic.LoadUint64(&s.failedConnections)
+	return &status
+}
+
+func (s *sftpServer) shutdown(code int, message string) {
+	if s.statusPath != "" {
+		os.Remove(s.statusPath)
+	}
+	if message == "" {
+		glog.Info("shutting down idle sftp server.")
+		atexit.Exit(code)
+	}
+	atexit.ExitWithMessage(code, message)
+}
+
+// configuredHostFolders reads the host folders from the instance and all instances configuration. The configuration
+// is read for each connection, so that host folders added while the daemon is running can be mounted.
+func configuredHostFolders() []hostFolderConfig.HostFolderConfig {
+	return append(allInstancesHostFolders(), instanceHostFolders()...)
+}
+
+// allInstancesHostFolders reads the host folders from the all instances configuration.
+func allInstancesHostFolders() []hostFolderConfig.HostFolderConfig {
+	if minishiftConfig.AllInstancesConfig == nil {
+		return nil
+	}
+	allInstancesConfig, err := minishiftConfig.NewAllInstancesConfig(minishiftConfig.AllInstancesConfig.FilePath)
+	if err != nil {
+		glog.Errorf("Unable to read the host folders of all instances: %v", err)
+		return nil
+	}
+	return allInstancesConfig.HostFolders
+}
+
+// instanceHostFolders reads the host folders from the configuration of the instance selected by the profile.
+func instanceHostFolders() []hostFolderConfig.HostFolderConfig {
+	if minishiftConfig.InstanceConfig == nil {
+		return nil
+	}
+	instanceConfig, err := minishiftConfig.NewInstanceConfig(minishiftConfig.InstanceConfig.FilePath)
+	if err != nil {
+		glog.Errorf("Unable to read the host folders of the instance: %v", err)
+		return nil
+	}
+	return instanceConfig.HostFolders
+}
+
+func (s *sftpServer) serverConfig() *ssh.ServerConfig {
 	// An SSH server is represented by a ServerConfig, which holds certificate details and handles authentication
 	config := ssh.ServerConfig{
-		PublicKeyCallback: keyAuth,
+		PublicKeyCallback: s.keyAuth,
 	}
 
 	if filehelper.Exists(minishiftConstants.ProfilePrivateKeyPath()) {
@@ -180,29 +386,47 @@ func serverConfig() *ssh.ServerConfig {
 	return &config
 }
 
-// populateAuthorizedKeysMap populates the authorizedKeysMap map after reading the key from the
-// authorized_keys file.
+// populateAuthorizedKeys populates the authorized keys after reading them from the authorized_keys file.
 // authorized_keys file is maintained separately for each profile.
-func populateAuthorizedKeysMap() {
+func (s *sftpServer) populateAuthorizedKeys() error {
 	authorizedKeysBytes, err := ioutil.ReadFile(minishiftConstants.ProfileAuthorizedKeysPath())
 	if err != nil {
-		glog.Fatalf("Failed to load authorized_keys, err: %v", err)
+		return fmt.Errorf("failed to load authorized_keys: %v", err)
 	}
 
+	authorizedKeys, err := parseAuthorizedKeys(authorizedKeysBytes)
+	if err != nil {
+		return err
+	}
+
+	// keys removed from the file must not be accepted anymore
+	s.authorizedKeysMutex.Lock()
+	defer s.authorizedKeysMutex.Unlock()
+	s.authorizedKeys = authorizedKeys
+	return nil
+}
+
+func parseAuthorizedKeys(authorizedKeysBytes []byte) (map[string]bool, error) {
+	authorizedKeys := make(map[string]bool)
 	for len(authorizedKeysBytes) > 0 {
 		pubKey, _, _, rest, err := ssh.ParseAuthorizedKey(authorizedKeysBytes)
 		if err != nil {
-			glog.Fatal(err)
+			return nil, fmt.Errorf("failed to parse authorized_keys: %v", err)
 		}
 
-		authorizedKeysMap[string(pubKey.Marshal())] = true
+		authorizedKeys[string(pubKey.Marshal())] = true
 		authorizedKeysBytes = rest
 	}
+	return authorizedKeys, nil
 }
 
-func keyAuth(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+func (s *sftpServer) keyAuth(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
 	// Reference link - https://github.com/golang/crypto/blob/master/ssh/example_test.go
-	if authorizedKeysMap[string(key.Marshal())] {
+	s.authorizedKeysMutex.RLock()
+	authorized := s.authorizedKeys[string(key.Marshal())]
+	s.authorizedKeysMutex.RUnlock()
+
+	if authorized {
 		permissions := ssh.Permissions{
 			Extensions: map[string]string{
 				"pubkey-fp": ssh.FingerprintSHA256(key),
@@ -0,0 +1,131 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func Test_failed_handshake_does_not_stop_server(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	assert.NoError(t, err)
+
+	publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	testDir, err := ioutil.TempDir("", "minishift-test-sftpd-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+	minipath := constants.Minipath
+	constants.Minipath = testDir
+	defer func() { constants.Minipath = minipath }()
+	assert.NoError(t, os.MkdirAll(filepath.Join(testDir, "certs"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(testDir, "certs", "authorized_keys"), ssh.MarshalAuthorizedKey(publicKey), 0600))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	server := &sftpServer{idleGracePeriod: time.Hour, authorizedKeys: make(map[string]bool)}
+	server.config = &ssh.ServerConfig{PublicKeyCallback: server.keyAuth}
+	server.config.AddHostKey(signer)
+	go server.serveConnections(listener)
+
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		assert.NoError(t, err)
+		conn.Write([]byte("GET / HTTP/1.0\r\n\r\n"))
+		conn.Close()
+	}
+
+	// the server keeps accepting connections after failed handshakes
+	deadline := time.Now().Add(10 * time.Second)
+	for atomic.LoadUint64(&server.failedConnections) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, uint64(2), atomic.LoadUint64(&server.totalConnections))
+	assert.Equal(t, uint64(2), atomic.LoadUint64(&server.failedConnections))
+}
+
+func Test_parse_authorized_keys(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+
+	keys, err := parseAuthorizedKeys(ssh.MarshalAuthorizedKey(publicKey))
+	assert.NoError(t, err)
+	assert.True(t, keys[string(publicKey.Marshal())])
+
+	_, err = parseAuthorizedKeys([]byte("not a key"))
+	assert.Error(t, err)
+}
+
+func Test_removed_authorized_keys_are_rejected(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-sftpd-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+	minipath := constants.Minipath
+	constants.Minipath = testDir
+	defer func() { constants.Minipath = minipath }()
+	assert.NoError(t, os.MkdirAll(filepath.Join(testDir, "certs"), 0755))
+	authorizedKeysPath := filepath.Join(testDir, "certs", "authorized_keys")
+
+	var publicKeys []ssh.PublicKey
+	for i := 0; i < 2; i++ {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.NoError(t, err)
+		publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+		assert.NoError(t, err)
+		publicKeys = append(publicKeys, publicKey)
+	}
+
+	server := &sftpServer{idleGracePeriod: time.Hour, authorizedKeys: make(map[string]bool)}
+	assert.NoError(t, ioutil.WriteFile(authorizedKeysPath, ssh.MarshalAuthorizedKey(publicKeys[0]), 0600))
+	assert.NoError(t, server.populateAuthorizedKeys())
+	_, err = server.keyAuth(dockerConnMetadata{}, publicKeys[0])
+	assert.NoError(t, err)
+
+	// the VM got re-created with a new key
+	assert.NoError(t, ioutil.WriteFile(authorizedKeysPath, ssh.MarshalAuthorizedKey(publicKeys[1]), 0600))
+	assert.NoError(t, server.populateAuthorizedKeys())
+	_, err = server.keyAuth(dockerConnMetadata{}, publicKeys[0])
+	assert.Error(t, err)
+	_, err = server.keyAuth(dockerConnMetadata{}, publicKeys[1])
+	assert.NoError(t, err)
+}
+
+type dockerConnMetadata struct {
+	ssh.ConnMetadata
+}
+
+func (dockerConnMetadata) User() string {
+	return "docker"
+}
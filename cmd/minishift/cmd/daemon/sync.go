@@ -0,0 +1,101 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/golang/glog"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	hostFolderConfig "github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+const (
+	syncSSHHostFlag = "ssh-host"
+	syncSSHPortFlag = "ssh-port"
+	syncSSHUserFlag = "ssh-user"
+	syncSSHKeyFlag  = "ssh-key"
+)
+
+var (
+	syncSSHConfig hostfolder.SyncSSHConfig
+
+	daemonSyncCmd = &cobra.Command{
+		Use:    "sync HOST_FOLDER_NAME --profile PROFILE",
+		Short:  "Keeps a sync host folder in sync with its copy in the VM.",
+		Long:   `Keeps a sync host folder in sync with its copy in the VM. Changes on the host are pushed via SSH, changes in the VM are pulled back if enabled.`,
+		Run:    runSync,
+		Hidden: true,
+	}
+)
+
+func init() {
+	daemonSyncCmd.Flags().StringVar(&syncSSHConfig.Host, syncSSHHostFlag, "", "The SSH host of the VM.")
+	daemonSyncCmd.Flags().IntVar(&syncSSHConfig.Port, syncSSHPortFlag, 22, "The SSH port of the VM.")
+	daemonSyncCmd.Flags().StringVar(&syncSSHConfig.User, syncSSHUserFlag, "docker", "The SSH user of the VM.")
+	daemonSyncCmd.Flags().StringVar(&syncSSHConfig.KeyPath, syncSSHKeyFlag, "", "The private SSH key of the VM.")
+	DaemonCmd.AddCommand(daemonSyncCmd)
+}
+
+func runSync(cmd *cobra.Command, args []string) {
+	if len(args) != 1 || minishiftConfig.AllInstancesConfig == nil {
+		atexit.ExitWithMessage(1, "Usage: minishift daemon sync HOST_FOLDER_NAME --profile PROFILE")
+	}
+	name := args[0]
+
+	// host folders of the instance take precedence over the ones of all instances, like in the host folder manager
+	profile := constants.ProfileName
+	hostFolder := syncHostFolder(name, instanceHostFolders())
+	if hostFolder == nil {
+		profile = ""
+		hostFolder = syncHostFolder(name, allInstancesHostFolders())
+	}
+	if hostFolder == nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("No sync host folder with name '%s' defined", name))
+	}
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	statusPath := hostfolder.SyncStatusPath(minishiftConfig.AllInstancesConfig.FilePath, profile, name)
+	if err := hostfolder.RunSync(*hostFolder, syncSSHConfig, statusPath, stop); err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Failed to sync host folder '%s': %v", name, err))
+	}
+	glog.Infof("Stopped syncing host folder '%s'", name)
+}
+
+// syncHostFolder returns the sync host folder with the specified name, nil if there is none.
+func syncHostFolder(name string, hostFolders []hostFolderConfig.HostFolderConfig) *hostFolderConfig.HostFolderConfig {
+	for i := range hostFolders {
+		if hostFolders[i].Name == name && hostFolders[i].Type == hostfolder.SYNC.String() {
+			return &hostFolders[i]
+		}
+	}
+	return nil
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/minishift/minishift/pkg/minikube/cluster"
 	"github.com/minishift/minishift/pkg/minikube/constants"
 	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
 	"github.com/minishift/minishift/pkg/minishift/oc"
 	pkgUtil "github.com/minishift/minishift/pkg/util"
 	"github.com/minishift/minishift/pkg/util/filehelper"
@@ -101,6 +102,9 @@ func runDelete(cmd *cobra.Command, args []string) {
 	if err := cluster.DeleteHost(api); err != nil {
 		handleFailedHostDeletion(err)
 	}
+	if err := hostfolder.RemoveNfsdClient(minishiftConfig.AllInstancesConfig, constants.ProfileName); err != nil {
+		fmt.Println("Unable to remove the VM from the clients of the NFS daemon:", err)
+	}
 
 	removeInstanceAndKubeConfig()
 
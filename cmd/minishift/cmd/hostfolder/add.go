@@ -19,6 +19,7 @@ package hostfolder
 import (
 	"fmt"
 	"runtime"
+	"strconv"
 	"strings"
 
 	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
@@ -40,6 +41,7 @@ const (
 	noPassword           = "you need to specify a password"
 	noDomain             = "you need to specify the Windows domain"
 	unknownType          = "'%s' is an unknown host folder type"
+	readOnlyNotSupported = "the read-only option is only supported for sshfs and nfs host folders"
 	nonSupportedTtyError = "not a tty supported terminal"
 	shareTypeFlag        = "type"
 	sourceFlag           = "source"
@@ -48,12 +50,14 @@ const (
 	interactiveFlag      = "interactive"
 	instanceOnlyFlag     = "instance-only"
 	usersShareFlag       = "users-share"
+	readOnlyFlag         = "read-only"
 )
 
 var (
 	instanceOnly bool
 	usersShare   bool
 	interactive  bool
+	readOnly     bool
 	shareType    string
 	source       string
 	target       string
@@ -69,12 +73,13 @@ var addCmd = &cobra.Command{
 
 func init() {
 	HostFolderCmd.AddCommand(addCmd)
-	addCmd.Flags().StringVarP(&shareType, shareTypeFlag, "t", "sshfs", "The host folder type. Allowed types are [cifs|sshfs].")
+	addCmd.Flags().StringVarP(&shareType, shareTypeFlag, "t", "sshfs", "The host folder type. Allowed types are [cifs|sshfs|nfs|sync].")
 	addCmd.Flags().StringVar(&source, sourceFlag, "", "The source of the host folder.")
 	addCmd.Flags().StringVar(&target, targetFlag, "", "The target (mount point) of the host folder.")
 	addCmd.Flags().StringVar(&options, optionsFlag, "", "Host folder type specific options.")
 	addCmd.Flags().BoolVar(&instanceOnly, instanceOnlyFlag, false, "Defines the host folder only for the current Minishift instance.")
 	addCmd.Flags().BoolVarP(&interactive, interactiveFlag, "i", false, "Allows to interactively provide the required parameters.")
+	addCmd.Flags().BoolVar(&readOnly, readOnlyFlag, false, "Shares the host folder read-only. Only supported for sshfs and nfs host folders.")
 
 	// Windows-only
 	if runtime.GOOS == "windows" {
@@ -113,6 +118,9 @@ func addHostFolder(cmd *cobra.Command, args []string) {
 
 	switch shareType {
 	case hostFolderConfig.CIFS.String():
+		if readOnly {
+			atexit.ExitWithMessage(1, readOnlyNotSupported)
+		}
 		if interactive {
 			addCIFSInteractive(hostFolderManager, name)
 		} else {
@@ -124,12 +132,90 @@ func addHostFolder(cmd *cobra.Command, args []string) {
 		} else {
 			addSSHFSNonInteractive(hostFolderManager, name)
 		}
+	case hostFolderConfig.SYNC.String():
+		if readOnly {
+			atexit.ExitWithMessage(1, readOnlyNotSupported)
+		}
+		if interactive {
+			addSyncInteractive(hostFolderManager, name)
+		} else {
+			addSyncNonInteractive(hostFolderManager, name)
+		}
+	case hostFolderConfig.NFS.String():
+		if interactive {
+			addNFSInteractive(hostFolderManager, name)
+		} else {
+			addNFSNonInteractive(hostFolderManager, name)
+		}
 	default:
 		atexit.ExitWithMessage(1, fmt.Sprintf(unknownType, shareType))
 	}
 }
 
 func addSSHFSInteractive(manager *hostFolderConfig.Manager, name string) error {
+	config := readLocalHostFolderInteractive(name, hostFolderConfig.SSHFS)
+	hostFolder := hostFolderConfig.NewSSHFSHostFolder(config, minishiftConfig.AllInstancesConfig)
+	manager.Add(hostFolder, !instanceOnly)
+
+	return nil
+}
+
+func addSSHFSNonInteractive(manager *hostFolderConfig.Manager, name string) error {
+	config := localHostFolderNonInteractive(name, hostFolderConfig.SSHFS)
+	hostFolder := hostFolderConfig.NewSSHFSHostFolder(config, minishiftConfig.AllInstancesConfig)
+	manager.Add(hostFolder, !instanceOnly)
+
+	return nil
+}
+
+func addNFSInteractive(manager *hostFolderConfig.Manager, name string) error {
+	config := readLocalHostFolderInteractive(name, hostFolderConfig.NFS)
+	hostFolder := hostFolderConfig.NewNfsHostFolder(config, minishiftConfig.AllInstancesConfig)
+	manager.Add(hostFolder, !instanceOnly)
+
+	return nil
+}
+
+func addNFSNonInteractive(manager *hostFolderConfig.Manager, name string) error {
+	config := localHostFolderNonInteractive(name, hostFolderConfig.NFS)
+	hostFolder := hostFolderConfig.NewNfsHostFolder(config, minishiftConfig.AllInstancesConfig)
+	manager.Add(hostFolder, !instanceOnly)
+
+	return nil
+}
+
+func addSyncInteractive(manager *hostFolderConfig.Manager, name string) error {
+	folderConfig := readLocalHostFolderInteractive(name, hostFolderConfig.SYNC)
+	delete(folderConfig.Options, config.ReadOnly)
+	folderConfig.Options[config.Excludes] = util.ReadInputFromStdin("Exclude patterns, separated by commas")
+	pullBack := strings.ToLower(util.ReadInputFromStdin("Pull back changes made in the VM [y/N]"))
+	folderConfig.Options[config.PullBack] = strconv.FormatBool(pullBack == "y" || pullBack == "yes")
+
+	hostFolder := hostFolderConfig.NewSyncHostFolder(folderConfig, minishiftConfig.AllInstancesConfig, instanceOnly)
+	manager.Add(hostFolder, !instanceOnly)
+
+	return nil
+}
+
+func addSyncNonInteractive(manager *hostFolderConfig.Manager, name string) error {
+	folderConfig := localHostFolderNonInteractive(name, hostFolderConfig.SYNC)
+	optionsMap := getOptions(options)
+	folderConfig.Options = map[string]string{
+		config.Source:     folderConfig.Options[config.Source],
+		config.MountPoint: folderConfig.Options[config.MountPoint],
+		config.Excludes:   optionsMap[config.Excludes],
+		config.PullBack:   strconv.FormatBool(optionsMap[config.PullBack] == "true"),
+	}
+
+	hostFolder := hostFolderConfig.NewSyncHostFolder(folderConfig, minishiftConfig.AllInstancesConfig, instanceOnly)
+	manager.Add(hostFolder, !instanceOnly)
+
+	return nil
+}
+
+// readLocalHostFolderInteractive reads the config of a host folder served by minishift itself, ie a sshfs, nfs or sync
+// host folder.
+func readLocalHostFolderInteractive(name string, folderType hostFolderConfig.Type) config.HostFolderConfig {
 	source := util.ReadInputFromStdin("Source path")
 	source, err := homedir.Expand(source)
 	if err != nil {
@@ -146,21 +232,19 @@ func addSSHFSInteractive(manager *hostFolderConfig.Manager, name string) error {
 
 	mountPath := readInputForMountPoint(name)
 
-	config := config.HostFolderConfig{
+	return config.HostFolderConfig{
 		Name: name,
-		Type: hostFolderConfig.SSHFS.String(),
+		Type: folderType.String(),
 		Options: map[string]string{
 			config.Source:     source,
 			config.MountPoint: mountPath,
+			config.ReadOnly:   strconv.FormatBool(readOnly),
 		},
 	}
-	hostFolder := hostFolderConfig.NewSSHFSHostFolder(config, minishiftConfig.AllInstancesConfig)
-	manager.Add(hostFolder, !instanceOnly)
-
-	return nil
 }
 
-func addSSHFSNonInteractive(manager *hostFolderConfig.Manager, name string) error {
+// localHostFolderNonInteractive creates the config of a host folder served by minishift itself from the flags.
+func localHostFolderNonInteractive(name string, folderType hostFolderConfig.Type) config.HostFolderConfig {
 	if source == "" {
 		atexit.ExitWithMessage(1, noSource)
 	}
@@ -173,19 +257,16 @@ func addSSHFSNonInteractive(manager *hostFolderConfig.Manager, name string) erro
 		atexit.ExitWithMessage(1, noTarget)
 	}
 
-	config := config.HostFolderConfig{
+	return config.HostFolderConfig{
 		Name: name,
-		Type: hostFolderConfig.SSHFS.String(),
+		Type: folderType.String(),
 		Options: map[string]string{
 			config.Source:       source,
 			config.MountPoint:   target,
 			config.ExtraOptions: options,
+			config.ReadOnly:     strconv.FormatBool(readOnly),
 		},
 	}
-	hostFolder := hostFolderConfig.NewSSHFSHostFolder(config, minishiftConfig.AllInstancesConfig)
-	manager.Add(hostFolder, !instanceOnly)
-
-	return nil
 }
 
 func addCIFSInteractive(manager *hostFolderConfig.Manager, name string) error {
@@ -283,7 +364,7 @@ func readNameAndTypeInteractive() (string, string) {
 	if name == "" {
 		atexit.ExitWithMessage(1, noName)
 	}
-	shareType := strings.ToLower(util.ReadInputFromStdin("Type [sshfs, cifs (S/c)]"))
+	shareType := strings.ToLower(util.ReadInputFromStdin("Type [sshfs, cifs, nfs, sync (S/c/n)]"))
 
 	if shareType == "s" || shareType == "" {
 		return name, hostFolderConfig.SSHFS.String()
@@ -293,5 +374,9 @@ func readNameAndTypeInteractive() (string, string) {
 		return name, hostFolderConfig.CIFS.String()
 	}
 
+	if shareType == "n" {
+		return name, hostFolderConfig.NFS.String()
+	}
+
 	return name, shareType
 }
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"github.com/minishift/minishift/cmd/testing/cli"
 	"github.com/minishift/minishift/pkg/minishift/config"
+	hostFolderConfig "github.com/minishift/minishift/pkg/minishift/hostfolder/config"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -158,3 +159,49 @@ func Test_unknown_host_folder_type_exits(t *testing.T) {
 	shareType = "snafu"
 	addHostFolder(nil, []string{"foo"})
 }
+
+func Test_read_only_not_supported_for_cifs(t *testing.T) {
+	var err error
+	tmpMinishiftHomeDir := cli.SetupTmpMinishiftHome(t)
+	config.InstanceConfig, err = config.NewInstanceConfig(filepath.Join(tmpMinishiftHomeDir, "config"))
+	config.AllInstancesConfig, err = config.NewAllInstancesConfig(filepath.Join(tmpMinishiftHomeDir, "config"))
+	assert.NoError(t, err, "Unexpected error setting instance config")
+
+	tee := cli.CreateTee(t, true)
+	defer cli.TearDown(tmpMinishiftHomeDir, tee)
+	defer viper.Reset()
+	defer func() { readOnly = false }()
+
+	atexit.RegisterExitHandler(cli.VerifyExitCodeAndMessage(t, tee, 1, readOnlyNotSupported))
+	source = "//127.0.0.1/share"
+	target = "/var/tmp"
+	shareType = "cifs"
+	readOnly = true
+	addHostFolder(nil, []string{"foo"})
+}
+
+func Test_add_read_only_nfs_host_folder(t *testing.T) {
+	var err error
+	tmpMinishiftHomeDir := cli.SetupTmpMinishiftHome(t)
+	config.InstanceConfig, err = config.NewInstanceConfig(filepath.Join(tmpMinishiftHomeDir, "config"))
+	assert.NoError(t, err, "Unexpected error setting instance config")
+	config.AllInstancesConfig, err = config.NewAllInstancesConfig(filepath.Join(tmpMinishiftHomeDir, "allinstances.json"))
+	assert.NoError(t, err, "Unexpected error setting all instances config")
+
+	tee := cli.CreateTee(t, true)
+	defer cli.TearDown(tmpMinishiftHomeDir, tee)
+	defer viper.Reset()
+	defer func() { readOnly = false }()
+
+	source = "/home/johndoe"
+	target = "/var/tmp"
+	shareType = "nfs"
+	readOnly = true
+	addHostFolder(nil, []string{"foo"})
+
+	assert.Len(t, config.AllInstancesConfig.HostFolders, 1)
+	hostFolder := config.AllInstancesConfig.HostFolders[0]
+	assert.Equal(t, "nfs", hostFolder.Type)
+	assert.Equal(t, "/home/johndoe", hostFolder.Option(hostFolderConfig.Source))
+	assert.True(t, hostFolder.IsReadOnly())
+}
@@ -23,10 +23,13 @@ import (
 	"github.com/minishift/minishift/cmd/minishift/cmd/util"
 	"github.com/minishift/minishift/cmd/minishift/state"
 	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
 	"github.com/spf13/cobra"
+	"io"
 	"os"
 	"text/tabwriter"
+	"time"
 )
 
 var listCmd = &cobra.Command{
@@ -44,25 +47,7 @@ var listCmd = &cobra.Command{
 			atexit.ExitWithMessage(1, err.Error())
 		}
 
-		w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
-		fmt.Fprintln(w, "Name\tType\tSource\tMountpoint\tMounted")
-
-		for _, info := range mountInfos {
-			mounted := "N"
-			if info.Mounted {
-				mounted = "Y"
-			}
-
-			fmt.Fprintln(w,
-				fmt.Sprintf("%s\t%s\t%s\t%s\t%s",
-					info.Name,
-					info.Type,
-					info.Source,
-					info.MountPoint,
-					mounted))
-		}
-
-		w.Flush()
+		printHostFolderList(os.Stdout, mountInfos, time.Now())
 	},
 }
 
@@ -82,3 +67,45 @@ func getDriver(api *libmachine.Client) drivers.Driver {
 func init() {
 	HostFolderCmd.AddCommand(listCmd)
 }
+
+// printHostFolderList prints the host folders as table. The sync status column is only shown if sync host folders
+// are defined.
+func printHostFolderList(out io.Writer, mountInfos []hostfolder.MountInfo, now time.Time) {
+	showSyncStatus := false
+	for _, info := range mountInfos {
+		if info.Type == hostfolder.SYNC.String() {
+			showSyncStatus = true
+		}
+	}
+
+	w := tabwriter.NewWriter(out, 4, 8, 3, ' ', 0)
+	header := "Name\tType\tSource\tMountpoint\tMounted"
+	if showSyncStatus {
+		header += "\tSync Status"
+	}
+	fmt.Fprintln(w, header)
+
+	for _, info := range mountInfos {
+		mounted := "N"
+		if info.Mounted {
+			mounted = "Y"
+		}
+
+		line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s",
+			info.Name,
+			info.Type,
+			info.Source,
+			info.MountPoint,
+			mounted)
+		if showSyncStatus {
+			syncStatus := ""
+			if info.Type == hostfolder.SYNC.String() {
+				syncStatus = info.SyncStatus.Summary(now)
+			}
+			line = fmt.Sprintf("%s\t%s", line, syncStatus)
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,50 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_list_shows_sync_status_only_with_sync_host_folders(t *testing.T) {
+	now := time.Now()
+	infos := []hostfolder.MountInfo{
+		{Name: "share", Type: "sshfs", Source: "/home/john/share", MountPoint: "/mnt/sda1/share", Mounted: true},
+	}
+
+	var out bytes.Buffer
+	printHostFolderList(&out, infos, now)
+	assert.NotContains(t, out.String(), "Sync Status")
+
+	infos = append(infos, hostfolder.MountInfo{
+		Name:       "workspace",
+		Type:       "sync",
+		Source:     "/home/john/workspace",
+		MountPoint: "/mnt/sda1/workspace",
+		Mounted:    true,
+		SyncStatus: &hostfolder.SyncStatus{State: hostfolder.SyncStateWatching, Heartbeat: now},
+	})
+	out.Reset()
+	printHostFolderList(&out, infos, now)
+	assert.Contains(t, out.String(), "Sync Status")
+	assert.Contains(t, out.String(), "watching, lag 0s")
+}
@@ -0,0 +1,104 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/minishift/minishift/cmd/minishift/cmd/util"
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusTimeout int
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status [HOST_FOLDER_NAME]",
+	Short: "Checks whether the host folders respond.",
+	Long:  `Checks whether the specified host folder, or all defined host folders, are mounted and respond. A mounted host folder not responding within the timeout is reported as stale and the command exits with status 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		api := libmachine.NewClient(state.InstanceDirs.Home, state.InstanceDirs.Certs)
+		defer api.Close()
+
+		util.ExitIfUndefined(api, constants.MachineName)
+
+		host, err := api.Load(constants.MachineName)
+		if err != nil {
+			atexit.ExitWithMessage(1, err.Error())
+		}
+
+		util.ExitIfNotRunning(host.Driver, constants.MachineName)
+
+		hostFolderManager := getHostFolderManager()
+		timeout := time.Duration(statusTimeout) * time.Second
+		var healths []*hostfolder.MountHealth
+		if len(args) > 0 {
+			health, err := hostFolderManager.Probe(host.Driver, args[0], timeout)
+			if err != nil {
+				atexit.ExitWithMessage(1, err.Error())
+			}
+			healths = append(healths, health)
+		} else {
+			healths, err = hostFolderManager.ProbeAll(host.Driver, timeout)
+			if err != nil {
+				atexit.ExitWithMessage(1, err.Error())
+			}
+		}
+
+		if printHostFolderStatus(os.Stdout, healths) {
+			atexit.Exit(1)
+		}
+	},
+}
+
+func init() {
+	HostFolderCmd.AddCommand(statusCmd)
+	statusCmd.Flags().IntVar(&statusTimeout, "timeout", int(hostfolder.DefaultProbeTimeout/time.Second), "The number of seconds after which a host folder not responding is reported as stale.")
+}
+
+// printHostFolderStatus prints the probe results as table. It returns true if at least one host folder is stale.
+func printHostFolderStatus(out io.Writer, healths []*hostfolder.MountHealth) bool {
+	stale := false
+	w := tabwriter.NewWriter(out, 4, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "Name\tType\tMountpoint\tStatus\tLatency\tError")
+	for _, health := range healths {
+		latency := ""
+		if health.State != hostfolder.HealthNotMounted {
+			latency = health.Latency.Round(time.Millisecond).String()
+		}
+		fmt.Fprintln(w, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s",
+			health.Name,
+			health.Type,
+			health.MountPoint,
+			health.State,
+			latency,
+			health.Error))
+		stale = stale || health.IsStale()
+	}
+	w.Flush()
+	return stale
+}
@@ -0,0 +1,51 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_status_reports_stale_host_folders(t *testing.T) {
+	healths := []*hostfolder.MountHealth{
+		{Name: "share", Type: "sshfs", MountPoint: "/mnt/sda1/share", State: hostfolder.HealthHealthy, Latency: 12 * time.Millisecond},
+		{Name: "data", Type: "cifs", MountPoint: "/mnt/sda1/data", State: hostfolder.HealthNotMounted},
+	}
+
+	var out bytes.Buffer
+	assert.False(t, printHostFolderStatus(&out, healths))
+	assert.Contains(t, out.String(), "Latency")
+	assert.Contains(t, out.String(), "12ms")
+	assert.Contains(t, out.String(), "not mounted")
+
+	healths = append(healths, &hostfolder.MountHealth{
+		Name:       "workspace",
+		Type:       "nfs",
+		MountPoint: "/mnt/sda1/workspace",
+		State:      hostfolder.HealthStale,
+		Latency:    5 * time.Second,
+		Error:      "no response within 5s",
+	})
+	out.Reset()
+	assert.True(t, printHostFolderStatus(&out, healths))
+	assert.Contains(t, out.String(), "no response within 5s")
+}
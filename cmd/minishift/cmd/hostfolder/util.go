@@ -48,6 +48,9 @@ func getHostFolderManager() *hostfolder.Manager {
 	if port != 0 {
 		hostfolder.SftpPort = port
 	}
+	if nfsPort := viper.GetInt(cmdConfig.ServicesNfsPort.Name); nfsPort != 0 {
+		hostfolder.NfsPort = nfsPort
+	}
 
 	return hostFolderManager
 }
@@ -0,0 +1,38 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"github.com/spf13/cobra"
+)
+
+const (
+	noBundleFileSpecified = "You need to specify the path of the bundle file."
+)
+
+var imageBundleCmd = &cobra.Command{
+	Use:   "bundle SUBCOMMAND [flags]",
+	Short: "Creates and loads bundles of cached images.",
+	Long:  "Creates and loads bundles of cached images. A bundle is a single tar.gz file which can be used to share cached images with hosts on slow or offline networks.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	ImageCmd.AddCommand(imageBundleCmd)
+}
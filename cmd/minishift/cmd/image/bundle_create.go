@@ -0,0 +1,79 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/docker/go-units"
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+var imageBundleCreateCmd = &cobra.Command{
+	Use:   "create FILE [image ...]",
+	Short: "Creates a bundle of the specified cached images.",
+	Long:  "Creates a bundle of the specified cached images. If no image is specified, all cached images are added to the bundle.",
+	Run:   createBundle,
+}
+
+func createBundle(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		atexit.ExitWithMessage(1, noBundleFileSpecified)
+	}
+
+	normalizedImageNames, err := normalizeImageNames(args[1:])
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("%v contains an invalid image names:\n%v", args[1:], err.Error()))
+	}
+
+	if err := runCreateBundle(state.InstanceDirs.ImageCache, args[0], normalizedImageNames, os.Stdout); err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot create the image bundle: %v", err))
+	}
+}
+
+// runCreateBundle writes the specified images of the image cache to the bundle file and prints its content.
+func runCreateBundle(cacheDir string, bundlePath string, images []string, out io.Writer) error {
+	handler, err := image.NewLocalOnlyOciImageHandler()
+	if err != nil {
+		return err
+	}
+
+	config := &image.ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: images}
+	bundleManifest, err := handler.CreateBundle(config, bundlePath)
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	for _, file := range bundleManifest.Files {
+		size += file.Size
+	}
+	for _, imageName := range bundleManifest.Images {
+		fmt.Fprintln(out, fmt.Sprintf("Added '%s'", imageName))
+	}
+	fmt.Fprintln(out, fmt.Sprintf("Created bundle %s with %d images (%s uncompressed)", bundlePath, len(bundleManifest.Images), units.HumanSize(float64(size))))
+	return nil
+}
+
+func init() {
+	imageBundleCmd.AddCommand(imageBundleCreateCmd)
+}
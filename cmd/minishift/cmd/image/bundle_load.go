@@ -0,0 +1,68 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+var imageBundleLoadCmd = &cobra.Command{
+	Use:   "load FILE",
+	Short: "Loads the images of the specified bundle into the local image cache.",
+	Long: `Loads the images of the specified bundle into the local image cache.
+The checksum of every file of the bundle is verified before it is added to the cache. Cached images with the same name are replaced.`,
+	Run: loadBundle,
+}
+
+func loadBundle(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		atexit.ExitWithMessage(1, noBundleFileSpecified)
+	}
+
+	if err := runLoadBundle(state.InstanceDirs.ImageCache, args[0], os.Stdout); err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot load the image bundle: %v", err))
+	}
+}
+
+// runLoadBundle loads the bundle file into the image cache and prints the loaded images.
+func runLoadBundle(cacheDir string, bundlePath string, out io.Writer) error {
+	handler, err := image.NewLocalOnlyOciImageHandler()
+	if err != nil {
+		return err
+	}
+
+	loadedImages, err := handler.LoadBundle(&image.ImageCacheConfig{HostCacheDir: cacheDir}, bundlePath)
+	if err != nil {
+		return err
+	}
+
+	for _, imageName := range loadedImages {
+		fmt.Fprintln(out, fmt.Sprintf("Loaded '%s'", imageName))
+	}
+	return nil
+}
+
+func init() {
+	imageBundleCmd.AddCommand(imageBundleLoadCmd)
+}
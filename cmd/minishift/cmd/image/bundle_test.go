@@ -0,0 +1,77 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minishift/minishift/cmd/testing/cli"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_bundle_file_required(t *testing.T) {
+	tee := cli.CreateTee(t, true)
+	defer cli.TearDown("", tee)
+
+	atexit.RegisterExitHandler(cli.VerifyExitCodeAndMessage(t, tee, 1, noBundleFileSpecified))
+
+	loadBundle(nil, []string{})
+}
+
+func Test_bundle_round_trip(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "minishift-test-image-bundle-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	sourceDir := filepath.Join(tmpDir, "source")
+	configDigest := writeBlob(t, sourceDir, "{}")
+	layerDigest := writeBlob(t, sourceDir, "layer")
+	manifest := fmt.Sprintf(`{"schemaVersion":2,"config":{"digest":"%s","size":2},"layers":[{"digest":"%s","size":5}]}`, configDigest, layerDigest)
+	manifestDigest := writeBlob(t, sourceDir, manifest)
+	index := fmt.Sprintf(`{"schemaVersion":2,"manifests":[{"digest":"%s","size":%d,"annotations":{"org.opencontainers.image.ref.name":"alpine:latest"}}]}`, manifestDigest, len(manifest))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(sourceDir, "index.json"), []byte(index), 0644))
+
+	bundlePath := filepath.Join(tmpDir, "alpine.tar.gz")
+	out := new(bytes.Buffer)
+	assert.NoError(t, runCreateBundle(sourceDir, bundlePath, nil, out))
+	assert.Contains(t, out.String(), "Added 'alpine:latest'\n")
+	assert.Contains(t, out.String(), fmt.Sprintf("Created bundle %s with 1 images", bundlePath))
+
+	targetDir := filepath.Join(tmpDir, "target")
+	out.Reset()
+	assert.NoError(t, runLoadBundle(targetDir, bundlePath, out))
+	assert.Equal(t, "Loaded 'alpine:latest'\n", out.String())
+	for _, digest := range []string{configDigest, layerDigest, manifestDigest} {
+		_, err := os.Stat(filepath.Join(targetDir, "blobs", "sha256", digest[len("sha256:"):]))
+		assert.NoError(t, err)
+	}
+}
+
+func writeBlob(t *testing.T, cacheDir string, content string) string {
+	hex := fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+	blobDir := filepath.Join(cacheDir, "blobs", "sha256")
+	assert.NoError(t, os.MkdirAll(blobDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(blobDir, hex), []byte(content), 0644))
+	return "sha256:" + hex
+}
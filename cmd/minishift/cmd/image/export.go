@@ -41,6 +41,10 @@ var (
 	exportAll bool
 	overwrite bool
 
+	exportWorkers int
+
+	exportFromRegistry bool
+
 	imageExportCmd = &cobra.Command{
 		Use:   "export [image ...]",
 		Short: "Exports the specified container images.",
@@ -50,10 +54,16 @@ var (
 )
 
 const (
-	noDockerDaemonImages = "There are currently no images in the Docker daemon which can be exported."
+	noDockerDaemonImages     = "There are currently no images in the Docker daemon which can be exported."
+	allFromRegistryExclusive = "The --all and --from-registry flags cannot be used together."
 )
 
 func exportImage(cmd *cobra.Command, args []string) {
+	if exportFromRegistry {
+		exportImageFromRegistry(cmd, args)
+		return
+	}
+
 	api := libmachine.NewClient(state.InstanceDirs.Home, state.InstanceDirs.Certs)
 	defer api.Close()
 
@@ -71,14 +81,8 @@ func exportImage(cmd *cobra.Command, args []string) {
 		atexit.ExitWithMessage(1, fmt.Sprintf("Error determining Docker daemon settings: %v", err))
 	}
 
-	var out io.Writer
-	if logToFile {
-		logFile := createLogFile()
-		defer logFile.Close()
-		out = logFile
-	} else {
-		out = os.Stdout
-	}
+	out, closeOut := exportOutput()
+	defer closeOut()
 
 	images := imagesToExport(api, args)
 
@@ -97,9 +101,60 @@ func exportImage(cmd *cobra.Command, args []string) {
 		CachedImages:      normalizedImageNames,
 		Out:               out,
 		ImageMissStrategy: image.Pull,
+		Workers:           imageWorkers(cmd, exportWorkers),
 	}
 
 	_, err = handler.ExportImages(imageCacheConfig, overwrite)
+	exitOnExportError(err, out)
+	enforceCachePolicy(state.InstanceDirs.ImageCache, normalizedImageNames, out)
+}
+
+// exportImageFromRegistry exports the images from their registries directly into the image cache, without the need
+// of a running VM.
+func exportImageFromRegistry(cmd *cobra.Command, args []string) {
+	if exportAll {
+		atexit.ExitWithMessage(1, allFromRegistryExclusive)
+	}
+
+	applyProxyConfig()
+
+	out, closeOut := exportOutput()
+	defer closeOut()
+
+	images := imagesToExport(nil, args)
+	normalizedImageNames, err := normalizeImageNames(images)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("%v contains an invalid image names:\n%v", images, err.Error()))
+	}
+
+	handler, err := image.NewLocalOnlyOciImageHandler()
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot create the image handler: %v", err))
+	}
+
+	imageCacheConfig := &image.ImageCacheConfig{
+		HostCacheDir:      state.InstanceDirs.ImageCache,
+		CachedImages:      normalizedImageNames,
+		Out:               out,
+		ImageMissStrategy: image.Pull,
+		Workers:           imageWorkers(cmd, exportWorkers),
+	}
+	_, err = handler.ExportImagesFromRegistry(imageCacheConfig, getRegistryConfig(), overwrite)
+	exitOnExportError(err, out)
+	enforceCachePolicy(state.InstanceDirs.ImageCache, normalizedImageNames, out)
+}
+
+// exportOutput returns the writer to which the export progress is written, either the log file or standard out, and
+// the function closing it.
+func exportOutput() (io.Writer, func()) {
+	if logToFile {
+		logFile := createLogFile()
+		return logFile, func() { logFile.Close() }
+	}
+	return os.Stdout, func() {}
+}
+
+func exitOnExportError(err error, out io.Writer) {
 	if err != nil {
 		msg := fmt.Sprintf("Container image export failed:\n%v", err)
 		if logToFile {
@@ -147,5 +202,7 @@ func init() {
 	imageExportCmd.Flags().BoolVar(&exportAll, "all", false, "Exports all images currently available in the Docker daemon.")
 	imageExportCmd.Flags().BoolVar(&logToFile, "log-to-file", false, "Logs export progress to file instead of standard out.")
 	imageExportCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Forces an image export when the image is already available in the Docker daemon.")
+	imageExportCmd.Flags().BoolVar(&exportFromRegistry, "from-registry", false, "Copies the images from their registries directly into the local cache without the need of a running VM. Honours the proxy, insecure-registry and registry-mirror settings.")
+	addWorkersFlag(imageExportCmd, &exportWorkers)
 	ImageCmd.AddCommand(imageExportCmd)
 }
@@ -32,3 +32,15 @@ func Test_no_images_to_export(t *testing.T) {
 
 	imagesToExport(nil, nil)
 }
+
+func Test_export_all_from_registry_not_allowed(t *testing.T) {
+	tee := cli.CreateTee(t, true)
+	defer cli.TearDown("", tee)
+
+	exportAll = true
+	defer func() { exportAll = false }()
+
+	atexit.RegisterExitHandler(cli.VerifyExitCodeAndMessage(t, tee, 1, allFromRegistryExclusive))
+
+	exportImageFromRegistry(imageExportCmd, nil)
+}
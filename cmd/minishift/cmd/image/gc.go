@@ -0,0 +1,75 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/docker/go-units"
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gcDryRun bool
+
+	imageGcCmd = &cobra.Command{
+		Use:   "gc",
+		Short: "Deletes the unused blobs of the local image cache.",
+		Long:  "Deletes the layers, configs and manifests of the local image cache which are not referenced by any cached image.",
+		Run:   collectGarbage,
+	}
+)
+
+func collectGarbage(cmd *cobra.Command, args []string) {
+	if err := runGarbageCollection(state.InstanceDirs.ImageCache, gcDryRun, os.Stdout); err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot delete the unused blobs of the image cache: %v", err))
+	}
+}
+
+// runGarbageCollection deletes the unused blobs of the specified image cache and prints the reclaimed space.
+func runGarbageCollection(cacheDir string, dryRun bool, out io.Writer) error {
+	handler, err := image.NewLocalOnlyOciImageHandler()
+	if err != nil {
+		return err
+	}
+
+	result, err := handler.CollectGarbage(&image.ImageCacheConfig{HostCacheDir: cacheDir}, dryRun)
+	if err != nil {
+		return err
+	}
+
+	size := units.HumanSize(float64(result.ReclaimedBytes))
+	if dryRun {
+		for _, blob := range result.DeletedBlobs {
+			fmt.Fprintln(out, fmt.Sprintf("Would delete blob %s", blob))
+		}
+		fmt.Fprintln(out, fmt.Sprintf("Would delete %d unused blobs of the image cache, reclaiming %s", len(result.DeletedBlobs), size))
+		return nil
+	}
+	fmt.Fprintln(out, fmt.Sprintf("Deleted %d unused blobs of the image cache, reclaimed %s", len(result.DeletedBlobs), size))
+	return nil
+}
+
+func init() {
+	imageGcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "Prints the unused blobs and the space they take without deleting them.")
+	ImageCmd.AddCommand(imageGcCmd)
+}
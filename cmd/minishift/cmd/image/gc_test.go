@@ -0,0 +1,47 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_garbage_collection_reports_reclaimed_space(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-image-gc-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	blobDir := filepath.Join(cacheDir, "blobs", "sha256")
+	assert.NoError(t, os.MkdirAll(blobDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(blobDir, "0123"), make([]byte, 2048), 0644))
+
+	out := new(bytes.Buffer)
+	assert.NoError(t, runGarbageCollection(cacheDir, true, out))
+	assert.Equal(t, "Would delete blob sha256:0123\nWould delete 1 unused blobs of the image cache, reclaiming 2.048 kB\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, runGarbageCollection(cacheDir, false, out))
+	assert.Equal(t, "Deleted 1 unused blobs of the image cache, reclaimed 2.048 kB\n", out.String())
+	_, err = os.Stat(filepath.Join(blobDir, "0123"))
+	assert.True(t, os.IsNotExist(err))
+}
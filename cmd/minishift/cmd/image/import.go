@@ -33,7 +33,8 @@ import (
 )
 
 var (
-	importAll bool
+	importAll     bool
+	importWorkers int
 
 	imageImportCmd = &cobra.Command{
 		Use:   "import [image ...]",
@@ -98,6 +99,7 @@ func importImage(cmd *cobra.Command, args []string) {
 		CachedImages:      normalizedImageNames,
 		Out:               os.Stdout,
 		ImageMissStrategy: image.Skip,
+		Workers:           imageWorkers(cmd, importWorkers),
 	}
 
 	importedImages, err := handler.ImportImages(imageCacheConfig)
@@ -112,5 +114,6 @@ func importImage(cmd *cobra.Command, args []string) {
 
 func init() {
 	imageImportCmd.Flags().BoolVar(&importAll, "all", false, "Imports all images available in the local image cache.")
+	addWorkersFlag(imageImportCmd, &importWorkers)
 	ImageCmd.AddCommand(imageImportCmd)
 }
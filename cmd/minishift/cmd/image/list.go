@@ -17,15 +17,49 @@ limitations under the License.
 package image
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
 	"github.com/docker/machine/libmachine"
+	"github.com/minishift/minishift/cmd/minishift/cmd/config"
+	"github.com/minishift/minishift/cmd/minishift/cmd/util"
 	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minikube/cluster"
 	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+
+	invalidOutputFormat = "Invalid output format '%s'. Use one of 'table' or 'json'."
+	outputWithVMFlag    = "The --output flag cannot be used together with --vm."
+)
+
+// imageDetails is a cached image as listed by 'image list --output'.
+type imageDetails struct {
+	Name           string `json:"name"`
+	Digest         string `json:"digest"`
+	CompressedSize int64  `json:"compressedSize"`
+	Configured     bool   `json:"configured"`
+	// InVM is nil if the images of the Docker daemon cannot be determined, eg because the VM is not running
+	InVM         *bool  `json:"inVM"`
+	LastImported string `json:"lastImported,omitempty"`
+}
+
 var (
 	dockerDaemonImages bool
+	listOutput         string
 
 	imageCacheListCmd = &cobra.Command{
 		Use:   "list ",
@@ -39,13 +73,134 @@ func listImages(cmd *cobra.Command, args []string) {
 	api := libmachine.NewClient(state.InstanceDirs.Home, state.InstanceDirs.Certs)
 	defer api.Close()
 
+	if listOutput != "" && listOutput != tableOutput && listOutput != jsonOutput {
+		atexit.ExitWithMessage(1, fmt.Sprintf(invalidOutputFormat, listOutput))
+	}
+
 	if dockerDaemonImages {
+		if listOutput != "" {
+			atexit.ExitWithMessage(1, outputWithVMFlag)
+		}
 		listDockerDaemonImages(api)
+	} else if listOutput != "" {
+		listCachedImageDetails(api)
 	} else {
 		listCachedImages()
 	}
 }
 
+func listCachedImageDetails(api *libmachine.Client) {
+	handler, err := image.NewLocalOnlyOciImageHandler()
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot create the image handler: %v", err))
+	}
+
+	infos, err := handler.GetCachedImageInfos(&image.ImageCacheConfig{HostCacheDir: state.InstanceDirs.ImageCache})
+	if err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+
+	details := getImageDetails(infos, viper.GetStringSlice(config.CacheImages.Name), dockerDaemonImagesIfRunning(api))
+	if err := printImageDetails(os.Stdout, details, listOutput); err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot print the image list: %v", err))
+	}
+}
+
+// getImageDetails combines the information about the cached images with the configured images and the images of the
+// Docker daemon. daemonImages is nil if the images of the Docker daemon are unknown.
+func getImageDetails(infos []image.CachedImageInfo, configuredImages []string, daemonImages map[string]bool) []imageDetails {
+	configured := make(map[string]bool)
+	for _, imageName := range configuredImages {
+		configured[imageName] = true
+	}
+
+	details := []imageDetails{}
+	for _, info := range infos {
+		detail := imageDetails{
+			Name:           info.Name,
+			Digest:         info.Digest,
+			CompressedSize: info.CompressedSize,
+			Configured:     configured[info.Name],
+		}
+		if daemonImages != nil {
+			inVM := daemonImages[info.Name]
+			detail.InVM = &inVM
+		}
+		if !info.LastImported.IsZero() {
+			detail.LastImported = info.LastImported.Format(time.RFC3339)
+		}
+		details = append(details, detail)
+	}
+	return details
+}
+
+func printImageDetails(writer io.Writer, details []imageDetails, output string) error {
+	if output == jsonOutput {
+		data, err := json.MarshalIndent(details, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(writer, string(data))
+		return nil
+	}
+
+	display := new(tabwriter.Writer)
+	display.Init(writer, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(display, "NAME\tDIGEST\tSIZE\tCONFIGURED\tIN VM\tLAST IMPORTED")
+	for _, detail := range details {
+		inVM := "unknown"
+		if detail.InVM != nil {
+			inVM = yesOrNo(*detail.InVM)
+		}
+		lastImported := "never"
+		if detail.LastImported != "" {
+			lastImported = detail.LastImported
+		}
+		digest := strings.TrimPrefix(detail.Digest, "sha256:")
+		if len(digest) > 12 {
+			digest = digest[:12]
+		}
+		fmt.Fprintln(display, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s", detail.Name, digest, units.HumanSize(float64(detail.CompressedSize)), yesOrNo(detail.Configured), inVM, lastImported))
+	}
+	return display.Flush()
+}
+
+func yesOrNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+// dockerDaemonImagesIfRunning returns the images of the Docker daemon of the VM, or nil if the VM is not running or
+// the images cannot be determined.
+func dockerDaemonImagesIfRunning(api *libmachine.Client) map[string]bool {
+	if exists, err := api.Exists(constants.MachineName); err != nil || !exists {
+		return nil
+	}
+
+	host, err := api.Load(constants.MachineName)
+	if err != nil || !util.IsHostRunning(host.Driver) {
+		return nil
+	}
+
+	envMap, err := cluster.GetHostDockerEnv(api)
+	if err != nil {
+		return nil
+	}
+
+	handler, err := image.NewOciImageHandler(host.Driver, envMap)
+	if err != nil {
+		return nil
+	}
+
+	images, err := handler.GetDockerImages()
+	if err != nil {
+		return nil
+	}
+	return images
+}
+
 func listCachedImages() {
 	cacheDir := state.InstanceDirs.ImageCache
 	cachedImages := getCachedImages(cacheDir)
@@ -71,5 +226,6 @@ func printImageList(images []string) {
 
 func init() {
 	imageCacheListCmd.Flags().BoolVar(&dockerDaemonImages, "vm", false, "Prints the available images in the Docker daemon.")
+	imageCacheListCmd.Flags().StringVarP(&listOutput, "output", "o", "", "Prints the cached images with their digest, size and usage. One of 'table' or 'json'.")
 	ImageCmd.AddCommand(imageCacheListCmd)
 }
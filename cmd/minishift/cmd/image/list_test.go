@@ -0,0 +1,70 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_image_details_table(t *testing.T) {
+	lastImported := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	infos := []image.CachedImageInfo{
+		{Name: "alpine:latest", Digest: "sha256:0123456789abcdef0123", CompressedSize: 2048, LastImported: lastImported},
+		{Name: "busybox:latest", Digest: "sha256:fedcba9876543210fedc", CompressedSize: 1000},
+	}
+
+	details := getImageDetails(infos, []string{"alpine:latest"}, nil)
+	out := new(bytes.Buffer)
+	assert.NoError(t, printImageDetails(out, details, tableOutput))
+
+	expected := `NAME            DIGEST        SIZE      CONFIGURED  IN VM    LAST IMPORTED
+alpine:latest   0123456789ab  2.048 kB  yes         unknown  2018-03-01T12:00:00Z
+busybox:latest  fedcba987654  1 kB      no          unknown  never
+`
+	assert.Equal(t, expected, out.String())
+}
+
+func Test_image_details_json(t *testing.T) {
+	infos := []image.CachedImageInfo{
+		{Name: "alpine:latest", Digest: "sha256:0123", CompressedSize: 2048},
+	}
+
+	details := getImageDetails(infos, nil, map[string]bool{"alpine:latest": true})
+	out := new(bytes.Buffer)
+	assert.NoError(t, printImageDetails(out, details, jsonOutput))
+
+	var parsed []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &parsed))
+	assert.Len(t, parsed, 1)
+	assert.Equal(t, "alpine:latest", parsed[0]["name"])
+	assert.Equal(t, "sha256:0123", parsed[0]["digest"])
+	assert.Equal(t, float64(2048), parsed[0]["compressedSize"])
+	assert.Equal(t, false, parsed[0]["configured"])
+	assert.Equal(t, true, parsed[0]["inVM"])
+	assert.NotContains(t, parsed[0], "lastImported")
+
+	details = getImageDetails(infos, nil, nil)
+	out.Reset()
+	assert.NoError(t, printImageDetails(out, details, jsonOutput))
+	assert.Contains(t, out.String(), `"inVM": null`)
+}
@@ -18,6 +18,7 @@ package image
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/docker/machine/libmachine"
 	"github.com/minishift/minishift/cmd/minishift/state"
@@ -76,6 +77,11 @@ func pruneImage(cmd *cobra.Command, args []string) {
 		msg := fmt.Sprintf("Deletion of the container image failed:\n%v", err)
 		atexit.ExitWithMessage(1, msg)
 	}
+
+	// the blobs of the deleted images might be shared with other images, hence unused blobs are deleted separately
+	if err := runGarbageCollection(state.InstanceDirs.ImageCache, false, os.Stdout); err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot delete the unused blobs of the image cache: %v", err))
+	}
 }
 
 func imagesToPrune(api *libmachine.Client, args []string) []string {
@@ -0,0 +1,88 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	refreshWorkers int
+
+	imageRefreshCmd = &cobra.Command{
+		Use:   "refresh [image ...]",
+		Short: "Exports the cached images again which changed in their registries.",
+		Long: `Compares the digests of the cached images with the digests in their registries and exports the images which changed directly from their registries.
+If no image is specified, all cached images are refreshed. Images exported from the Docker daemon are exported again once, since the digest of their source is unknown.`,
+		Run: refreshImages,
+	}
+)
+
+func refreshImages(cmd *cobra.Command, args []string) {
+	cacheDir := state.InstanceDirs.ImageCache
+	images := args
+	if len(images) == 0 {
+		images = getCachedImages(cacheDir)
+	}
+	if len(images) == 0 {
+		atexit.ExitWithMessage(0, noCachedImages)
+	}
+
+	normalizedImageNames, err := normalizeImageNames(images)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("%v contains an invalid image names:\n%v", images, err.Error()))
+	}
+
+	applyProxyConfig()
+
+	handler, err := image.NewLocalOnlyOciImageHandler()
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot create the image handler: %v", err))
+	}
+
+	imageCacheConfig := &image.ImageCacheConfig{
+		HostCacheDir:      cacheDir,
+		CachedImages:      normalizedImageNames,
+		Out:               os.Stdout,
+		ImageMissStrategy: image.Skip,
+		Workers:           imageWorkers(cmd, refreshWorkers),
+	}
+
+	updatedImages, err := handler.RefreshImages(imageCacheConfig, getRegistryConfig())
+	for _, imageName := range updatedImages {
+		fmt.Println(fmt.Sprintf("Updated '%s'", imageName))
+	}
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Container image refresh failed:\n%v", err))
+	}
+	if len(updatedImages) == 0 {
+		fmt.Println("All images are up to date")
+	}
+
+	enforceCachePolicy(cacheDir, nil, os.Stdout)
+}
+
+func init() {
+	addWorkersFlag(imageRefreshCmd, &refreshWorkers)
+	ImageCmd.AddCommand(imageRefreshCmd)
+}
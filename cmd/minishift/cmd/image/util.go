@@ -18,10 +18,12 @@ package image
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"sort"
 
 	"github.com/containers/image/docker/reference"
+	"github.com/docker/go-units"
 	"github.com/docker/machine/libmachine"
 	"github.com/minishift/minishift/cmd/minishift/cmd/config"
 	"github.com/minishift/minishift/cmd/minishift/cmd/util"
@@ -33,8 +35,90 @@ import (
 	pkgUtil "github.com/minishift/minishift/pkg/util"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
 	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
+const (
+	workersFlag = "workers"
+)
+
+// addWorkersFlag adds the flag specifying the number of images imported or exported at the same time.
+func addWorkersFlag(cmd *cobra.Command, workers *int) {
+	cmd.Flags().IntVar(workers, workersFlag, 1, fmt.Sprintf("Maximum number of images processed concurrently. Defaults to the '%s' setting of the persistent configuration or 1.", config.ImageWorkers.Name))
+}
+
+// imageWorkers returns the number of images to process concurrently. Unless specified via the workers flag, the
+// value of the persistent configuration is used.
+func imageWorkers(cmd *cobra.Command, workers int) int {
+	if !cmd.Flags().Changed(workersFlag) && viper.IsSet(config.ImageWorkers.Name) {
+		return viper.GetInt(config.ImageWorkers.Name)
+	}
+	return workers
+}
+
+// applyProxyConfig exports the configured proxies to the environment, so that they are used for accessing registries
+// from the host.
+func applyProxyConfig() {
+	proxyConfig, err := pkgUtil.NewProxyConfig(viper.GetString(config.HttpProxy.Name), viper.GetString(config.HttpsProxy.Name), viper.GetString(config.NoProxyList.Name))
+	if err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+	if proxyConfig.IsEnabled() {
+		proxyConfig.ApplyToEnvironment()
+	}
+}
+
+func getRegistryConfig() *image.RegistryConfig {
+	return &image.RegistryConfig{
+		InsecureRegistries: viper.GetStringSlice(config.InsecureRegistry.Name),
+		RegistryMirrors:    viper.GetStringSlice(config.RegistryMirror.Name),
+	}
+}
+
+func getCachePolicy() (*image.CachePolicy, error) {
+	policy := &image.CachePolicy{MaxOpenShiftVersions: viper.GetInt(config.ImageCacheMaxOpenShiftVersions.Name)}
+	if maxSize := viper.GetString(config.ImageCacheMaxSize.Name); maxSize != "" {
+		size, err := units.FromHumanSize(maxSize)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Invalid value '%s' of %s: %v", maxSize, config.ImageCacheMaxSize.Name, err))
+		}
+		policy.MaxSize = size
+	}
+	return policy, nil
+}
+
+// enforceCachePolicy evicts images from the image cache according to the configured cache policy. The specified
+// images as well as the images of the cache-images setting are kept. Problems are reported as warnings.
+func enforceCachePolicy(cacheDir string, images []string, out io.Writer) {
+	policy, err := getCachePolicy()
+	if err != nil {
+		fmt.Fprintln(out, fmt.Sprintf("WARN: %v", err))
+		return
+	}
+
+	handler, err := image.NewLocalOnlyOciImageHandler()
+	if err != nil {
+		fmt.Fprintln(out, fmt.Sprintf("WARN: Cannot create the image handler: %v", err))
+		return
+	}
+
+	keep := append([]string{}, images...)
+	keep = append(keep, viper.GetStringSlice(config.CacheImages.Name)...)
+	result, err := handler.EnforceCachePolicy(&image.ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: keep}, policy)
+	if result != nil {
+		for _, imageName := range result.EvictedImages {
+			fmt.Fprintln(out, fmt.Sprintf("Evicted '%s' from the local cache", imageName))
+		}
+		if len(result.EvictedImages) > 0 && err == nil {
+			fmt.Fprintln(out, fmt.Sprintf("Reclaimed %s", units.HumanSize(float64(result.ReclaimedBytes))))
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(out, fmt.Sprintf("WARN: Cannot enforce the image cache policy: %v", err))
+	}
+}
+
 func getCachedImages(cacheDir string) []string {
 	api := libmachine.NewClient(state.InstanceDirs.Home, state.InstanceDirs.Certs)
 	defer api.Close()
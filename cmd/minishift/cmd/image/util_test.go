@@ -20,6 +20,9 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/minishift/minishift/cmd/minishift/cmd/config"
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,3 +44,21 @@ func Test_normalize_image_names(t *testing.T) {
 		assert.Equal(t, normalizedImage, test.normalizedImage, fmt.Sprintf("Normalizing '%s' should have returned '%s', got '%s'", test.image, normalizedImage, test.normalizedImage))
 	}
 }
+
+func Test_cache_policy_from_config(t *testing.T) {
+	defer viper.Reset()
+
+	policy, err := getCachePolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, &image.CachePolicy{}, policy, "No limits should be set by default")
+
+	viper.Set(config.ImageCacheMaxSize.Name, "20GB")
+	viper.Set(config.ImageCacheMaxOpenShiftVersions.Name, 2)
+	policy, err = getCachePolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, &image.CachePolicy{MaxSize: 20 * 1000 * 1000 * 1000, MaxOpenShiftVersions: 2}, policy)
+
+	viper.Set(config.ImageCacheMaxSize.Name, "lots")
+	_, err = getCachePolicy()
+	assert.Error(t, err)
+}
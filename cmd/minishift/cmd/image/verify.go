@@ -0,0 +1,90 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	repair bool
+
+	imageVerifyCmd = &cobra.Command{
+		Use:   "verify",
+		Short: "Verifies the integrity of the local image cache.",
+		Long: `Verifies the integrity of the local image cache.
+Every blob is re-hashed and checked against its digest, and the manifest, config and layers of every cached image are checked to exist.
+With --repair, corrupt blobs are deleted and corrupt or incomplete images are removed from the cache, so that they are exported again.`,
+		Run: verifyCache,
+	}
+)
+
+func verifyCache(cmd *cobra.Command, args []string) {
+	valid, err := runVerifyCache(state.InstanceDirs.ImageCache, repair, os.Stdout)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot verify the image cache: %v", err))
+	}
+	if !valid {
+		atexit.Exit(1)
+	}
+}
+
+// runVerifyCache verifies the specified image cache and prints the problems found. It returns true if the cache is
+// valid, or has been repaired.
+func runVerifyCache(cacheDir string, repair bool, out io.Writer) (bool, error) {
+	handler, err := image.NewLocalOnlyOciImageHandler()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := handler.VerifyCache(&image.ImageCacheConfig{HostCacheDir: cacheDir}, repair)
+	if err != nil {
+		return false, err
+	}
+
+	if result.IsValid() {
+		fmt.Fprintln(out, "The image cache is valid")
+		return true, nil
+	}
+
+	for _, blob := range result.CorruptBlobs {
+		fmt.Fprintln(out, fmt.Sprintf("Blob %s is corrupt", blob))
+	}
+	for _, problem := range result.Images {
+		fmt.Fprintln(out, fmt.Sprintf("Image '%s' is corrupt or incomplete: %s", problem.Name, strings.Join(problem.Problems, ", ")))
+	}
+
+	if !result.Repaired {
+		fmt.Fprintln(out, "Use 'minishift image verify --repair' to remove the corrupt blobs and images from the cache")
+		return false, nil
+	}
+	fmt.Fprintln(out, fmt.Sprintf("Deleted %d corrupt blobs and removed %d images from the cache. Export the images again to cache them.", len(result.CorruptBlobs), len(result.Images)))
+	return true, nil
+}
+
+func init() {
+	imageVerifyCmd.Flags().BoolVar(&repair, "repair", false, "Deletes corrupt blobs and removes corrupt or incomplete images from the cache.")
+	ImageCmd.AddCommand(imageVerifyCmd)
+}
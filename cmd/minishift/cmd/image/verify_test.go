@@ -0,0 +1,60 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_verify_reports_missing_layer(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-image-verify-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	configDigest := writeBlob(t, cacheDir, "{}")
+	layerDigest := "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	manifest := fmt.Sprintf(`{"schemaVersion":2,"config":{"digest":"%s","size":2},"layers":[{"digest":"%s","size":5}]}`, configDigest, layerDigest)
+	manifestDigest := writeBlob(t, cacheDir, manifest)
+	index := fmt.Sprintf(`{"schemaVersion":2,"manifests":[{"digest":"%s","size":%d,"annotations":{"org.opencontainers.image.ref.name":"alpine:latest"}}]}`, manifestDigest, len(manifest))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(cacheDir, "index.json"), []byte(index), 0644))
+
+	out := new(bytes.Buffer)
+	valid, err := runVerifyCache(cacheDir, false, out)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, fmt.Sprintf("Image 'alpine:latest' is corrupt or incomplete: layer %s is missing\n", layerDigest)+
+		"Use 'minishift image verify --repair' to remove the corrupt blobs and images from the cache\n", out.String())
+
+	out.Reset()
+	valid, err = runVerifyCache(cacheDir, true, out)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Contains(t, out.String(), "Deleted 0 corrupt blobs and removed 1 images from the cache.")
+
+	out.Reset()
+	valid, err = runVerifyCache(cacheDir, false, out)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, "The image cache is valid\n", out.String())
+}
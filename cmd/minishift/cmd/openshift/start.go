@@ -94,6 +94,8 @@ func runStart(cmd *cobra.Command, args []string) {
 		KubeConfigPath:       constants.KubeConfigPath,
 		OcPath:               ocPath,
 		AddonEnv:             viper.GetStringSlice(confCmd.AddonEnv.Name),
+		AddonWorkers:         viper.GetInt(confCmd.AddonWorkers.Name),
+		AllowHostCommands:    viper.GetBool(confCmd.AllowHostCommands.Name),
 		PublicHostname:       confCmd.GetDefaultPublicHostName(ip),
 		SSHCommander:         sshCommander,
 		OcBinaryPathInsideVM: fmt.Sprintf("%s/oc", minishiftConstants.OcPathInsideVM),
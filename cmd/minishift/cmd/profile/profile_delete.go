@@ -27,6 +27,8 @@ import (
 	cmdState "github.com/minishift/minishift/cmd/minishift/state"
 	"github.com/minishift/minishift/pkg/minikube/cluster"
 	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
 	profileActions "github.com/minishift/minishift/pkg/minishift/profile"
 	pkgUtil "github.com/minishift/minishift/pkg/util"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
@@ -83,6 +85,9 @@ func runProfileDelete(cmd *cobra.Command, args []string) {
 			fmt.Println(fmt.Sprintf("Deleted: Minishift VM '%s'", constants.MachineName))
 		}
 	}
+	if err := hostfolder.RemoveNfsdClient(minishiftConfig.AllInstancesConfig, profileName); err != nil {
+		fmt.Println(fmt.Sprintf("Unable to remove the VM from the clients of the NFS daemon: %v", err))
+	}
 
 	err := os.RemoveAll(profileDirs.Home)
 	if err != nil {
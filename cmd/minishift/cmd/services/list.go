@@ -18,8 +18,13 @@ package services
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"time"
 
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
 	minishiftConstants "github.com/minishift/minishift/pkg/minishift/constants"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
 	"github.com/spf13/cobra"
 )
 
@@ -27,7 +32,7 @@ import (
 var serviceListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List the available Minishift services.",
-	Long:  "List the available Minishift services.",
+	Long:  "List the available Minishift services. For the sftpd service its status is shown as well.",
 	Run:   runServiceList,
 }
 
@@ -36,8 +41,38 @@ func init() {
 }
 
 func runServiceList(cmd *cobra.Command, args []string) {
-	fmt.Printf("The following Minishift services are available: \n")
+	printServiceList(os.Stdout, sftpdStatus(), time.Now())
+}
+
+func printServiceList(out io.Writer, sftpdStatus *hostfolder.SftpdStatus, now time.Time) {
+	fmt.Fprintf(out, "The following Minishift services are available: \n")
 	for _, component := range minishiftConstants.ValidServices {
-		fmt.Printf("\t- %s\n", component)
+		if component != minishiftConstants.SftpdDaemon {
+			fmt.Fprintf(out, "\t- %s\n", component)
+			continue
+		}
+
+		switch {
+		case sftpdStatus == nil:
+			fmt.Fprintf(out, "\t- %s (not running)\n", component)
+		case !sftpdStatus.IsAlive(now):
+			fmt.Fprintf(out, "\t- %s (not responding, last heartbeat %s ago)\n", component, now.Sub(sftpdStatus.Heartbeat).Round(time.Second))
+		default:
+			fmt.Fprintf(out, "\t- %s (%s)\n", component, sftpdStatus.String())
+		}
+	}
+}
+
+// sftpdStatus reads the heartbeat status of the sftpd daemon. nil is returned if the daemon is not running.
+func sftpdStatus() *hostfolder.SftpdStatus {
+	if minishiftConfig.AllInstancesConfig == nil {
+		return nil
+	}
+
+	status, err := hostfolder.ReadSftpdStatus(hostfolder.SftpdStatusPath(minishiftConfig.AllInstancesConfig.FilePath))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return nil
 	}
+	return status
 }
@@ -0,0 +1,45 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_service_list_shows_sftpd_status(t *testing.T) {
+	now := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	out := new(bytes.Buffer)
+	printServiceList(out, nil, now)
+	assert.Contains(t, out.String(), "\t- sftpd (not running)\n")
+	assert.Contains(t, out.String(), "\t- proxy\n")
+
+	status := &hostfolder.SftpdStatus{PID: 42, Port: 2022, Heartbeat: now.Add(-5 * time.Second), ActiveSessions: 1, TotalConnections: 3}
+	out.Reset()
+	printServiceList(out, status, now)
+	assert.Contains(t, out.String(), "\t- sftpd (running, PID 42, port 2022, 1 active sessions, 3 connections, 0 failed)\n")
+
+	status.Heartbeat = now.Add(-5 * time.Minute)
+	out.Reset()
+	printServiceList(out, status, now)
+	assert.Contains(t, out.String(), "\t- sftpd (not responding, last heartbeat 5m0s ago)\n")
+}
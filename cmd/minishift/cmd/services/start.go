@@ -17,10 +17,13 @@ limitations under the License.
 package services
 
 import (
+	"fmt"
 	"runtime"
 
+	"github.com/minishift/minishift/pkg/minikube/constants"
 	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
 	minishiftConstants "github.com/minishift/minishift/pkg/minishift/constants"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
 	"github.com/minishift/minishift/pkg/minishift/network/proxy"
 	"github.com/minishift/minishift/pkg/minishift/systemtray"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
@@ -63,6 +66,15 @@ func runServiceStart(cmd *cobra.Command, args []string) {
 	case minishiftConstants.SftpdDaemon:
 		// Add code to start sftpd
 		atexit.ExitWithMessage(0, "Start functionality for SFTP daemon is not available")
+	case minishiftConstants.NfsdDaemon:
+		// the NFS daemon is started when the first NFS host folder is mounted
+		atexit.ExitWithMessage(0, "Start functionality for NFS daemon is not available")
+	case minishiftConstants.HostFolderWatcherDaemon:
+		pid, err := hostfolder.EnsureMountWatcherRunning(minishiftConfig.InstanceConfig, constants.ProfileName)
+		if err != nil {
+			atexit.ExitWithMessage(1, fmt.Sprintf("Unable to start the host folder watcher: %v", err))
+		}
+		fmt.Println(fmt.Sprintf("Host folder watcher is running with PID: %d", pid))
 	case minishiftConstants.ProxyDaemon:
 		proxy.EnsureProxyDaemonRunning()
 	default:
@@ -23,6 +23,7 @@ import (
 
 	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
 	minishiftConstants "github.com/minishift/minishift/pkg/minishift/constants"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
 	"github.com/minishift/minishift/pkg/minishift/network/proxy"
 	"github.com/minishift/minishift/pkg/minishift/systemtray"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
@@ -76,7 +77,24 @@ func runServiceStop(cmd *cobra.Command, args []string) {
 			atexit.ExitWithMessage(1, "Unable to get Sftp daemon process using PID")
 		}
 		proc.Kill()
+		os.Remove(hostfolder.SftpdStatusPath(minishiftConfig.AllInstancesConfig.FilePath))
 		atexit.ExitWithMessage(0, fmt.Sprintf("Killed process with PID: %d\n", pid))
+	case minishiftConstants.NfsdDaemon:
+		pid := minishiftConfig.AllInstancesConfig.NfsdPID
+		proc, err := os.FindProcess(pid)
+		if err != nil {
+			atexit.ExitWithMessage(1, "Unable to get NFS daemon process using PID")
+		}
+		proc.Kill()
+		atexit.ExitWithMessage(0, fmt.Sprintf("Killed process with PID: %d\n", pid))
+	case minishiftConstants.HostFolderWatcherDaemon:
+		pid, err := hostfolder.StopMountWatcher(minishiftConfig.InstanceConfig)
+		if err != nil {
+			atexit.ExitWithMessage(1, fmt.Sprintf("Unable to stop the host folder watcher: %v", err))
+		}
+		if pid > 0 {
+			atexit.ExitWithMessage(0, fmt.Sprintf("Killed process with PID: %d\n", pid))
+		}
 	case minishiftConstants.ProxyDaemon:
 		if pid := proxy.GetPID(); pid > 0 {
 			proc, _ := os.FindProcess(pid)
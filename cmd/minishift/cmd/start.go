@@ -290,6 +290,8 @@ func runStart(cmd *cobra.Command, args []string) {
 			KubeConfigPath:       constants.KubeConfigPath,
 			OcPath:               ocPath,
 			AddonEnv:             viper.GetStringSlice(configCmd.AddonEnv.Name),
+			AddonWorkers:         viper.GetInt(configCmd.AddonWorkers.Name),
+			AllowHostCommands:    viper.GetBool(configCmd.AllowHostCommands.Name),
 			PublicHostname:       configCmd.GetDefaultPublicHostName(ip),
 			SSHCommander:         sshCommander,
 			OcBinaryPathInsideVM: fmt.Sprintf("%s/oc", minishiftConstants.OcPathInsideVM),
@@ -583,6 +585,7 @@ func importContainerImages(driver drivers.Driver, api libmachine.API, openShiftV
 		HostCacheDir: state.InstanceDirs.ImageCache,
 		CachedImages: images,
 		Out:          os.Stdout,
+		Workers:      viper.GetInt(configCmd.ImageWorkers.Name),
 	}
 	_, err = handler.ImportImages(config)
 	if err != nil {
@@ -709,6 +712,8 @@ func initStartFlags() *flag.FlagSet {
 	startFlagSet.AddFlag(insecureRegistryFlag)
 	startFlagSet.AddFlag(registryMirrorFlag)
 	startFlagSet.AddFlag(cmdUtil.AddOnEnvFlag)
+	startFlagSet.AddFlag(cmdUtil.AllowHostCommandsFlag)
+	startFlagSet.Int(configCmd.AddonWorkers.Name, 1, "Maximum number of independent add-ons which are applied concurrently.")
 
 	if runtime.GOOS == "windows" {
 		startFlagSet.String(configCmd.NetworkDevice.Name, "", "Specify the network device to use for the IP address. Ignored if no IP address specified (Hyper-V only)")
@@ -20,6 +20,7 @@ import (
 	configCmd "github.com/minishift/minishift/cmd/minishift/cmd/config"
 	"github.com/minishift/minishift/pkg/minishift/constants"
 	flag "github.com/spf13/pflag"
+	"strconv"
 	"strings"
 )
 
@@ -53,6 +54,14 @@ var AddOnEnvFlag = &flag.Flag{
 	Value:     NewStringSliceValue([]string{}, &[]string{}),
 }
 
+var AllowHostCommandsFlag = &flag.Flag{
+	Name:        configCmd.AllowHostCommands.Name,
+	Usage:       "Allows add-ons to run commands on the host using the 'host' add-on command.",
+	Value:       NewBoolValue(false, new(bool)),
+	DefValue:    "false",
+	NoOptDefVal: "true",
+}
+
 func NewStringValue(val string, p *string) *stringValue {
 	*p = val
 	return (*stringValue)(p)
@@ -129,3 +138,22 @@ func InitClusterUpFlags(commandName string) *flag.FlagSet {
 
 	return clusterUpFlagSet
 }
+
+func NewBoolValue(val bool, p *bool) *boolValue {
+	*p = val
+	return (*boolValue)(p)
+}
+
+func (b *boolValue) Set(val string) error {
+	v, err := strconv.ParseBool(val)
+	*b = boolValue(v)
+	return err
+}
+
+func (b *boolValue) Type() string {
+	return "bool"
+}
+
+func (b *boolValue) String() string {
+	return strconv.FormatBool(bool(*b))
+}
@@ -36,6 +36,10 @@ const (
 	anyMinishiftVersion      = ""
 	varDefaults              = "Var-Defaults"
 	dependsOn                = "Depends-On"
+	VarMetaTagName           = "Var"
+
+	RequiredVarsMetaTagName = requiredVars
+	DependsOnMetaTagName    = dependsOn
 )
 
 type RequiredVar struct {
@@ -54,6 +58,7 @@ type AddOnMeta interface {
 	MinishiftVersion() string
 	Dependency() ([]string, error)
 	Url() string
+	VarDeclarations() ([]*VarDeclaration, error)
 }
 
 type DefaultAddOnMeta struct {
@@ -73,6 +78,9 @@ func NewAddOnMeta(headers map[string]interface{}) (AddOnMeta, error) {
 	if err := varDefaultsCheck(headers); err != nil {
 		return nil, err
 	}
+	if _, err := varDeclarations(headers); err != nil {
+		return nil, err
+	}
 	if !checkDependencySemantic(headers) {
 		return nil, fmt.Errorf("The Dependencies should be a comma seperated list of Add-ons.")
 	}
@@ -159,6 +167,36 @@ func (meta *DefaultAddOnMeta) Dependency() ([]string, error) {
 	return []string{}, nil
 }
 
+// VarDeclarations returns the declarations of the typed variables of this addon.
+func (meta *DefaultAddOnMeta) VarDeclarations() ([]*VarDeclaration, error) {
+	return varDeclarations(meta.headers)
+}
+
+func varDeclarations(headers map[string]interface{}) ([]*VarDeclaration, error) {
+	var entries []string
+	switch v := headers[VarMetaTagName].(type) {
+	case string:
+		entries = []string{v}
+	case []string:
+		entries = v
+	}
+
+	declarations := make([]*VarDeclaration, 0, len(entries))
+	declared := make(map[string]bool)
+	for _, entry := range entries {
+		declaration, err := ParseVarDeclaration(entry)
+		if err != nil {
+			return nil, err
+		}
+		if declared[declaration.Name] {
+			return nil, errors.New(fmt.Sprintf("Variable '%s' is declared more than once", declaration.Name))
+		}
+		declared[declaration.Name] = true
+		declarations = append(declarations, declaration)
+	}
+	return declarations, nil
+}
+
 func checkDependencySemantic(headers map[string]interface{}) bool {
 	// Comma seperated list of dependencies
 	if headers[dependsOn] != nil {
@@ -0,0 +1,196 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	StringVarType = "string"
+	IntVarType    = "int"
+	BoolVarType   = "bool"
+	EnumVarType   = "enum"
+	RegexVarType  = "regex"
+	SecretVarType = "secret"
+)
+
+var varTypes = []string{StringVarType, IntVarType, BoolVarType, EnumVarType, RegexVarType, SecretVarType}
+
+// VarDeclaration declares the type and the description of an addon variable. Variables are declared in the addon
+// header, one 'Var' entry per variable, eg:
+//
+//	# Var: HTTP_PORT int The port the proxy listens on
+//	# Var: MODE enum(dev|prod) The deployment mode
+//	# Var: APP_NAME regex([a-z][a-z0-9-]*) The name of the application
+//	# Var: TOKEN secret The token to access the registry
+type VarDeclaration struct {
+	Name        string
+	Type        string
+	Description string
+	// Values are the allowed values of an enum variable
+	Values []string
+	// Pattern is the pattern a regex variable needs to match
+	Pattern string
+
+	regexp *regexp.Regexp
+}
+
+// ParseVarDeclaration parses a single variable declaration of the form 'NAME TYPE [DESCRIPTION]'.
+func ParseVarDeclaration(declaration string) (*VarDeclaration, error) {
+	declaration = strings.TrimSpace(declaration)
+	fields := strings.Fields(declaration)
+	if len(fields) < 2 {
+		return nil, errors.New(fmt.Sprintf("'%s' is not a well formed variable declaration. Use 'NAME TYPE [DESCRIPTION]'", declaration))
+	}
+
+	name := fields[0]
+	rest := strings.TrimSpace(strings.TrimPrefix(declaration, name))
+	varType, description, err := splitVarType(rest)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid declaration of variable '%s': %s", name, err.Error()))
+	}
+
+	varDeclaration := &VarDeclaration{Name: name, Description: description}
+	switch {
+	case strings.HasPrefix(varType, EnumVarType+"("):
+		varDeclaration.Type = EnumVarType
+		for _, value := range strings.Split(typeArgument(varType), "|") {
+			if value = strings.TrimSpace(value); value != "" {
+				varDeclaration.Values = append(varDeclaration.Values, value)
+			}
+		}
+		if len(varDeclaration.Values) == 0 {
+			return nil, errors.New(fmt.Sprintf("Invalid declaration of variable '%s': enum without values", name))
+		}
+	case strings.HasPrefix(varType, RegexVarType+"("):
+		varDeclaration.Type = RegexVarType
+		varDeclaration.Pattern = typeArgument(varType)
+		r, err := regexp.Compile("^(?:" + varDeclaration.Pattern + ")$")
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Invalid declaration of variable '%s': %s", name, err.Error()))
+		}
+		varDeclaration.regexp = r
+	case varType == EnumVarType || varType == RegexVarType:
+		return nil, errors.New(fmt.Sprintf("Invalid declaration of variable '%s': type '%s' requires an argument, eg %s(...)", name, varType, varType))
+	default:
+		if !isVarType(varType) {
+			return nil, errors.New(fmt.Sprintf("Invalid declaration of variable '%s': unknown type '%s'. Supported types are %s", name, varType, strings.Join(varTypes, ", ")))
+		}
+		varDeclaration.Type = varType
+	}
+
+	return varDeclaration, nil
+}
+
+// Validate checks whether the specified value is valid for the declared type.
+func (d *VarDeclaration) Validate(value string) error {
+	var valid bool
+	switch d.Type {
+	case IntVarType:
+		_, err := strconv.Atoi(value)
+		valid = err == nil
+	case BoolVarType:
+		_, err := strconv.ParseBool(value)
+		valid = err == nil
+	case EnumVarType:
+		for _, allowed := range d.Values {
+			if value == allowed {
+				valid = true
+			}
+		}
+	case RegexVarType:
+		valid = d.regexp.MatchString(value)
+	default:
+		valid = true
+	}
+
+	if !valid {
+		shownValue := value
+		if d.IsSecret() {
+			shownValue = "*****"
+		}
+		return errors.New(fmt.Sprintf("The value '%s' of variable '%s' is not of type %s", shownValue, d.Name, d.TypeString()))
+	}
+	return nil
+}
+
+// IsSecret returns true if the value of the variable must not be shown.
+func (d *VarDeclaration) IsSecret() bool {
+	return d.Type == SecretVarType
+}
+
+// TypeString returns the type of the variable including its argument, eg enum(dev|prod).
+func (d *VarDeclaration) TypeString() string {
+	switch d.Type {
+	case EnumVarType:
+		return fmt.Sprintf("%s(%s)", d.Type, strings.Join(d.Values, "|"))
+	case RegexVarType:
+		return fmt.Sprintf("%s(%s)", d.Type, d.Pattern)
+	}
+	return d.Type
+}
+
+func (d *VarDeclaration) String() string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s %s", d.Name, d.TypeString(), d.Description))
+}
+
+// splitVarType splits off the type of a declaration. The argument of enum and regex types is enclosed in
+// parentheses and may contain whitespace as well as nested parentheses.
+func splitVarType(s string) (string, string, error) {
+	open := strings.Index(s, "(")
+	space := strings.IndexAny(s, " \t")
+	if open == -1 || (space != -1 && space < open) {
+		if space == -1 {
+			return s, "", nil
+		}
+		return s[:space], strings.TrimSpace(s[space:]), nil
+	}
+
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[:i+1], strings.TrimSpace(s[i+1:]), nil
+			}
+		}
+	}
+	return "", "", errors.New(fmt.Sprintf("missing ')' in '%s'", s))
+}
+
+func typeArgument(varType string) string {
+	return varType[strings.Index(varType, "(")+1 : len(varType)-1]
+}
+
+func isVarType(varType string) bool {
+	for _, t := range varTypes {
+		if t == varType {
+			return true
+		}
+	}
+	return false
+}
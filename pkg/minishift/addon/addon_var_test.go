@@ -0,0 +1,89 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVarDeclaration(t *testing.T) {
+	var testCases = []struct {
+		declaration string
+		name        string
+		varType     string
+		description string
+	}{
+		{"HTTP_PORT int The port of the proxy", "HTTP_PORT", "int", "The port of the proxy"},
+		{"DEBUG bool", "DEBUG", "bool", ""},
+		{"MODE enum(dev|prod) The deployment mode", "MODE", "enum(dev|prod)", "The deployment mode"},
+		{"APP regex([a-z]+(-[a-z]+)*) The (lower case) name", "APP", "regex([a-z]+(-[a-z]+)*)", "The (lower case) name"},
+		{"TOKEN secret The registry token", "TOKEN", "secret", "The registry token"},
+	}
+
+	for _, testCase := range testCases {
+		declaration, err := ParseVarDeclaration(testCase.declaration)
+		assert.NoError(t, err, "Unexpected error parsing '%s'", testCase.declaration)
+		assert.Equal(t, testCase.name, declaration.Name)
+		assert.Equal(t, testCase.varType, declaration.TypeString())
+		assert.Equal(t, testCase.description, declaration.Description)
+	}
+}
+
+func TestParseInvalidVarDeclaration(t *testing.T) {
+	for _, declaration := range []string{"HTTP_PORT", "HTTP_PORT float", "MODE enum", "MODE enum()", "APP regex([a-z", "APP regex(*)"} {
+		_, err := ParseVarDeclaration(declaration)
+		assert.Error(t, err, "Expected error parsing '%s'", declaration)
+	}
+}
+
+func TestValidateVar(t *testing.T) {
+	var testCases = []struct {
+		declaration string
+		valid       []string
+		invalid     []string
+	}{
+		{"PORT int", []string{"8080", "-1"}, []string{"abc", "80.5", ""}},
+		{"DEBUG bool", []string{"true", "false", "1"}, []string{"yes", ""}},
+		{"MODE enum(dev|prod)", []string{"dev", "prod"}, []string{"test", "dev|prod"}},
+		{"APP regex([a-z]+)", []string{"foo"}, []string{"Foo", "foo1", ""}},
+		{"NAME string", []string{"", "anything"}, nil},
+	}
+
+	for _, testCase := range testCases {
+		declaration, err := ParseVarDeclaration(testCase.declaration)
+		assert.NoError(t, err)
+		for _, value := range testCase.valid {
+			assert.NoError(t, declaration.Validate(value), "'%s' should be valid for '%s'", value, testCase.declaration)
+		}
+		for _, value := range testCase.invalid {
+			assert.Error(t, declaration.Validate(value), "'%s' should be invalid for '%s'", value, testCase.declaration)
+		}
+	}
+}
+
+func TestDuplicateVarDeclarationReturnsError(t *testing.T) {
+	testMap := map[string]interface{}{
+		"Name":         "foo",
+		"Description":  []string{"bar"},
+		VarMetaTagName: []string{"PORT int", "PORT string"},
+	}
+
+	_, err := NewAddOnMeta(testMap)
+	assert.EqualError(t, err, "Variable 'PORT' is declared more than once")
+}
@@ -44,12 +44,13 @@ func (c *CatCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVar
 	// split off the actual 'cat' command. As we need to get file name of remaining string.
 	catString := strings.TrimPrefix(c.rawCommand, "cat")
 	fileName := strings.Replace(catString, " ", "", 1)
+	filePath := ec.resolvePath(fileName)
 
-	if fInfo, err = os.Stat(fileName); os.IsNotExist(err) {
+	if fInfo, err = os.Stat(filePath); os.IsNotExist(err) {
 		return errors.New(fmt.Sprintf("File %s doesn't exist", fileName))
 	}
 
-	f, err = os.Open(fileName)
+	f, err = os.Open(filePath)
 	defer f.Close()
 	fileSize := fInfo.Size()
 	buffer := make([]byte, fileSize)
@@ -63,6 +64,6 @@ func (c *CatCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVar
 		ec.AddToContext(outputVariable, strings.TrimSpace(string(buffer)))
 		return nil
 	}
-	fmt.Printf("%s", string(buffer))
+	fmt.Fprintf(ec.Output(), "%s", ec.Mask(string(buffer)))
 	return nil
 }
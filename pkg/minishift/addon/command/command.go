@@ -33,11 +33,20 @@ type defaultCommand struct {
 
 	rawCommand     string
 	fn             doExecute
+	planFn         doExecute
 	ignoreError    bool
 	outputVariable string
 }
 
 func (c *defaultCommand) Execute(ec *ExecutionContext) error {
+	if ec.IsDryRun() {
+		if c.planFn != nil {
+			return c.planFn(ec, c.ignoreError, c.outputVariable)
+		}
+		ec.record(c.rawCommand, c.ignoreError, c.outputVariable)
+		return nil
+	}
+
 	err := c.fn(ec, c.ignoreError, c.outputVariable)
 	if c.ignoreError {
 		return nil
@@ -55,3 +64,8 @@ func (c *defaultCommand) String() string {
 func (c *defaultCommand) IgnoreError() bool {
 	return c.ignoreError
 }
+
+// OutputVariable returns the name of the variable the output of the command is assigned to, if any.
+func (c *defaultCommand) OutputVariable() string {
+	return c.outputVariable
+}
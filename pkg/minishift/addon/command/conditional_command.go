@@ -0,0 +1,221 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	minishiftStrings "github.com/minishift/minishift/pkg/util/strings"
+)
+
+const (
+	definedOperator   = "defined"
+	undefinedOperator = "undefined"
+	equalOperator     = "=="
+	notEqualOperator  = "!="
+	matchOperator     = "=~"
+	notMatchOperator  = "!~"
+
+	elseKeyword = "else"
+	endKeyword  = "end"
+)
+
+var binaryOperators = []string{equalOperator, notEqualOperator, matchOperator, notMatchOperator}
+
+// Condition is the condition of an 'if' block. It either checks whether a variable is (un)defined in the
+// interpolation context, eg 'defined FOO', or compares two interpolated operands, eg '#{FOO} == bar' or
+// '#{output} =~ ^v3\.11'.
+type Condition struct {
+	raw      string
+	operator string
+	left     string
+	right    string
+}
+
+// NewCondition parses the specified condition expression.
+func NewCondition(expression string) (*Condition, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return nil, errors.New("Empty condition")
+	}
+
+	for _, operator := range []string{definedOperator, undefinedOperator} {
+		if strings.HasPrefix(expression, operator+" ") {
+			variable := strings.TrimSpace(strings.TrimPrefix(expression, operator))
+			if strings.ContainsAny(variable, " \t") {
+				return nil, errors.New(fmt.Sprintf("'%s' expects a single variable name", operator))
+			}
+			return &Condition{raw: expression, operator: operator, left: variable}, nil
+		}
+	}
+
+	// the left most operator wins, so that operators can be used within the right operand, eg a regular expression
+	operator, index := "", -1
+	for _, candidate := range binaryOperators {
+		if i := strings.Index(expression, candidate); i != -1 && (index == -1 || i < index) {
+			operator, index = candidate, i
+		}
+	}
+	if index != -1 {
+		condition := &Condition{
+			raw:      expression,
+			operator: operator,
+			left:     unquote(expression[:index]),
+			right:    unquote(expression[index+len(operator):]),
+		}
+		if condition.isRegexp() && !strings.Contains(condition.right, "#{") {
+			if _, err := regexp.Compile(condition.right); err != nil {
+				return nil, errors.New(fmt.Sprintf("Invalid regular expression '%s': %s", condition.right, err.Error()))
+			}
+		}
+		return condition, nil
+	}
+
+	return nil, errors.New(fmt.Sprintf("Unable to parse condition '%s'. Use 'defined VAR', 'undefined VAR' or one of the operators %s", expression, strings.Join(binaryOperators, ", ")))
+}
+
+// Evaluate evaluates this condition against the specified execution context.
+func (c *Condition) Evaluate(ec *ExecutionContext) (bool, error) {
+	switch c.operator {
+	case definedOperator:
+		return minishiftStrings.Contains(ec.Vars(), c.left), nil
+	case undefinedOperator:
+		return !minishiftStrings.Contains(ec.Vars(), c.left), nil
+	}
+
+	left := ec.Interpolate(c.left)
+	right := ec.Interpolate(c.right)
+	switch c.operator {
+	case equalOperator:
+		return left == right, nil
+	case notEqualOperator:
+		return left != right, nil
+	}
+
+	r, err := regexp.Compile(right)
+	if err != nil {
+		return false, errors.New(fmt.Sprintf("Invalid regular expression '%s' in condition '%s': %s", right, c.raw, err.Error()))
+	}
+	if c.operator == matchOperator {
+		return r.MatchString(left), nil
+	}
+	return !r.MatchString(left), nil
+}
+
+func (c *Condition) String() string {
+	return c.raw
+}
+
+// Variable returns the variable checked by a 'defined' or 'undefined' condition and whether the condition is true if
+// the variable is defined. An empty name is returned for comparisons.
+func (c *Condition) Variable() (string, bool) {
+	switch c.operator {
+	case definedOperator:
+		return c.left, true
+	case undefinedOperator:
+		return c.left, false
+	}
+	return "", false
+}
+
+func (c *Condition) isRegexp() bool {
+	return c.operator == matchOperator || c.operator == notMatchOperator
+}
+
+// ConditionalCommand executes its commands if its condition evaluates to true and its else commands otherwise.
+type ConditionalCommand struct {
+	*defaultCommand
+
+	condition    *Condition
+	commands     []Command
+	elseCommands []Command
+}
+
+func NewConditionalCommand(command string, condition *Condition, commands []Command, elseCommands []Command) *ConditionalCommand {
+	defaultCommand := &defaultCommand{rawCommand: command}
+	conditionalCommand := &ConditionalCommand{defaultCommand, condition, commands, elseCommands}
+	defaultCommand.fn = conditionalCommand.doExecute
+	defaultCommand.planFn = conditionalCommand.doPlan
+	return conditionalCommand
+}
+
+// Condition returns the condition of this command.
+func (c *ConditionalCommand) Condition() *Condition {
+	return c.condition
+}
+
+// Commands returns the commands executed if the condition evaluates to true.
+func (c *ConditionalCommand) Commands() []Command {
+	return c.commands
+}
+
+// ElseCommands returns the commands executed if the condition evaluates to false.
+func (c *ConditionalCommand) ElseCommands() []Command {
+	return c.elseCommands
+}
+
+func (c *ConditionalCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
+	matched, err := c.condition.Evaluate(ec)
+	if err != nil {
+		return err
+	}
+
+	commands := c.elseCommands
+	if matched {
+		commands = c.commands
+	}
+
+	for _, command := range commands {
+		if err := command.Execute(ec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// doPlan records both branches of this command, since the condition might depend on values which are only known
+// once the add-on is actually applied.
+func (c *ConditionalCommand) doPlan(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
+	ec.record(c.rawCommand, false, "")
+	ec.planDepth++
+	for _, command := range c.commands {
+		command.Execute(ec)
+	}
+	ec.planDepth--
+
+	if len(c.elseCommands) > 0 {
+		ec.record(elseKeyword, false, "")
+		ec.planDepth++
+		for _, command := range c.elseCommands {
+			command.Execute(ec)
+		}
+		ec.planDepth--
+	}
+	ec.record(endKeyword, false, "")
+	return nil
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
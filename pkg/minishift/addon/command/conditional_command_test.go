@@ -0,0 +1,77 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"testing"
+
+	pkgTesting "github.com/minishift/minishift/pkg/testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_condition_evaluation(t *testing.T) {
+	context, _ := NewExecutionContext(nil, nil)
+	context.AddToContext("FOO", "foo")
+	context.AddToContext("version", "v3.11.0")
+
+	testCases := []struct {
+		expression string
+		expected   bool
+	}{
+		{"defined FOO", true},
+		{"defined BAR", false},
+		{"undefined BAR", true},
+		{"#{FOO} == foo", true},
+		{"#{FOO} == \"foo bar\"", false},
+		{"#{FOO} != bar", true},
+		{"#{version} =~ ^v3\\.11", true},
+		{"#{version} !~ ^v3\\.11", false},
+		{"#{version} =~ a==b", false},
+	}
+
+	for _, testCase := range testCases {
+		condition, err := NewCondition(testCase.expression)
+		assert.NoError(t, err, "Unexpected error parsing '%s'", testCase.expression)
+		result, err := condition.Evaluate(context)
+		assert.NoError(t, err, "Unexpected error evaluating '%s'", testCase.expression)
+		assert.Equal(t, testCase.expected, result, "Unexpected result for '%s'", testCase.expression)
+	}
+}
+
+func Test_invalid_conditions(t *testing.T) {
+	for _, expression := range []string{"", "#{FOO}", "defined FOO BAR", "#{FOO} =~ ^(v3"} {
+		_, err := NewCondition(expression)
+		assert.Error(t, err, "Expected error parsing '%s'", expression)
+	}
+}
+
+func Test_conditional_command_executes_matching_branch(t *testing.T) {
+	context, _ := NewExecutionContext(nil, nil)
+	context.AddToContext("FOO", "foo")
+
+	condition, _ := NewCondition("defined FOO")
+	conditional := NewConditionalCommand("if defined FOO", condition,
+		[]Command{NewEchoCommand("echo then", false)}, []Command{NewEchoCommand("echo else", false)})
+
+	tee, err := pkgTesting.NewTee(true)
+	assert.NoError(t, err)
+	err = conditional.Execute(context)
+	tee.Close()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "\nthen", tee.StdoutBuffer.String())
+}
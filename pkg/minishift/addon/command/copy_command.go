@@ -0,0 +1,162 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/util/shell"
+)
+
+const (
+	modeOption = "--mode"
+
+	// uploadChunkSize is the maximum number of base64 encoded bytes sent per ssh command
+	uploadChunkSize = 64 * 1024
+)
+
+// CopyArguments are the arguments of a copy or template command.
+type CopyArguments struct {
+	Source string
+	Target string
+	// Mode is the octal file mode of the target file, eg 0644. If empty, the mode is not changed.
+	Mode string
+}
+
+// ParseCopyArguments parses the arguments of a copy or template command of the form '[--mode MODE] SOURCE TARGET'.
+func ParseCopyArguments(args string) (*CopyArguments, error) {
+	copyArgs := &CopyArguments{}
+	var paths []string
+	fields := strings.Fields(args)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		switch {
+		case field == modeOption:
+			if i+1 == len(fields) {
+				return nil, errors.New(fmt.Sprintf("Option '%s' requires a value", modeOption))
+			}
+			i++
+			copyArgs.Mode = fields[i]
+		case strings.HasPrefix(field, modeOption+"="):
+			copyArgs.Mode = strings.TrimPrefix(field, modeOption+"=")
+		default:
+			paths = append(paths, field)
+		}
+	}
+
+	if len(paths) != 2 {
+		return nil, errors.New("Expected a source and a target path")
+	}
+	copyArgs.Source, copyArgs.Target = paths[0], paths[1]
+
+	if copyArgs.Mode != "" {
+		if mode, err := strconv.ParseUint(copyArgs.Mode, 8, 32); err != nil || mode > 07777 {
+			return nil, errors.New(fmt.Sprintf("Invalid file mode '%s'. Use an octal mode, eg 0644", copyArgs.Mode))
+		}
+	}
+	return copyArgs, nil
+}
+
+// CopyCommand uploads a file of the add-on directory into the VM. Template commands interpolate the content of the
+// file with the variables of the execution context before uploading it.
+type CopyCommand struct {
+	*defaultCommand
+
+	args        *CopyArguments
+	interpolate bool
+}
+
+func NewCopyCommand(command string, args *CopyArguments, ignoreError bool) *CopyCommand {
+	return newCopyCommand(command, args, false, ignoreError)
+}
+
+func NewTemplateCommand(command string, args *CopyArguments, ignoreError bool) *CopyCommand {
+	return newCopyCommand(command, args, true, ignoreError)
+}
+
+func newCopyCommand(command string, args *CopyArguments, interpolate bool, ignoreError bool) *CopyCommand {
+	defaultCommand := &defaultCommand{rawCommand: command, ignoreError: ignoreError}
+	copyCommand := &CopyCommand{defaultCommand, args, interpolate}
+	defaultCommand.fn = copyCommand.doExecute
+	return copyCommand
+}
+
+// Arguments returns the source, target and mode of this command.
+func (c *CopyCommand) Arguments() *CopyArguments {
+	return c.args
+}
+
+// IsTemplate returns true if the content of the source file is interpolated before it is uploaded.
+func (c *CopyCommand) IsTemplate() bool {
+	return c.interpolate
+}
+
+func (c *CopyCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
+	source := ec.Interpolate(c.args.Source)
+	target := ec.Interpolate(c.args.Target)
+	fmt.Fprint(ec.Output(), ".")
+
+	sourcePath, err := ec.resolveAddOnFile(source)
+	if err != nil {
+		return errors.New(ec.Mask(fmt.Sprintf("Unable to read '%s': %s", source, err.Error())))
+	}
+	content, err := ioutil.ReadFile(sourcePath)
+	if err != nil {
+		return errors.New(ec.Mask(fmt.Sprintf("Unable to read '%s': %s", source, err.Error())))
+	}
+	if c.interpolate {
+		content = []byte(ec.Interpolate(string(content)))
+	}
+
+	for _, cmd := range uploadCommands(content, target, c.args.Mode) {
+		if _, err := ec.GetSSHCommander().SSHCommand(cmd); err != nil {
+			return errors.New(ec.Mask(fmt.Sprintf("Unable to copy '%s' to '%s': %s", source, target, uploadError(err, cmd))))
+		}
+	}
+	return nil
+}
+
+// uploadError returns the message of the error of an upload command without the command itself, since it contains
+// the base64 encoded content of the file, which cannot be masked.
+func uploadError(err error, cmd string) string {
+	return strings.Replace(err.Error(), cmd, "<upload command>", -1)
+}
+
+// uploadCommands returns the ssh commands writing content to the target file in the VM. The content is base64
+// encoded and split into chunks, so that large files do not exceed the maximum length of a command.
+func uploadCommands(content []byte, target string, mode string) []string {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	commands := []string{fmt.Sprintf("sudo mkdir -p %s && sudo truncate -s 0 %s", shell.Quote(path.Dir(target)), shell.Quote(target))}
+	for start := 0; start < len(encoded); start += uploadChunkSize {
+		end := start + uploadChunkSize
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		// chunks are a multiple of 4 bytes long, so that each of them can be decoded on its own
+		commands = append(commands, fmt.Sprintf("echo %s | base64 --decode | sudo tee -a %s > /dev/null", encoded[start:end], shell.Quote(target)))
+	}
+	if mode != "" {
+		commands = append(commands, fmt.Sprintf("sudo chmod %s %s", mode, shell.Quote(target)))
+	}
+	return commands
+}
@@ -0,0 +1,193 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSSHCommander struct {
+	commands []string
+}
+
+func (r *recordingSSHCommander) SSHCommand(args string) (string, error) {
+	r.commands = append(r.commands, args)
+	return "", nil
+}
+
+func Test_parsing_copy_arguments(t *testing.T) {
+	args, err := ParseCopyArguments(" --mode 0640 files/app.conf /etc/app.conf")
+	assert.NoError(t, err)
+	assert.Equal(t, &CopyArguments{Source: "files/app.conf", Target: "/etc/app.conf", Mode: "0640"}, args)
+
+	args, err = ParseCopyArguments("files/app.conf /etc/app.conf --mode=755")
+	assert.NoError(t, err)
+	assert.Equal(t, "755", args.Mode)
+
+	for _, invalid := range []string{"", "files/app.conf", "a b c", "a b --mode", "a b --mode 0800", "a b --mode=rw"} {
+		_, err := ParseCopyArguments(invalid)
+		assert.Error(t, err, "Expected error for '%s'", invalid)
+	}
+}
+
+func Test_template_command_uploads_interpolated_file(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-copy-command-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(testDir, "app.conf"), []byte("port=#{PORT}\n"), 0644))
+
+	commander := &recordingSSHCommander{}
+	context, err := NewExecutionContext(nil, commander)
+	assert.NoError(t, err)
+	context.SetOutput(new(bytes.Buffer))
+	context.SetWorkingDir(testDir)
+	context.AddToContext("PORT", "8080")
+
+	args := &CopyArguments{Source: "app.conf", Target: "/etc/app/app.conf", Mode: "0600"}
+	err = NewTemplateCommand("template --mode 0600 app.conf /etc/app/app.conf", args, false).Execute(context)
+	assert.NoError(t, err)
+
+	expected := []string{
+		"sudo mkdir -p '/etc/app' && sudo truncate -s 0 '/etc/app/app.conf'",
+		"echo " + base64.StdEncoding.EncodeToString([]byte("port=8080\n")) + " | base64 --decode | sudo tee -a '/etc/app/app.conf' > /dev/null",
+		"sudo chmod 0600 '/etc/app/app.conf'",
+	}
+	assert.Equal(t, expected, commander.commands)
+
+	commander.commands = nil
+	err = NewCopyCommand("copy app.conf /etc/app/app.conf", &CopyArguments{Source: "app.conf", Target: "/etc/app/app.conf"}, false).Execute(context)
+	assert.NoError(t, err)
+	assert.Len(t, commander.commands, 2)
+	assert.Contains(t, commander.commands[1], base64.StdEncoding.EncodeToString([]byte("port=#{PORT}\n")))
+}
+
+func Test_upload_of_large_files_is_split_into_chunks(t *testing.T) {
+	content := []byte(strings.Repeat("x", uploadChunkSize))
+	commands := uploadCommands(content, "/tmp/large", "")
+
+	var decoded []byte
+	for _, cmd := range commands[1:] {
+		chunk := strings.TrimSuffix(strings.TrimPrefix(cmd, "echo "), " | base64 --decode | sudo tee -a '/tmp/large' > /dev/null")
+		data, err := base64.StdEncoding.DecodeString(chunk)
+		assert.NoError(t, err)
+		decoded = append(decoded, data...)
+	}
+	assert.Len(t, commands, 3)
+	assert.Equal(t, content, decoded)
+}
+
+func Test_copy_command_fails_for_missing_file(t *testing.T) {
+	context, err := NewExecutionContext(nil, &recordingSSHCommander{})
+	assert.NoError(t, err)
+	context.SetOutput(new(bytes.Buffer))
+
+	err = NewCopyCommand("copy missing /tmp/missing", &CopyArguments{Source: "missing", Target: "/tmp/missing"}, false).Execute(context)
+	assert.Error(t, err)
+}
+
+func Test_copy_command_rejects_sources_outside_of_add_on_directory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Creating symbolic links requires privileges on Windows")
+	}
+
+	testDir, err := ioutil.TempDir("", "minishift-test-copy-command-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+	addOnDir := filepath.Join(testDir, "addon")
+	assert.NoError(t, os.Mkdir(addOnDir, 0755))
+	secret := filepath.Join(testDir, "secret")
+	assert.NoError(t, ioutil.WriteFile(secret, []byte("secret"), 0600))
+	assert.NoError(t, os.Symlink(secret, filepath.Join(addOnDir, "link")))
+
+	commander := &recordingSSHCommander{}
+	context, err := NewExecutionContext(nil, commander)
+	assert.NoError(t, err)
+	context.SetOutput(new(bytes.Buffer))
+	context.SetWorkingDir(addOnDir)
+
+	var testCases = []struct {
+		source        string
+		expectedError string
+	}{
+		{secret, fmt.Sprintf("Unable to read '%s': Absolute path '%s' is not allowed. Use a path relative to the add-on directory", secret, secret)},
+		{"../secret", "Unable to read '../secret': '../secret' is not inside of the add-on directory"},
+		{"link", "Unable to read 'link': 'link' is not inside of the add-on directory"},
+	}
+	for _, testCase := range testCases {
+		args := &CopyArguments{Source: testCase.source, Target: "/tmp/secret"}
+		err = NewCopyCommand("copy "+testCase.source+" /tmp/secret", args, false).Execute(context)
+		assert.EqualError(t, err, testCase.expectedError)
+	}
+	assert.Empty(t, commander.commands, "nothing must be uploaded")
+}
+
+type failingSSHCommander struct{}
+
+func (f *failingSSHCommander) SSHCommand(args string) (string, error) {
+	return "", errors.New(fmt.Sprintf("ssh command error:\ncommand : %s\nerr     : exit status 1\n", args))
+}
+
+func Test_command_errors_mask_secret_variables(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-copy-command-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(testDir, "app.conf"), []byte("password=#{PASSWORD}\n"), 0644))
+
+	context, err := NewExecutionContext(nil, &failingSSHCommander{})
+	assert.NoError(t, err)
+	context.SetOutput(new(bytes.Buffer))
+	context.SetWorkingDir(testDir)
+	context.AddToContext("PASSWORD", "s3cret")
+	context.MarkSecret("PASSWORD")
+
+	err = NewSshCommand("ssh echo #{PASSWORD}", false, "").Execute(context)
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cret")
+
+	err = NewDockerCommand("docker login -p #{PASSWORD}", false, "").Execute(context)
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cret")
+
+	args := &CopyArguments{Source: "app.conf", Target: "/etc/app/#{PASSWORD}.conf"}
+	err = NewTemplateCommand("template app.conf /etc/app/#{PASSWORD}.conf", args, false).Execute(context)
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cret")
+	assert.NotContains(t, err.Error(), base64.StdEncoding.EncodeToString([]byte("password=s3cret\n")))
+	assert.Contains(t, err.Error(), "<upload command>")
+}
+
+func Test_upload_commands_quote_target(t *testing.T) {
+	commands := uploadCommands([]byte("x"), "/etc/it's/app.conf", "0644")
+
+	expected := []string{
+		`sudo mkdir -p '/etc/it'\''s' && sudo truncate -s 0 '/etc/it'\''s/app.conf'`,
+		`echo eA== | base64 --decode | sudo tee -a '/etc/it'\''s/app.conf' > /dev/null`,
+		`sudo chmod 0644 '/etc/it'\''s/app.conf'`,
+	}
+	assert.Equal(t, expected, commands)
+}
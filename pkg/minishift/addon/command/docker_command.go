@@ -36,11 +36,12 @@ func NewDockerCommand(command string, ignoreError bool, outputVariable string) *
 func (c *DockerCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
 	commander := ec.GetDockerCommander()
 	cmd := ec.Interpolate(c.rawCommand)
-	fmt.Print(".")
+	fmt.Fprint(ec.Output(), ".")
 
 	output, err := commander.LocalExec(cmd)
 	if err != nil {
-		return errors.New(fmt.Sprintf("Error executing command '%s':", err.Error()))
+		// the error contains the interpolated command, including the values of secret variables
+		return errors.New(ec.Mask(fmt.Sprintf("Error executing command '%s':", err.Error())))
 	}
 
 	if outputVariable != "" {
@@ -41,7 +41,7 @@ func (c *EchoCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVa
 	echoString = strings.Replace(echoString, " ", "", 1)
 
 	echoString = ec.Interpolate(echoString)
-	_, err = fmt.Print("\n" + echoString)
+	_, err = fmt.Fprint(ec.Output(), "\n"+ec.Mask(echoString))
 
 	if err != nil {
 		return errors.New(fmt.Sprintf("Error executing command '%s':", err.Error()))
@@ -17,9 +17,19 @@ limitations under the License.
 package command
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
 	"github.com/docker/machine/libmachine/provision"
 	"github.com/minishift/minishift/pkg/minishift/docker"
 	"github.com/minishift/minishift/pkg/minishift/oc"
+	minishiftStrings "github.com/minishift/minishift/pkg/util/strings"
 )
 
 // ExecutionContext contains the mapping of supported addon variables to their values
@@ -31,13 +41,54 @@ type ExecutionContext struct {
 	dockerCommander      docker.DockerCommander
 	sshCommander         provision.SSHCommander
 	interpolationContext InterpolationContext
+
+	output            io.Writer
+	workingDir        string
+	allowHostCommands bool
+
+	secrets    map[string]bool
+	dryRun     bool
+	plan       []PlannedCommand
+	planDepth  int
+	unresolved map[string]bool
 }
 
+// PlannedCommand is a command recorded by an execution context in dry-run mode.
+type PlannedCommand struct {
+	// Type is the command type, eg oc, ssh or if
+	Type string
+	// Command is the interpolated command with secrets masked
+	Command string
+	// IgnoreError is true if the command is prefixed with '!'
+	IgnoreError bool
+	// Unresolved lists the variables which are only known when the add-on is actually applied
+	Unresolved []string
+	// Depth is the nesting depth of the command within conditional blocks
+	Depth int
+}
+
+const (
+	secretMask = "*****"
+
+	// minSecretSubstringLength is the length from which secret values are masked wherever they occur. Shorter values
+	// are only masked as whole words.
+	minSecretSubstringLength = 6
+)
+
+var variableRegexp = regexp.MustCompile(`#{([^}]+)}`)
+
 // NewExecutionContext creates a new execution context to be used with addon execution
 func NewExecutionContext(ocRunner *oc.OcRunner, sshCommander provision.SSHCommander) (*ExecutionContext, error) {
 	dockerCommander := docker.NewVmDockerCommander(sshCommander)
 	context := NewInterpolationContext()
-	return &ExecutionContext{ocRunner: ocRunner, dockerCommander: dockerCommander, sshCommander: sshCommander, interpolationContext: context}, nil
+	return &ExecutionContext{
+		ocRunner:             ocRunner,
+		dockerCommander:      dockerCommander,
+		sshCommander:         sshCommander,
+		interpolationContext: context,
+		secrets:              make(map[string]bool),
+		unresolved:           make(map[string]bool),
+	}, nil
 }
 
 // GetSSHCommander returns a ssh commander to execute ssh commands against the Minishift VM
@@ -70,3 +121,209 @@ func (ec *ExecutionContext) Interpolate(cmd string) string {
 func (ec *ExecutionContext) Vars() []string {
 	return ec.interpolationContext.Vars()
 }
+
+// Output returns the writer to which commands print their output. It defaults to os.Stdout.
+func (ec *ExecutionContext) Output() io.Writer {
+	if ec.output == nil {
+		return os.Stdout
+	}
+	return ec.output
+}
+
+// SetOutput sets the writer to which commands print their output.
+func (ec *ExecutionContext) SetOutput(output io.Writer) {
+	ec.output = output
+}
+
+// WorkingDir returns the directory against which commands resolve relative file names. If empty, the current
+// working directory of the process is used.
+func (ec *ExecutionContext) WorkingDir() string {
+	return ec.workingDir
+}
+
+// SetWorkingDir sets the directory against which commands resolve relative file names, including the ones passed to
+// oc. Unlike changing the working directory of the process, this allows addons to be applied concurrently.
+func (ec *ExecutionContext) SetWorkingDir(dir string) {
+	ec.workingDir = dir
+	if ec.ocRunner != nil {
+		ec.ocRunner = ec.ocRunner.InDir(dir)
+	}
+}
+
+// Clone creates a copy of this context with its own set of variables, so that the copy can be used to apply an
+// addon independently of other addons.
+func (ec *ExecutionContext) Clone() *ExecutionContext {
+	clone := &ExecutionContext{
+		ocRunner:             ec.ocRunner,
+		dockerCommander:      ec.dockerCommander,
+		sshCommander:         ec.sshCommander,
+		interpolationContext: NewInterpolationContext(),
+		output:               ec.output,
+		workingDir:           ec.workingDir,
+		allowHostCommands:    ec.allowHostCommands,
+		secrets:              make(map[string]bool),
+		dryRun:               ec.dryRun,
+		unresolved:           make(map[string]bool),
+	}
+	for _, key := range ec.Vars() {
+		clone.AddToContext(key, ec.Interpolate(fmt.Sprintf("#{%s}", key)))
+	}
+	for key := range ec.secrets {
+		clone.secrets[key] = true
+	}
+	return clone
+}
+
+// resolvePath resolves the specified file name against the working directory of this context.
+func (ec *ExecutionContext) resolvePath(name string) string {
+	if ec.workingDir == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(ec.workingDir, name)
+}
+
+// resolveAddOnFile resolves the specified file of the applied add-on. Relative file names are resolved against the
+// working directory of this context, which is the directory of the add-on.
+func (ec *ExecutionContext) resolveAddOnFile(name string) (string, error) {
+	addOnDir := ec.workingDir
+	if addOnDir == "" {
+		var err error
+		if addOnDir, err = os.Getwd(); err != nil {
+			return "", err
+		}
+	}
+	return ResolveAddOnFile(addOnDir, name)
+}
+
+// ResolveAddOnFile resolves the specified file name against the add-on directory. An error is returned for absolute
+// file names and for files which are not inside of the add-on directory, also if symbolic links point outside of it.
+func ResolveAddOnFile(addOnDir string, name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", errors.New(fmt.Sprintf("Absolute path '%s' is not allowed. Use a path relative to the add-on directory", name))
+	}
+
+	dir, err := filepath.EvalSymlinks(addOnDir)
+	if err != nil {
+		return "", err
+	}
+	file, err := filepath.EvalSymlinks(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(dir, file); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New(fmt.Sprintf("'%s' is not inside of the add-on directory", name))
+	}
+	return file, nil
+}
+
+// MarkSecret marks the value of the specified variable as secret. Secret values are masked in the output of Mask.
+func (ec *ExecutionContext) MarkSecret(key string) {
+	ec.secrets[key] = true
+}
+
+// Mask replaces the values of all secret variables contained in s.
+func (ec *ExecutionContext) Mask(s string) string {
+	for key := range ec.secrets {
+		value := ec.interpolationContext.Interpolate(fmt.Sprintf("#{%s}", key))
+		if value == "" || value == fmt.Sprintf("#{%s}", key) {
+			continue
+		}
+		if len(value) < minSecretSubstringLength {
+			s = maskToken(s, value)
+		} else {
+			s = strings.Replace(s, value, secretMask, -1)
+		}
+	}
+	return s
+}
+
+// maskToken masks the occurrences of value which are not part of a longer word, so that masking a short secret does
+// not garble unrelated output.
+func maskToken(s string, value string) string {
+	var masked bytes.Buffer
+	start := 0
+	for i := 0; i+len(value) <= len(s); {
+		end := i + len(value)
+		if s[i:end] == value && (i == 0 || !isWordByte(s[i-1])) && (end == len(s) || !isWordByte(s[end])) {
+			masked.WriteString(s[start:i])
+			masked.WriteString(secretMask)
+			start = end
+			i = end
+		} else {
+			i++
+		}
+	}
+	masked.WriteString(s[start:])
+	return masked.String()
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// RedactedValues returns the values of all variables of this context, with the values of secret variables masked.
+func (ec *ExecutionContext) RedactedValues() map[string]string {
+	values := make(map[string]string)
+	for _, key := range ec.Vars() {
+		if ec.secrets[key] {
+			values[key] = secretMask
+		} else {
+			values[key] = ec.Interpolate(fmt.Sprintf("#{%s}", key))
+		}
+	}
+	return values
+}
+
+// SetAllowHostCommands allows or forbids the execution of host commands, which run processes on the host.
+func (ec *ExecutionContext) SetAllowHostCommands(allow bool) {
+	ec.allowHostCommands = allow
+}
+
+// AllowHostCommands returns true if host commands may be executed.
+func (ec *ExecutionContext) AllowHostCommands() bool {
+	return ec.allowHostCommands
+}
+
+// SetDryRun enables or disables the dry-run mode. In dry-run mode commands are not executed, but recorded in the
+// plan of this context.
+func (ec *ExecutionContext) SetDryRun(dryRun bool) {
+	ec.dryRun = dryRun
+}
+
+// IsDryRun returns true if this context is in dry-run mode.
+func (ec *ExecutionContext) IsDryRun() bool {
+	return ec.dryRun
+}
+
+// Plan returns the commands recorded in dry-run mode.
+func (ec *ExecutionContext) Plan() []PlannedCommand {
+	return ec.plan
+}
+
+// record adds the specified raw command to the plan. A specified output variable is marked as unresolved, since its
+// value is only known once the command actually runs.
+func (ec *ExecutionContext) record(rawCommand string, ignoreError bool, outputVariable string) {
+	var unresolved []string
+	for _, match := range variableRegexp.FindAllStringSubmatch(rawCommand, -1) {
+		variable := match[1]
+		if ec.unresolved[variable] || !minishiftStrings.Contains(ec.Vars(), variable) {
+			unresolved = append(unresolved, variable)
+		}
+	}
+
+	commandType := strings.Fields(rawCommand + " ")
+	plannedCommand := PlannedCommand{
+		Command:     ec.Mask(ec.Interpolate(rawCommand)),
+		IgnoreError: ignoreError,
+		Unresolved:  unresolved,
+		Depth:       ec.planDepth,
+	}
+	if len(commandType) > 0 {
+		plannedCommand.Type = commandType[0]
+	}
+	ec.plan = append(ec.plan, plannedCommand)
+
+	if outputVariable != "" {
+		ec.unresolved[outputVariable] = true
+	}
+}
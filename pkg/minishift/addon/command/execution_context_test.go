@@ -0,0 +1,68 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_secrets_are_masked(t *testing.T) {
+	context, _ := NewExecutionContext(nil, nil)
+	context.AddToContext("USER", "developer")
+	context.AddToContext("PASSWORD", "s3cr3t")
+	context.MarkSecret("PASSWORD")
+
+	assert.Equal(t, "login developer *****", context.Mask(context.Interpolate("login #{USER} #{PASSWORD}")))
+}
+
+func Test_short_secrets_are_only_masked_as_whole_words(t *testing.T) {
+	context, _ := NewExecutionContext(nil, nil)
+	context.AddToContext("PIN", "42")
+	context.MarkSecret("PIN")
+
+	assert.Equal(t, "pin ***** accepted on port 8420", context.Mask("pin 42 accepted on port 8420"))
+	assert.Equal(t, "--pin=*****", context.Mask("--pin=42"))
+	assert.Equal(t, "*****", context.Mask("42"))
+}
+
+func Test_dry_run_records_commands(t *testing.T) {
+	context, _ := NewExecutionContext(nil, nil)
+	context.AddToContext("PASSWORD", "s3cr3t")
+	context.MarkSecret("PASSWORD")
+	context.SetDryRun(true)
+
+	condition, _ := NewCondition("defined PASSWORD")
+	commands := []Command{
+		NewOcCommand("oc whoami -t", false, "token"),
+		NewSshCommand("ssh login #{token} #{PASSWORD}", true, ""),
+		NewConditionalCommand("if defined PASSWORD", condition, []Command{NewEchoCommand("echo done", false)}, nil),
+	}
+	for _, c := range commands {
+		assert.NoError(t, c.Execute(context))
+	}
+
+	expectedPlan := []PlannedCommand{
+		{Type: "oc", Command: "oc whoami -t"},
+		{Type: "ssh", Command: "ssh login #{token} *****", IgnoreError: true, Unresolved: []string{"token"}},
+		{Type: "if", Command: "if defined PASSWORD"},
+		{Type: "echo", Command: "echo done", Depth: 1},
+		{Type: "end", Command: "end"},
+	}
+	assert.Equal(t, expectedPlan, context.Plan())
+}
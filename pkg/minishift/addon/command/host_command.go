@@ -0,0 +1,96 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+const (
+	// HostEnvPrefix is the prefix of the environment variables under which the interpolation variables are passed to
+	// host commands, eg the variable routing-suffix is passed as ADDON_ROUTING_SUFFIX
+	HostEnvPrefix = "ADDON_"
+
+	hostCommandsDisabledError = "Host commands are disabled. Use the '--allow-host-commands' flag or run 'minishift config set allow-host-commands true' to allow add-ons to run commands on the host"
+)
+
+var invalidEnvCharRegexp = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// HostCommand runs a process on the host. Since add-ons are not necessarily trusted, host commands need to be
+// allowed explicitly via the execution context.
+type HostCommand struct {
+	*defaultCommand
+}
+
+func NewHostCommand(command string, ignoreError bool, outputVariable string) *HostCommand {
+	defaultCommand := &defaultCommand{rawCommand: command, ignoreError: ignoreError, outputVariable: outputVariable}
+	hostCommand := &HostCommand{defaultCommand}
+	defaultCommand.fn = hostCommand.doExecute
+	return hostCommand
+}
+
+func (c *HostCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
+	if !ec.AllowHostCommands() {
+		return errors.New(hostCommandsDisabledError)
+	}
+
+	cmdLine := ec.Interpolate(strings.TrimSpace(strings.TrimPrefix(c.rawCommand, "host")))
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", cmdLine)
+	} else {
+		cmd = exec.Command("sh", "-c", cmdLine)
+	}
+	cmd.Dir = ec.WorkingDir()
+	cmd.Env = append(os.Environ(), HostEnv(ec)...)
+
+	stdOut, stdErr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdout = stdOut
+	cmd.Stderr = stdErr
+	if err := cmd.Run(); err != nil {
+		return errors.New(fmt.Sprintf("Error executing command '%s': %s %s", ec.Mask(cmdLine), err.Error(), ec.Mask(strings.TrimSpace(stdErr.String()))))
+	}
+
+	if outputVariable != "" {
+		ec.AddToContext(outputVariable, strings.TrimSpace(stdOut.String()))
+		return nil
+	}
+	fmt.Fprint(ec.Output(), ec.Mask(stdOut.String()))
+	return nil
+}
+
+// HostEnv returns the environment variables passed to host commands. Each interpolation variable is passed with
+// the HostEnvPrefix in upper case, with characters not allowed in environment variable names replaced by '_'. The path
+// of the kube config is passed as KUBECONFIG.
+func HostEnv(ec *ExecutionContext) []string {
+	var env []string
+	for _, key := range ec.Vars() {
+		name := HostEnvPrefix + invalidEnvCharRegexp.ReplaceAllString(strings.ToUpper(key), "_")
+		env = append(env, fmt.Sprintf("%s=%s", name, ec.Interpolate(fmt.Sprintf("#{%s}", key))))
+	}
+	if ec.GetOcCommander() != nil && ec.GetOcCommander().KubeConfigPath != "" {
+		env = append(env, fmt.Sprintf("KUBECONFIG=%s", ec.GetOcCommander().KubeConfigPath))
+	}
+	return env
+}
@@ -0,0 +1,79 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_host_commands_need_to_be_allowed(t *testing.T) {
+	context, err := NewExecutionContext(nil, nil)
+	assert.NoError(t, err)
+
+	err = NewHostCommand("host echo foo", false, "").Execute(context)
+	assert.EqualError(t, err, hostCommandsDisabledError)
+}
+
+func Test_host_command_gets_variables_as_environment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The test uses a POSIX shell")
+	}
+
+	testDir, err := ioutil.TempDir("", "minishift-test-host-command-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	context, err := NewExecutionContext(nil, nil)
+	assert.NoError(t, err)
+	output := new(bytes.Buffer)
+	context.SetOutput(output)
+	context.SetWorkingDir(testDir)
+	context.SetAllowHostCommands(true)
+	context.AddToContext("routing-suffix", "192.168.99.100.nip.io")
+	context.AddToContext("TOKEN", "secret")
+	context.MarkSecret("TOKEN")
+
+	err = NewHostCommand("host echo $ADDON_ROUTING_SUFFIX $ADDON_TOKEN && touch created", false, "").Execute(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "192.168.99.100.nip.io *****\n", output.String())
+	assert.FileExists(t, testDir+"/created")
+
+	err = NewHostCommand("host echo #{routing-suffix}", false, "suffix").Execute(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "192.168.99.100.nip.io", context.Interpolate("#{suffix}"))
+
+	err = NewHostCommand("host exit 3", false, "").Execute(context)
+	assert.Error(t, err)
+}
+
+func Test_host_env_contains_prefixed_variables(t *testing.T) {
+	context, err := NewExecutionContext(nil, nil)
+	assert.NoError(t, err)
+	context.AddToContext("ip", "192.168.99.100")
+	context.AddToContext("addon-name", "certs")
+
+	env := HostEnv(context)
+	sort.Strings(env)
+	assert.Equal(t, []string{"ADDON_ADDON_NAME=certs", "ADDON_IP=192.168.99.100"}, env)
+}
@@ -40,7 +40,7 @@ func (c *OcCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVari
 	// split off the actual 'oc' command. We are using our cached oc version to run oc commands
 	cmd := strings.Replace(c.rawCommand, "oc ", "", 1)
 	cmd = ec.Interpolate(cmd)
-	fmt.Print(".")
+	fmt.Fprint(ec.Output(), ".")
 
 	commander := ec.GetOcCommander()
 
@@ -39,7 +39,7 @@ func (c *OpenShiftCommand) doExecute(ec *ExecutionContext, ignoreError bool, out
 	// split off the actual 'openshift' command. We are using origin container to run those commands
 	cmd := strings.Replace(c.rawCommand, "openshift ", "", 1)
 	cmd = ec.Interpolate(cmd)
-	fmt.Print(".")
+	fmt.Fprint(ec.Output(), ".")
 
 	commander := ec.GetDockerCommander()
 	output, err := commander.Exec("-t", constants.OpenshiftContainerName, constants.OpenshiftOcExec, ec.Interpolate(cmd))
@@ -45,7 +45,7 @@ func (c *SleepCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputV
 		return err
 	}
 
-	fmt.Print(".")
+	fmt.Fprint(ec.Output(), ".")
 	time.Sleep(duration)
 	return nil
 }
@@ -36,12 +36,13 @@ func NewSshCommand(command string, ignoreError bool, outputVariable string) *SSH
 func (c *SSHCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
 	cmd := strings.Replace(c.rawCommand, "ssh ", "", 1)
 	cmd = ec.Interpolate(cmd)
-	fmt.Print(".")
+	fmt.Fprint(ec.Output(), ".")
 	commander := ec.GetSSHCommander()
 
 	output, err := commander.SSHCommand(ec.Interpolate(cmd))
 	if err != nil {
-		return errors.New(fmt.Sprintf("Error executing command '%s':", err.Error()))
+		// the error contains the interpolated command, including the values of secret variables
+		return errors.New(ec.Mask(fmt.Sprintf("Error executing command '%s':", err.Error())))
 	}
 
 	if outputVariable != "" {
@@ -0,0 +1,188 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minishift/minishift/pkg/util"
+	minishiftStrings "github.com/minishift/minishift/pkg/util/strings"
+)
+
+const (
+	// WaitForOutputVariable is the internal variable under which the output of a wait-for check is captured
+	WaitForOutputVariable = "wait-for-output"
+
+	defaultWaitForTimeout  = 5 * time.Minute
+	defaultWaitForInterval = 5 * time.Second
+
+	timeoutOption  = "timeout="
+	intervalOption = "interval="
+	matchOption    = "match="
+)
+
+// WaitForOptions are the options of a wait-for command.
+type WaitForOptions struct {
+	Timeout  time.Duration
+	Interval time.Duration
+	Match    *regexp.Regexp
+}
+
+// ParseWaitForOptions parses the leading 'timeout=', 'interval=' and 'match=' options of the arguments of a wait-for
+// command. Durations are either specified in seconds or as Go duration, eg 90s or 5m. The match value can be quoted.
+// The options and the remaining check command are returned.
+func ParseWaitForOptions(args string) (*WaitForOptions, string, error) {
+	options := &WaitForOptions{Timeout: defaultWaitForTimeout, Interval: defaultWaitForInterval}
+
+	rest := strings.TrimSpace(args)
+	for {
+		var option string
+		for _, candidate := range []string{timeoutOption, intervalOption, matchOption} {
+			if strings.HasPrefix(rest, candidate) {
+				option = candidate
+			}
+		}
+		if option == "" {
+			break
+		}
+
+		var value string
+		value, rest = nextValue(strings.TrimPrefix(rest, option))
+		switch option {
+		case timeoutOption, intervalOption:
+			duration, err := parseDuration(value)
+			if err != nil {
+				return nil, "", errors.New(fmt.Sprintf("Invalid duration '%s' for option '%s'", value, strings.TrimSuffix(option, "=")))
+			}
+			if option == timeoutOption {
+				options.Timeout = duration
+			} else {
+				options.Interval = duration
+			}
+		case matchOption:
+			r, err := regexp.Compile(value)
+			if err != nil {
+				return nil, "", errors.New(fmt.Sprintf("Invalid regular expression '%s': %s", value, err.Error()))
+			}
+			options.Match = r
+		}
+	}
+
+	if rest == "" {
+		return nil, "", errors.New("Missing command to wait for")
+	}
+	if options.Interval > options.Timeout {
+		return nil, "", errors.New(fmt.Sprintf("The interval %s exceeds the timeout %s", options.Interval, options.Timeout))
+	}
+
+	return options, rest, nil
+}
+
+// WaitForCommand re-runs an oc, openshift, ssh or docker check until it succeeds, or until its output matches the
+// configured pattern, or until the timeout is reached.
+type WaitForCommand struct {
+	*defaultCommand
+
+	check   Command
+	options *WaitForOptions
+}
+
+func NewWaitForCommand(command string, check Command, options *WaitForOptions, ignoreError bool, outputVariable string) *WaitForCommand {
+	defaultCommand := &defaultCommand{rawCommand: command, ignoreError: ignoreError, outputVariable: outputVariable}
+	waitForCommand := &WaitForCommand{defaultCommand, check, options}
+	defaultCommand.fn = waitForCommand.doExecute
+	return waitForCommand
+}
+
+// Check returns the command which is re-run until the wait condition is met.
+func (c *WaitForCommand) Check() Command {
+	return c.check
+}
+
+// Options returns the timeout, interval and match options of this command.
+func (c *WaitForCommand) Options() *WaitForOptions {
+	return c.options
+}
+
+func (c *WaitForCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
+	defer ec.RemoveFromContext(WaitForOutputVariable)
+
+	var (
+		output  string
+		lastErr error
+	)
+	check := func() error {
+		ec.RemoveFromContext(WaitForOutputVariable)
+		if lastErr = c.check.Execute(ec); lastErr != nil {
+			return &util.RetriableError{Err: lastErr}
+		}
+
+		output = ""
+		if minishiftStrings.Contains(ec.Vars(), WaitForOutputVariable) {
+			output = ec.Interpolate(fmt.Sprintf("#{%s}", WaitForOutputVariable))
+		}
+		if c.options.Match != nil && !c.options.Match.MatchString(output) {
+			lastErr = errors.New(fmt.Sprintf("Output '%s' does not match '%s'", output, c.options.Match.String()))
+			return &util.RetriableError{Err: lastErr}
+		}
+		return nil
+	}
+
+	attempts := int(c.options.Timeout/c.options.Interval) + 1
+	if err := util.RetryAfter(attempts, check, c.options.Interval); err != nil {
+		return errors.New(fmt.Sprintf("Timed out after %s waiting for '%s': %s", c.options.Timeout, c.String(), ec.Mask(lastErr.Error())))
+	}
+
+	if outputVariable != "" {
+		ec.AddToContext(outputVariable, output)
+	}
+	return nil
+}
+
+func nextValue(s string) (string, string) {
+	if len(s) > 0 && (s[0] == '"' || s[0] == '\'') {
+		if end := strings.IndexByte(s[1:], s[0]); end != -1 {
+			return s[1 : end+1], strings.TrimSpace(s[end+2:])
+		}
+	}
+	if i := strings.IndexAny(s, " \t"); i != -1 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0, errors.New("duration must be positive")
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, errors.New("duration must be positive")
+	}
+	return duration, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSSHCommander struct {
+	outputs []string
+	calls   int
+}
+
+func (f *fakeSSHCommander) SSHCommand(args string) (string, error) {
+	f.calls++
+	if f.calls > len(f.outputs) {
+		return "", errors.New("no more output")
+	}
+	return f.outputs[f.calls-1], nil
+}
+
+func Test_parsing_wait_for_options(t *testing.T) {
+	options, check, err := ParseWaitForOptions(` timeout=2m interval=10 match="Running|Completed" oc get pods`)
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, options.Timeout)
+	assert.Equal(t, 10*time.Second, options.Interval)
+	assert.Equal(t, "Running|Completed", options.Match.String())
+	assert.Equal(t, "oc get pods", check)
+
+	options, check, err = ParseWaitForOptions("oc rollout status dc/foo")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultWaitForTimeout, options.Timeout)
+	assert.Nil(t, options.Match)
+	assert.Equal(t, "oc rollout status dc/foo", check)
+}
+
+func Test_parsing_invalid_wait_for_options(t *testing.T) {
+	for _, args := range []string{"timeout=abc oc get pods", "match=( oc get pods", "timeout=10", "timeout=5 interval=10 oc get pods"} {
+		_, _, err := ParseWaitForOptions(args)
+		assert.Error(t, err, "Expected error for '%s'", args)
+	}
+}
+
+func Test_wait_for_retries_until_output_matches(t *testing.T) {
+	commander := &fakeSSHCommander{outputs: []string{"Pending", "Pending", "Running"}}
+	context, _ := NewExecutionContext(nil, commander)
+
+	options := &WaitForOptions{Timeout: time.Second, Interval: time.Millisecond, Match: regexp.MustCompile("^Running$")}
+	check := NewSshCommand("ssh get-status", false, WaitForOutputVariable)
+	waitFor := NewWaitForCommand("wait-for match=^Running$ ssh get-status", check, options, false, "status")
+
+	err := waitFor.Execute(context)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, commander.calls)
+	assert.Equal(t, "Running", context.Interpolate("#{status}"))
+	assert.NotContains(t, context.Vars(), WaitForOutputVariable)
+}
+
+func Test_wait_for_times_out(t *testing.T) {
+	commander := &fakeSSHCommander{outputs: []string{"Pending", "Pending", "Pending", "Pending"}}
+	context, _ := NewExecutionContext(nil, commander)
+
+	options := &WaitForOptions{Timeout: 3 * time.Millisecond, Interval: time.Millisecond, Match: regexp.MustCompile("^Running$")}
+	check := NewSshCommand("ssh get-status", false, WaitForOutputVariable)
+	waitFor := NewWaitForCommand("wait-for timeout=3ms interval=1ms match=^Running$ ssh get-status", check, options, false, "")
+
+	err := waitFor.Execute(context)
+	assert.EqualError(t, err, "Timed out after 3ms waiting for 'wait-for timeout=3ms interval=1ms match=^Running$ ssh get-status': Output 'Pending' does not match '^Running$'")
+}
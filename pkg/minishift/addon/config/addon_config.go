@@ -16,8 +16,42 @@ limitations under the License.
 
 package config
 
+import (
+	"time"
+)
+
+// MaxApplyHistory is the number of apply records kept per add-on
+const MaxApplyHistory = 10
+
 type AddOnConfig struct {
 	Name     string
 	Enabled  bool
 	Priority float64
+	History  []ApplyRecord `json:",omitempty"`
+}
+
+// ApplyRecord records a single application of an add-on. Checksum is the checksum of the add-on content at the time
+// it got applied and Variables contains the resolved variables, with the values of secret variables redacted.
+type ApplyRecord struct {
+	Timestamp time.Time
+	Checksum  string
+	Variables map[string]string `json:",omitempty"`
+	Success   bool
+	Error     string `json:",omitempty"`
+}
+
+// AddApplyRecord appends the specified record to the apply history, keeping at most MaxApplyHistory records.
+func (c *AddOnConfig) AddApplyRecord(record ApplyRecord) {
+	c.History = append(c.History, record)
+	if len(c.History) > MaxApplyHistory {
+		c.History = c.History[len(c.History)-MaxApplyHistory:]
+	}
+}
+
+// LastApply returns the most recent apply record or nil if the add-on has never been applied.
+func (c *AddOnConfig) LastApply() *ApplyRecord {
+	if len(c.History) == 0 {
+		return nil
+	}
+	return &c.History[len(c.History)-1]
 }
@@ -0,0 +1,268 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
+	"github.com/pkg/errors"
+)
+
+// applyGroup is a set of addons with the same effective priority. Addons within a group only need to wait for their
+// dependencies, groups are applied one after the other.
+type applyGroup struct {
+	priority int
+	addOns   []addon.AddOn
+}
+
+type applyResult struct {
+	name   string
+	output *bytes.Buffer
+	err    error
+}
+
+// buildApplyGroups builds the dependency graph of the enabled addons and groups them by priority. The effective
+// priority of an addon is the highest priority of itself and its dependencies, so that dependencies are always
+// applied first. Dependencies on addons which are not enabled are not part of the graph. Besides the groups in
+// ascending priority order, the dependencies between the enabled addons are returned.
+func buildApplyGroups(addOns []addon.AddOn) ([]*applyGroup, map[string][]string, error) {
+	enabled := make(map[string]addon.AddOn)
+	for _, addOn := range addOns {
+		if addOn.IsEnabled() {
+			enabled[addOn.MetaData().Name()] = addOn
+		}
+	}
+
+	dependencies := make(map[string][]string)
+	for name, addOn := range enabled {
+		deps, err := addOn.MetaData().Dependency()
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "Unable to determine the dependencies of addon '%s'", name)
+		}
+		for _, dep := range deps {
+			if _, ok := enabled[dep]; ok {
+				dependencies[name] = append(dependencies[name], dep)
+			}
+		}
+		sort.Strings(dependencies[name])
+	}
+
+	if err := checkDependencyCycles(dependencies); err != nil {
+		return nil, nil, err
+	}
+
+	effectivePriorities := make(map[string]int)
+	var effectivePriority func(name string) int
+	effectivePriority = func(name string) int {
+		if priority, ok := effectivePriorities[name]; ok {
+			return priority
+		}
+		priority := enabled[name].GetPriority()
+		for _, dep := range dependencies[name] {
+			if depPriority := effectivePriority(dep); depPriority > priority {
+				priority = depPriority
+			}
+		}
+		effectivePriorities[name] = priority
+		return priority
+	}
+
+	groupsByPriority := make(map[int]*applyGroup)
+	for name, addOn := range enabled {
+		priority := effectivePriority(name)
+		if groupsByPriority[priority] == nil {
+			groupsByPriority[priority] = &applyGroup{priority: priority}
+		}
+		groupsByPriority[priority].addOns = append(groupsByPriority[priority].addOns, addOn)
+	}
+
+	var groups []*applyGroup
+	for _, group := range groupsByPriority {
+		sort.Sort(addon.ByStatusThenPriorityThenName(group.addOns))
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].priority < groups[j].priority
+	})
+
+	return groups, dependencies, nil
+}
+
+// checkDependencyCycles returns an error describing the first dependency cycle found in the specified dependencies.
+func checkDependencyCycles(dependencies map[string][]string) error {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+
+	var names []string
+	for name := range dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	state := make(map[string]int)
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case inProgress:
+			var start int
+			for i, n := range path {
+				if n == name {
+					start = i
+				}
+			}
+			cycle := append(path[start:], name)
+			return errors.New(fmt.Sprintf("Dependency cycle detected between add-ons: %s", strings.Join(cycle, " -> ")))
+		}
+
+		state[name] = inProgress
+		path = append(path, name)
+		for _, dep := range dependencies[name] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyGroup applies the addons of the specified group with up to maxWorkers addons running at the same time. Each
+// addon is applied with its own copy of the context and its output is printed once the addon is done.
+func (m *AddOnManager) applyGroup(group *applyGroup, dependencies map[string][]string, context *command.ExecutionContext, maxWorkers int) error {
+	inGroup := make(map[string]bool)
+	for _, addOn := range group.addOns {
+		inGroup[addOn.MetaData().Name()] = true
+	}
+
+	pending := group.addOns
+	applied := make(map[string]bool)
+	results := make(chan applyResult)
+	running := 0
+	var firstErr error
+
+	isReady := func(addOn addon.AddOn) bool {
+		for _, dep := range dependencies[addOn.MetaData().Name()] {
+			if inGroup[dep] && !applied[dep] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for {
+		if firstErr == nil {
+			var waiting []addon.AddOn
+			for _, addOn := range pending {
+				if running < maxWorkers && isReady(addOn) {
+					running++
+					go m.applyBuffered(addOn, context, results)
+				} else {
+					waiting = append(waiting, addOn)
+				}
+			}
+			pending = waiting
+		}
+
+		if running == 0 {
+			break
+		}
+
+		result := <-results
+		running--
+		fmt.Fprint(context.Output(), result.output.String())
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = errors.Wrapf(result.err, "Error applying addon '%s'", result.name)
+			}
+			continue
+		}
+		applied[result.name] = true
+	}
+
+	return firstErr
+}
+
+func (m *AddOnManager) applyBuffered(addOn addon.AddOn, context *command.ExecutionContext, results chan<- applyResult) {
+	output := new(bytes.Buffer)
+	addOnContext := context.Clone()
+	addOnContext.SetOutput(output)
+	addOnContext.SetWorkingDir(addOn.InstallPath())
+
+	err := m.ApplyAddOn(addOn, addOnContext)
+	results <- applyResult{name: addOn.MetaData().Name(), output: output, err: err}
+}
+
+// DependencyNode is a node of the dependency tree of an addon.
+type DependencyNode struct {
+	Name      string `json:"name" yaml:"name"`
+	Installed bool   `json:"installed" yaml:"installed"`
+	// Cycle is true if the addon already occurs further up in the tree. Its dependencies are not resolved again.
+	Cycle        bool              `json:"cycle,omitempty" yaml:"cycle,omitempty"`
+	Dependencies []*DependencyNode `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+}
+
+// DependencyTree resolves the transitive dependencies of the specified addon. Dependencies which are not installed
+// are part of the tree, but cannot be resolved any further.
+func (m *AddOnManager) DependencyTree(addonName string) ([]*DependencyNode, error) {
+	addOn := m.addOns[addonName]
+	if addOn == nil {
+		return nil, errors.New(fmt.Sprintf("Unable to find addon '%s' in addon directory '%s'", addonName, m.baseDir))
+	}
+	return m.dependencyNodes(addOn, map[string]bool{addonName: true})
+}
+
+func (m *AddOnManager) dependencyNodes(addOn addon.AddOn, path map[string]bool) ([]*DependencyNode, error) {
+	dependencies, err := addOn.MetaData().Dependency()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unable to determine the dependencies of addon '%s'", addOn.MetaData().Name())
+	}
+
+	var nodes []*DependencyNode
+	for _, name := range dependencies {
+		dependency := m.addOns[name]
+		node := &DependencyNode{Name: name, Installed: dependency != nil, Cycle: path[name]}
+		if dependency != nil && !node.Cycle {
+			path[name] = true
+			node.Dependencies, err = m.dependencyNodes(dependency, path)
+			delete(path, name)
+			if err != nil {
+				return nil, err
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
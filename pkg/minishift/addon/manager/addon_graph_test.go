@@ -0,0 +1,178 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minishift/minishift/cmd/testing/cli"
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
+	"github.com/minishift/minishift/pkg/minishift/addon/config"
+	instanceState "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildApplyGroups(t *testing.T) {
+	addOns := []addon.AddOn{
+		createGraphTestAddOn(t, "a", 0, "", true),
+		createGraphTestAddOn(t, "b", 0, "c", true),
+		createGraphTestAddOn(t, "c", 5, "", true),
+		createGraphTestAddOn(t, "d", 5, "e", true),
+		createGraphTestAddOn(t, "e", 0, "", false),
+	}
+
+	groups, dependencies, err := buildApplyGroups(addOns)
+	assert.NoError(t, err)
+	assert.Len(t, groups, 2)
+
+	assert.Equal(t, 0, groups[0].priority)
+	assert.Equal(t, []string{"a"}, addOnNames(groups[0].addOns))
+
+	// b depends on c and is therefore moved into the group of c
+	assert.Equal(t, 5, groups[1].priority)
+	assert.Equal(t, []string{"b", "c", "d"}, addOnNames(groups[1].addOns))
+
+	assert.Equal(t, []string{"c"}, dependencies["b"])
+	assert.Empty(t, dependencies["d"], "Dependencies on disabled addons are not part of the graph")
+}
+
+func TestBuildApplyGroupsDetectsCycles(t *testing.T) {
+	addOns := []addon.AddOn{
+		createGraphTestAddOn(t, "x", 0, "y", true),
+		createGraphTestAddOn(t, "y", 0, "z", true),
+		createGraphTestAddOn(t, "z", 0, "x", true),
+	}
+
+	_, _, err := buildApplyGroups(addOns)
+	assert.EqualError(t, err, "Dependency cycle detected between add-ons: x -> y -> z -> x")
+}
+
+func TestApplyConcurrently(t *testing.T) {
+	var err error
+	tmpMinishiftHomeDir := cli.SetupTmpMinishiftHome(t)
+	defer os.RemoveAll(tmpMinishiftHomeDir)
+	instanceState.InstanceStateConfig, err = instanceState.NewInstanceStateConfig(filepath.Join(tmpMinishiftHomeDir, "config"))
+	assert.NoError(t, err, "Unexpected error creating instance config in '%s'", tmpMinishiftHomeDir)
+
+	addOns := map[string]addon.AddOn{}
+	for _, addOn := range []addon.AddOn{
+		createGraphTestAddOn(t, "a", 0, "", true),
+		createGraphTestAddOn(t, "b", 5, "c", true),
+		createGraphTestAddOn(t, "c", 5, "", true),
+		createGraphTestAddOn(t, "d", 5, "", true),
+	} {
+		addOns[addOn.MetaData().Name()] = addOn
+	}
+	manager := &AddOnManager{addOns: addOns, configs: make(map[string]*config.AddOnConfig)}
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+
+	tee := cli.CreateTee(t, false)
+	err = manager.ApplyConcurrently(context, 3)
+	tee.Close()
+	assert.NoError(t, err)
+
+	output := tee.StdoutBuffer.String()
+	for _, name := range []string{"a", "b", "c", "d"} {
+		assert.Contains(t, output, "-- Applying addon '"+name+"':\nfirst line of "+name+"\nsecond line of "+name+"\n")
+	}
+	assert.True(t, strings.Index(output, "addon 'a'") < strings.Index(output, "addon 'c'"), "Lower priorities are applied first")
+	assert.True(t, strings.Index(output, "addon 'c'") < strings.Index(output, "addon 'b'"), "Dependencies are applied first")
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		status, _ := manager.ApplyStatus(addOns[name])
+		assert.NotEqual(t, NotAppliedStatus, status)
+	}
+}
+
+func TestApplyConcurrentlyWithCycle(t *testing.T) {
+	addOns := map[string]addon.AddOn{
+		"x": createGraphTestAddOn(t, "x", 0, "y", true),
+		"y": createGraphTestAddOn(t, "y", 0, "x", true),
+	}
+	manager := &AddOnManager{addOns: addOns, configs: make(map[string]*config.AddOnConfig)}
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+
+	tee := cli.CreateTee(t, false)
+	err := manager.ApplyConcurrently(context, 2)
+	tee.Close()
+
+	assert.EqualError(t, err, "Dependency cycle detected between add-ons: x -> y -> x")
+	assert.Empty(t, tee.StdoutBuffer.String(), "No addon should be applied")
+}
+
+func createGraphTestAddOn(t *testing.T, name string, priority int, dependsOn string, enabled bool) addon.AddOn {
+	testAddonMap := getTestAddonMap(name, "test description", "", "", "")
+	if dependsOn != "" {
+		testAddonMap["Depends-On"] = dependsOn
+	}
+	addOnMeta, err := addon.NewAddOnMeta(testAddonMap)
+	assert.NoError(t, err, "Failed to create addon meta")
+
+	commands := []command.Command{
+		command.NewEchoCommand("echo first line of "+name, false),
+		command.NewEchoCommand("echo second line of "+name, false),
+	}
+	addOn := addon.NewAddOn(addOnMeta, addOnMeta, commands, []command.Command{}, "")
+	addOn.SetEnabled(enabled)
+	addOn.SetPriority(priority)
+	return addOn
+}
+
+func addOnNames(addOns []addon.AddOn) []string {
+	var names []string
+	for _, addOn := range addOns {
+		names = append(names, addOn.MetaData().Name())
+	}
+	return names
+}
+
+func TestDependencyTree(t *testing.T) {
+	addOns := map[string]addon.AddOn{}
+	for _, addOn := range []addon.AddOn{
+		createGraphTestAddOn(t, "a", 0, "b, c", true),
+		createGraphTestAddOn(t, "b", 0, "c, missing", false),
+		createGraphTestAddOn(t, "c", 0, "a", true),
+	} {
+		addOns[addOn.MetaData().Name()] = addOn
+	}
+	manager := &AddOnManager{addOns: addOns}
+
+	tree, err := manager.DependencyTree("a")
+	assert.NoError(t, err)
+
+	expected := []*DependencyNode{
+		{Name: "b", Installed: true, Dependencies: []*DependencyNode{
+			{Name: "c", Installed: true, Dependencies: []*DependencyNode{
+				{Name: "a", Installed: true, Cycle: true},
+			}},
+			{Name: "missing"},
+		}},
+		{Name: "c", Installed: true, Dependencies: []*DependencyNode{
+			{Name: "a", Installed: true, Cycle: true},
+		}},
+	}
+	assert.Equal(t, expected, tree)
+
+	_, err = manager.DependencyTree("unknown")
+	assert.Error(t, err)
+}
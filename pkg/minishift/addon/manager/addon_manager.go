@@ -17,11 +17,15 @@ limitations under the License.
 package manager
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
+	"sync"
+	"time"
 
 	"strings"
 
@@ -40,11 +44,33 @@ import (
 
 const versionRangeSeparator = ","
 
+const (
+	// NotAppliedStatus is the apply status of an addon which has never been applied
+	NotAppliedStatus = "not applied"
+	// AppliedStatus is the apply status of an addon which got successfully applied and did not change since
+	AppliedStatus = "applied"
+	// StaleStatus is the apply status of an addon whose content changed since it got successfully applied
+	StaleStatus = "stale"
+	// FailedStatus is the apply status of an addon whose last application failed
+	FailedStatus = "failed"
+)
+
+// MetaDataChange describes the change of a single metadata field between the installed and the updated version of
+// an addon.
+type MetaDataChange struct {
+	Field    string
+	OldValue string
+	NewValue string
+}
+
 // AddOnManager is the central point for all operations around managing addons. An addon
 // manager is created for the base directory of a addon collection.
 type AddOnManager struct {
 	baseDir string
 	addOns  map[string]addon.AddOn
+	configs map[string]*config.AddOnConfig
+
+	configsLock sync.Mutex
 }
 
 // NewAddOnManager creates a new addon manager for the specified addon directory.
@@ -58,6 +84,10 @@ func NewAddOnManager(baseDir string, configMap map[string]*config.AddOnConfig) (
 		return nil, errors.Wrapf(err, "Unable to create addon manager for non existing directory '%s'. ", baseDir)
 	}
 
+	if configMap == nil {
+		configMap = make(map[string]*config.AddOnConfig)
+	}
+
 	detectedAddOns := make(map[string]addon.AddOn)
 	p := parser.NewAddOnParser()
 
@@ -81,7 +111,7 @@ func NewAddOnManager(baseDir string, configMap map[string]*config.AddOnConfig) (
 		detectedAddOns[addOn.MetaData().Name()] = addOn
 	}
 
-	return &AddOnManager{baseDir: baseDir, addOns: detectedAddOns}, nil
+	return &AddOnManager{baseDir: baseDir, addOns: detectedAddOns, configs: configMap}, nil
 }
 
 // BaseDir returns the base directory against which this addon manager was initialised
@@ -94,21 +124,34 @@ func (m *AddOnManager) List() []addon.AddOn {
 	return m.mapToSlice()
 }
 
-// Install installs the addon provided via the source directory into the addon directory managed by this addon manager.
+// Install installs the addon provided via source into the addon directory managed by this addon manager. The source
+// can be a local directory, a Git repository or a tar.gz/zip archive (see ParseAddOnSource). The source is recorded
+// in the installed addon directory, so that the addon can be reinstalled later on.
 // It returns the name of the installed addon. In case an error occurs the empty string and an error are returned.
 func (m *AddOnManager) Install(source string, force bool) (string, error) {
-	// For now we are assuming that we are dealing with a file path. This can be extended to support URLs, etc as well (HF)
-	if !filehelper.IsDirectory(source) {
-		return "", errors.New(fmt.Sprintf("The source of a addon needs to be a directory. '%s' is not", source))
+	addOnSource, err := ParseAddOnSource(source)
+	if err != nil {
+		return "", err
+	}
+
+	workDir, err := ioutil.TempDir("", "minishift-addon-install-")
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to create temporary directory for addon installation")
+	}
+	defer os.RemoveAll(workDir)
+
+	addOnDir, err := addOnSource.Fetch(workDir)
+	if err != nil {
+		return "", err
 	}
 
 	p := parser.NewAddOnParser()
-	addOn, err := p.Parse(source)
+	addOn, err := p.Parse(addOnDir)
 	if err != nil {
 		return "", errors.Wrap(err, "Unable to parse specified addon")
 	}
 
-	targetPath := filepath.Join(m.baseDir, filepath.Base(source))
+	targetPath := filepath.Join(m.baseDir, filepath.Base(addOnDir))
 	if filehelper.IsDirectory(targetPath) {
 		if force {
 			os.RemoveAll(targetPath)
@@ -117,10 +160,81 @@ func (m *AddOnManager) Install(source string, force bool) (string, error) {
 		}
 	}
 
-	filehelper.CopyDir(source, targetPath)
+	if err := filehelper.CopyDir(addOnDir, targetPath); err != nil {
+		return "", errors.Wrapf(err, "Unable to copy addon to '%s'", targetPath)
+	}
+	if err := addOnSource.Write(targetPath); err != nil {
+		return "", errors.Wrap(err, "Unable to record addon source")
+	}
+
 	return addOn.MetaData().Name(), nil
 }
 
+// Update reinstalls the addon specified via addonName from the source recorded at installation time. The metadata
+// of the fetched addon is compared against the installed copy and the differences are returned. In case the new copy
+// of the addon cannot be installed or parsed, the previously installed copy is restored.
+func (m *AddOnManager) Update(addonName string) ([]MetaDataChange, error) {
+	installedAddOn := m.addOns[addonName]
+	if installedAddOn == nil {
+		return nil, errors.New(fmt.Sprintf("Unable to find addon '%s' in addon directory '%s'", addonName, m.baseDir))
+	}
+
+	targetPath := installedAddOn.InstallPath()
+	addOnSource, err := ReadAddOnSource(targetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir, err := ioutil.TempDir("", "minishift-addon-update-")
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to create temporary directory for addon update")
+	}
+	defer os.RemoveAll(workDir)
+
+	sourceDir := filepath.Join(workDir, "source")
+	if err := os.Mkdir(sourceDir, 0755); err != nil {
+		return nil, errors.Wrap(err, "Unable to create temporary directory for addon update")
+	}
+	addOnDir, err := addOnSource.Fetch(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser.NewAddOnParser()
+	newAddOn, err := p.Parse(addOnDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unable to parse addon fetched from '%s'", addOnSource)
+	}
+	if newAddOn.MetaData().Name() != addonName {
+		return nil, errors.New(fmt.Sprintf("The addon fetched from '%s' is named '%s' instead of '%s'", addOnSource, newAddOn.MetaData().Name(), addonName))
+	}
+
+	changes, err := diffMetaData(installedAddOn.MetaData(), newAddOn.MetaData())
+	if err != nil {
+		return nil, err
+	}
+
+	backupPath := filepath.Join(workDir, "backup", addonName)
+	if err := filehelper.CopyDir(targetPath, backupPath); err != nil {
+		return nil, errors.Wrapf(err, "Unable to back up addon '%s'", addonName)
+	}
+
+	updatedAddOn, err := replaceAddOn(addOnDir, targetPath, addOnSource)
+	if err != nil {
+		os.RemoveAll(targetPath)
+		if restoreErr := filehelper.CopyDir(backupPath, targetPath); restoreErr != nil {
+			return nil, errors.Wrapf(restoreErr, "Unable to restore addon '%s' after failed update (%s)", addonName, err.Error())
+		}
+		return nil, errors.Wrapf(err, "Unable to update addon '%s'. The previous version has been restored", addonName)
+	}
+
+	updatedAddOn.SetEnabled(installedAddOn.IsEnabled())
+	updatedAddOn.SetPriority(installedAddOn.GetPriority())
+	m.addOns[addonName] = updatedAddOn
+
+	return changes, nil
+}
+
 // Get returns the addon with the specified name. nil is returned if there is no addon with this name.
 func (m *AddOnManager) Get(name string) addon.AddOn {
 	return m.addOns[name]
@@ -137,7 +251,7 @@ func (m *AddOnManager) Enable(addonName string, priority int) (*config.AddOnConf
 	addOn.SetEnabled(true)
 	addOn.SetPriority(priority)
 
-	return &config.AddOnConfig{addonName, true, float64(priority)}, nil
+	return &config.AddOnConfig{Name: addonName, Enabled: true, Priority: float64(priority), History: m.history(addonName)}, nil
 }
 
 // UnInstall uninstalls the addon specified via addonName.
@@ -166,7 +280,27 @@ func (m *AddOnManager) Disable(addonName string) (*config.AddOnConfig, error) {
 
 	addOn.SetEnabled(false)
 
-	return &config.AddOnConfig{addonName, false, float64(addOn.GetPriority())}, nil
+	return &config.AddOnConfig{Name: addonName, Enabled: false, Priority: float64(addOn.GetPriority()), History: m.history(addonName)}, nil
+}
+
+// ApplyStatus returns the apply status of the specified addon, one of NotAppliedStatus, AppliedStatus, StaleStatus
+// or FailedStatus, together with the most recent apply record. The record is nil if the addon has never been applied.
+func (m *AddOnManager) ApplyStatus(addOn addon.AddOn) (string, *config.ApplyRecord) {
+	addOnConfig := m.configs[addOn.MetaData().Name()]
+	if addOnConfig == nil || addOnConfig.LastApply() == nil {
+		return NotAppliedStatus, nil
+	}
+
+	lastApply := addOnConfig.LastApply()
+	if !lastApply.Success {
+		return FailedStatus, lastApply
+	}
+
+	checksum, err := addOnChecksum(addOn.InstallPath())
+	if err != nil || checksum != lastApply.Checksum {
+		return StaleStatus, lastApply
+	}
+	return AppliedStatus, lastApply
 }
 
 // Apply executes all enabled addons.
@@ -185,6 +319,29 @@ func (m *AddOnManager) Apply(context *command.ExecutionContext) error {
 	return nil
 }
 
+// ApplyConcurrently executes all enabled addons, running up to maxWorkers independent addons at the same time.
+// Addons are applied in groups of ascending priority. Within a group, an addon is applied once all the addons it
+// depends on (see AddOnMeta.Dependency) are applied. An addon which depends on an addon with a higher priority is moved
+// into the group of its dependency. The output of each addon is buffered and printed once the addon is applied.
+// An error is returned for dependency cycles, in which case no addon is applied. After the first failure no further
+// addons are started.
+func (m *AddOnManager) ApplyConcurrently(context *command.ExecutionContext, maxWorkers int) error {
+	groups, dependencies, err := buildApplyGroups(m.mapToSlice())
+	if err != nil {
+		return err
+	}
+	if maxWorkers < 1 {
+		maxWorkers = 1
+	}
+
+	for _, group := range groups {
+		if err := m.applyGroup(group, dependencies, context, maxWorkers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (m *AddOnManager) IsInstalled(name string) bool {
 	_, installed := m.addOns[name]
 	return installed
@@ -194,16 +351,59 @@ func (m *AddOnManager) String() string {
 	return fmt.Sprintf("%#v", m)
 }
 
+// ApplyAddOn applies the specified addon. In case a command fails, the error is returned and the commands executed
+// so far are not reverted.
 func (m *AddOnManager) ApplyAddOn(addOn addon.AddOn, context *command.ExecutionContext) error {
-	fmt.Print(fmt.Sprintf("-- Applying addon '%s':", addOn.MetaData().Name()))
+	return m.applyAddOn(addOn, context, false)
+}
+
+// ApplyAddOnAtomic applies the specified addon like ApplyAddOn. In case a command fails, the commands of the
+// .addon.remove file of the addon are run to roll back the changes made so far. Errors of the remove commands are
+// ignored. The returned error names the failed command.
+func (m *AddOnManager) ApplyAddOnAtomic(addOn addon.AddOn, context *command.ExecutionContext) error {
+	return m.applyAddOn(addOn, context, true)
+}
+
+// applyAddOn applies the specified addon and records the outcome in the apply history of the addon. In atomic mode
+// the apply history stays unchanged if applying fails, since a failed command triggers a rollback and a failed
+// verification does not change anything.
+func (m *AddOnManager) applyAddOn(addOn addon.AddOn, context *command.ExecutionContext, atomic bool) error {
+	if context.IsDryRun() {
+		fmt.Fprint(context.Output(), fmt.Sprintf("-- Dry run of applying addon '%s':\n", addOn.MetaData().Name()))
+	} else {
+		fmt.Fprint(context.Output(), fmt.Sprintf("-- Applying addon '%s':", addOn.MetaData().Name()))
+	}
 	context.AddToContext("addon-name", addOn.MetaData().Name())
 	defer context.RemoveFromContext("addon-name")
 
+	err := m.executeAddOn(addOn, context, atomic)
+	if err != nil && atomic {
+		return err
+	}
+
+	if context.IsDryRun() {
+		return err
+	}
+	m.recordApply(addOn, context, err)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(context.Output(), "\n")
+	return nil
+}
+
+// executeAddOn verifies the requirements of the specified addon and executes its commands. In atomic mode a failed
+// command is rolled back, within the same working directory as the commands of the addon.
+func (m *AddOnManager) executeAddOn(addOn addon.AddOn, context *command.ExecutionContext, atomic bool) error {
 	if err := addVarDefaultsToContext(addOn, context); err != nil {
 		return err
 	}
 
 	addonMetadata := addOn.MetaData()
+	if err := markSecretVariables(context, addonMetadata); err != nil {
+		return err
+	}
 	if err := verifyRequiredOpenshiftVersion(addonMetadata); err != nil {
 		return err
 	}
@@ -217,38 +417,116 @@ func (m *AddOnManager) ApplyAddOn(addOn addon.AddOn, context *command.ExecutionC
 		return err
 	}
 
-	oldDir, err := os.Getwd()
-	if err != nil {
-		return errors.Wrap(err, "Unable to apply addon due to failing IO operation")
+	// a context with a working directory resolves relative file names itself
+	if context.WorkingDir() == "" {
+		oldDir, err := os.Getwd()
+		if err != nil {
+			return errors.Wrap(err, "Unable to apply addon due to failing IO operation")
+		}
+		defer os.Chdir(oldDir)
+
+		os.Chdir(addOn.InstallPath())
 	}
-	defer os.Chdir(oldDir)
+	failedCommand, err := addonCmdExecution(addOn.Commands(), context)
+	if err != nil && atomic {
+		return rollbackAddOn(addOn, failedCommand, err, context)
+	}
+	return err
+}
 
-	os.Chdir(addOn.InstallPath())
-	if err := addonCmdExecution(addOn.Commands(), context); err != nil {
-		return err
+// recordApply adds the outcome of applying the specified addon to its apply history.
+func (m *AddOnManager) recordApply(addOn addon.AddOn, context *command.ExecutionContext, applyErr error) {
+	m.configsLock.Lock()
+	defer m.configsLock.Unlock()
+
+	name := addOn.MetaData().Name()
+	addOnConfig := m.configs[name]
+	if addOnConfig == nil {
+		addOnConfig = &config.AddOnConfig{Name: name, Enabled: addOn.IsEnabled(), Priority: float64(addOn.GetPriority())}
+		m.configs[name] = addOnConfig
+	}
+
+	// a missing checksum marks the addon as stale, which is the safe choice
+	checksum, _ := addOnChecksum(addOn.InstallPath())
+	record := config.ApplyRecord{
+		Timestamp: time.Now().UTC(),
+		Checksum:  checksum,
+		Variables: context.RedactedValues(),
+		Success:   applyErr == nil,
+	}
+	if applyErr != nil {
+		record.Error = context.Mask(applyErr.Error())
 	}
+	addOnConfig.AddApplyRecord(record)
+}
 
-	fmt.Print("\n")
+func (m *AddOnManager) history(addonName string) []config.ApplyRecord {
+	if addOnConfig := m.configs[addonName]; addOnConfig != nil {
+		return addOnConfig.History
+	}
 	return nil
 }
 
-func (m *AddOnManager) RemoveAddOn(addOn addon.AddOn, context *command.ExecutionContext) error {
-	fmt.Print(fmt.Sprintf("-- Removing addon '%s':", addOn.MetaData().Name()))
-	context.AddToContext("addon-name", addOn.MetaData().Name())
-	defer context.RemoveFromContext("addon-name")
+// rollbackAddOn runs the remove commands of the specified addon after failedCommand failed with cmdErr.
+func rollbackAddOn(addOn addon.AddOn, failedCommand command.Command, cmdErr error, context *command.ExecutionContext) error {
+	if len(addOn.RemoveCommands()) == 0 {
+		return errors.Wrapf(cmdErr, "Command '%s' failed. Unable to roll back addon '%s', since it has no %s.addon.remove file",
+			failedCommand.String(), addOn.MetaData().Name(), addOn.MetaData().Name())
+	}
+	if err := prepareRemoveAddOn(addOn, context); err != nil {
+		return errors.Wrapf(cmdErr, "Command '%s' failed. Unable to roll back addon '%s': %s",
+			failedCommand.String(), addOn.MetaData().Name(), err.Error())
+	}
+
+	fmt.Fprint(context.Output(), fmt.Sprintf("\n-- Rolling back addon '%s':", addOn.MetaData().Name()))
+	for _, c := range addOn.RemoveCommands() {
+		// best effort, the addon might only be partially applied
+		c.Execute(context)
+	}
+	fmt.Fprint(context.Output(), "\n")
 
+	return errors.Wrapf(cmdErr, "Command '%s' failed. The changes of addon '%s' have been rolled back", failedCommand.String(), addOn.MetaData().Name())
+}
+
+// prepareRemoveAddOn adds the variable defaults of the specified addon and of its remove file to the context and
+// verifies the requirements of the remove file.
+func prepareRemoveAddOn(addOn addon.AddOn, context *command.ExecutionContext) error {
 	if err := addVarDefaultsToContext(addOn, context); err != nil {
 		return err
 	}
 
 	addonMetadata := addOn.MetaDataForAddonRemove()
+	if addonMetadata == nil {
+		return nil
+	}
+	if err := addMetaVarDefaultsToContext(addonMetadata, context); err != nil {
+		return err
+	}
+	if err := markSecretVariables(context, addOn.MetaData()); err != nil {
+		return err
+	}
+	if err := markSecretVariables(context, addonMetadata); err != nil {
+		return err
+	}
 	if err := verifyRequiredOpenshiftVersion(addonMetadata); err != nil {
 		return err
 	}
 	if err := verifyRequiredMinishiftVersion(addonMetadata); err != nil {
 		return err
 	}
-	if err := verifyRequiredVariablesInContext(context, addonMetadata); err != nil {
+	return verifyRequiredVariablesInContext(context, addonMetadata)
+}
+
+func (m *AddOnManager) RemoveAddOn(addOn addon.AddOn, context *command.ExecutionContext) error {
+	if context.IsDryRun() {
+		fmt.Fprint(context.Output(), fmt.Sprintf("-- Dry run of removing addon '%s':\n", addOn.MetaData().Name()))
+	} else {
+		fmt.Fprint(context.Output(), fmt.Sprintf("-- Removing addon '%s':", addOn.MetaData().Name()))
+	}
+	context.AddToContext("addon-name", addOn.MetaData().Name())
+	defer context.RemoveFromContext("addon-name")
+
+	if err := prepareRemoveAddOn(addOn, context); err != nil {
 		return err
 	}
 
@@ -259,13 +537,29 @@ func (m *AddOnManager) RemoveAddOn(addOn addon.AddOn, context *command.Execution
 	defer os.Chdir(oldDir)
 
 	os.Chdir(addOn.InstallPath())
-	if err := addonCmdExecution(addOn.RemoveCommands(), context); err != nil {
+	if _, err := addonCmdExecution(addOn.RemoveCommands(), context); err != nil {
 		return err
 	}
-	fmt.Print("\n")
+	if !context.IsDryRun() {
+		fmt.Fprint(context.Output(), "\n")
+	}
 	return nil
 }
 
+// replaceAddOn replaces the addon installed in targetPath with the content of addOnDir and parses the result.
+func replaceAddOn(addOnDir string, targetPath string, addOnSource *AddOnSource) (addon.AddOn, error) {
+	if err := os.RemoveAll(targetPath); err != nil {
+		return nil, err
+	}
+	if err := filehelper.CopyDir(addOnDir, targetPath); err != nil {
+		return nil, err
+	}
+	if err := addOnSource.Write(targetPath); err != nil {
+		return nil, err
+	}
+	return parser.NewAddOnParser().Parse(targetPath)
+}
+
 func (m *AddOnManager) mapToSlice() []addon.AddOn {
 	addOnSlice := make([]addon.AddOn, len(m.addOns))
 	i := 0
@@ -299,6 +593,39 @@ func verifyRequiredVariablesInContext(context *command.ExecutionContext, meta ad
 		return fmt.Errorf("The variable(s) '%s' are required by the add-on, but are not defined in the context", missing)
 	}
 
+	declarations, err := meta.VarDeclarations()
+	if err != nil {
+		return err
+	}
+	var invalidVars []string
+	for _, declaration := range declarations {
+		if !check[declaration.Name] {
+			continue
+		}
+		value := context.Interpolate(fmt.Sprintf("#{%s}", declaration.Name))
+		if err := declaration.Validate(value); err != nil {
+			invalidVars = append(invalidVars, err.Error())
+		}
+	}
+	if len(invalidVars) > 0 {
+		return errors.New(fmt.Sprintf("Invalid add-on variable(s): %s", strings.Join(invalidVars, "; ")))
+	}
+
+	return nil
+}
+
+// markSecretVariables marks the variables declared as secret in the specified metadata as secret in the context,
+// so that their values are masked in the output.
+func markSecretVariables(context *command.ExecutionContext, meta addon.AddOnMeta) error {
+	declarations, err := meta.VarDeclarations()
+	if err != nil {
+		return err
+	}
+	for _, declaration := range declarations {
+		if declaration.IsSecret() {
+			context.MarkSecret(declaration.Name)
+		}
+	}
 	return nil
 }
 
@@ -347,6 +674,41 @@ func (m *AddOnManager) verifyRequiredAddons(metadata addon.AddOnMeta) error {
 	return err
 }
 
+// diffMetaData compares the metadata fields relevant for an addon update and returns the fields which differ.
+func diffMetaData(oldMeta addon.AddOnMeta, newMeta addon.AddOnMeta) ([]MetaDataChange, error) {
+	oldRequiredVars, err := oldMeta.RequiredVars()
+	if err != nil {
+		return nil, err
+	}
+	newRequiredVars, err := newMeta.RequiredVars()
+	if err != nil {
+		return nil, err
+	}
+	oldDependencies, err := oldMeta.Dependency()
+	if err != nil {
+		return nil, err
+	}
+	newDependencies, err := newMeta.Dependency()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []MetaDataChange{
+		{addon.NameMetaTagName, oldMeta.Name(), newMeta.Name()},
+		{addon.RequiredOpenShiftVersion, oldMeta.OpenShiftVersion(), newMeta.OpenShiftVersion()},
+		{"Required-Vars", strings.Join(oldRequiredVars, ", "), strings.Join(newRequiredVars, ", ")},
+		{"Depends-On", strings.Join(oldDependencies, ", "), strings.Join(newDependencies, ", ")},
+	}
+
+	var changes []MetaDataChange
+	for _, field := range fields {
+		if field.OldValue != field.NewValue {
+			changes = append(changes, field)
+		}
+	}
+	return changes, nil
+}
+
 func setStateAndPriority(addOn addon.AddOn, configMap map[string]*config.AddOnConfig) {
 	addOnConfig := configMap[addOn.MetaData().Name()]
 	if addOnConfig == nil {
@@ -426,7 +788,13 @@ func compareComponentVersions(currentVersion, requiredVersion string, componentN
 }
 
 func addVarDefaultsToContext(addOn addon.AddOn, context *command.ExecutionContext) error {
-	varDefaults, err := addOn.MetaData().VarDefaults()
+	return addMetaVarDefaultsToContext(addOn.MetaData(), context)
+}
+
+// addMetaVarDefaultsToContext adds the variable defaults of the specified meta data to the context, unless the
+// variables are already defined.
+func addMetaVarDefaultsToContext(meta addon.AddOnMeta, context *command.ExecutionContext) error {
+	varDefaults, err := meta.VarDefaults()
 	if err != nil {
 		return err
 	}
@@ -441,12 +809,52 @@ func addVarDefaultsToContext(addOn addon.AddOn, context *command.ExecutionContex
 	return nil
 }
 
-func addonCmdExecution(commands []command.Command, context *command.ExecutionContext) error {
+// addOnChecksum calculates the SHA-256 checksum over the names and the content of all files of the addon installed in
+// addOnDir. The recorded addon source is not part of the checksum.
+func addOnChecksum(addOnDir string) (string, error) {
+	var files []string
+	err := filepath.Walk(addOnDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() && info.Name() != AddOnSourceFile {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "Unable to calculate checksum of addon in '%s'", addOnDir)
+	}
+	sort.Strings(files)
+
+	hash := sha256.New()
+	for _, file := range files {
+		relPath, err := filepath.Rel(addOnDir, file)
+		if err != nil {
+			return "", err
+		}
+		io.WriteString(hash, filepath.ToSlash(relPath)+"\x00")
+
+		f, err := os.Open(file)
+		if err != nil {
+			return "", errors.Wrapf(err, "Unable to calculate checksum of addon in '%s'", addOnDir)
+		}
+		_, err = io.Copy(hash, f)
+		f.Close()
+		if err != nil {
+			return "", errors.Wrapf(err, "Unable to calculate checksum of addon in '%s'", addOnDir)
+		}
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// addonCmdExecution executes the specified commands. In case of an error, the failed command is returned as well.
+func addonCmdExecution(commands []command.Command, context *command.ExecutionContext) (command.Command, error) {
 	for _, c := range commands {
 		if err := c.Execute(context); err != nil {
-			return err
+			return c, err
 		}
 	}
 
-	return nil
+	return nil, nil
 }
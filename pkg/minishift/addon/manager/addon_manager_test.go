@@ -109,6 +109,46 @@ func Test_invalid_addons_get_skipped(t *testing.T) {
 	assert.Len(t, addOns, expectedNumberOfAddOns)
 }
 
+func TestUpdateAddon(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-manager-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnsDir := filepath.Join(testDir, "addons")
+	sourceDir := filepath.Join(testDir, "source", "anyuid")
+	assert.NoError(t, os.Mkdir(addOnsDir, 0777))
+	assert.NoError(t, os.MkdirAll(sourceDir, 0777))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(sourceDir, "anyuid.addon"), []byte(anyuid), 0644))
+
+	manager, err := NewAddOnManager(addOnsDir, make(map[string]*config.AddOnConfig))
+	assert.NoError(t, err, "Error creating addon manager")
+	_, err = manager.Install(sourceDir, false)
+	assert.NoError(t, err, "Error installing addon")
+
+	manager, err = NewAddOnManager(addOnsDir, make(map[string]*config.AddOnConfig))
+	assert.NoError(t, err, "Error creating addon manager")
+
+	updatedAnyuid := "# Name: anyuid\n# Description: anyuid\n# OpenShift-Version: >=3.7.0\n# Required-Vars: FOO\n\noc foo\n"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(sourceDir, "anyuid.addon"), []byte(updatedAnyuid), 0644))
+
+	changes, err := manager.Update("anyuid")
+	assert.NoError(t, err, "Error updating addon")
+	expectedChanges := []MetaDataChange{
+		{"OpenShift-Version", "", ">=3.7.0"},
+		{"Required-Vars", "", "FOO"},
+	}
+	assert.Equal(t, expectedChanges, changes)
+
+	// an unparsable update leaves the installed version in place
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(sourceDir, "anyuid.addon"), []byte("# Name: anyuid\nsnafu\n"), 0644))
+	_, err = manager.Update("anyuid")
+	assert.Error(t, err, "Update with invalid addon should fail")
+
+	manager, err = NewAddOnManager(addOnsDir, make(map[string]*config.AddOnConfig))
+	assert.NoError(t, err, "Error creating addon manager")
+	assert.Equal(t, ">=3.7.0", manager.Get("anyuid").MetaData().OpenShiftVersion())
+}
+
 func TestAddVarDefaultsToContext(t *testing.T) {
 	context, _ := command.NewExecutionContext(nil, nil)
 	expectedVarName := "FOO"
@@ -188,6 +228,297 @@ This testaddon is having variable TEST with foo value
 	assert.Equal(t, expectedApplyAddonOutput, tee.StdoutBuffer.String())
 }
 
+func TestApplyAddonDryRun(t *testing.T) {
+	path := filepath.Join(basepath, "..", "..", "..", "..", "test", "testdata", "testaddons")
+	manager, err := NewAddOnManager(path, make(map[string]*config.AddOnConfig))
+	assert.NoError(t, err, "Unexpected error creating manager in directory '%s'", path)
+
+	testaddon := manager.Get("testaddon")
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	context.SetDryRun(true)
+
+	tee := cli.CreateTee(t, false)
+	err = manager.ApplyAddOn(testaddon, context)
+	tee.Close()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "-- Dry run of applying addon 'testaddon':\n", tee.StdoutBuffer.String())
+	expectedPlan := []command.PlannedCommand{
+		{Type: "echo", Command: "echo This testaddon is having variable TEST with foo value"},
+	}
+	assert.Equal(t, expectedPlan, context.Plan())
+}
+
+func TestApplyAddonAtomic(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-atomic-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnDir := filepath.Join(testDir, "failing")
+	assert.NoError(t, os.Mkdir(addOnDir, 0777))
+	addOnContent := `# Name: failing
+# Description: Addon failing on its second command
+
+echo applying
+cat missing.txt
+echo not reached
+`
+	removeContent := `# Name: failing
+# Description: Removes the failing addon
+
+echo rolling back
+`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(addOnDir, "failing.addon"), []byte(addOnContent), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(addOnDir, "failing.addon.remove"), []byte(removeContent), 0644))
+
+	addOnConfigs := make(map[string]*config.AddOnConfig)
+	manager, err := NewAddOnManager(testDir, addOnConfigs)
+	assert.NoError(t, err, "Unexpected error creating manager in directory '%s'", testDir)
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+
+	tee := cli.CreateTee(t, false)
+	err = manager.ApplyAddOnAtomic(manager.Get("failing"), context)
+	tee.Close()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Command 'cat missing.txt' failed")
+	assert.Contains(t, tee.StdoutBuffer.String(), "-- Rolling back addon 'failing':\nrolling back")
+	assert.NotContains(t, tee.StdoutBuffer.String(), "not reached")
+	assert.Empty(t, addOnConfigs, "Addon state should be unchanged")
+}
+
+func TestApplyAddonAtomicRollbackUsesRemoveVarDefaults(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-atomic-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnContent := `# Name: failing
+# Description: Addon failing on its second command
+
+echo applying
+cat missing.txt
+`
+	removeContent := `# Name: failing
+# Description: Removes the failing addon
+# Var-Defaults: project=myproject
+
+echo rolling back #{project}
+`
+	manager, addOnConfigs := createAtomicTestAddOn(t, testDir, addOnContent, removeContent)
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	tee := cli.CreateTee(t, false)
+	err = manager.ApplyAddOnAtomic(manager.Get("failing"), context)
+	tee.Close()
+
+	assert.Error(t, err)
+	assert.Contains(t, tee.StdoutBuffer.String(), "rolling back myproject")
+	assert.Empty(t, addOnConfigs, "Addon state should be unchanged")
+}
+
+func TestApplyAddonAtomicRollbackVerifiesRemoveRequiredVars(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-atomic-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnContent := `# Name: failing
+# Description: Addon failing on its second command
+
+echo applying
+cat missing.txt
+`
+	removeContent := `# Name: failing
+# Description: Removes the failing addon
+# Required-Vars: project
+
+echo rolling back #{project}
+`
+	manager, _ := createAtomicTestAddOn(t, testDir, addOnContent, removeContent)
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	tee := cli.CreateTee(t, false)
+	err = manager.ApplyAddOnAtomic(manager.Get("failing"), context)
+	tee.Close()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Unable to roll back addon 'failing'")
+	assert.Contains(t, err.Error(), "'project'")
+	assert.NotContains(t, tee.StdoutBuffer.String(), "rolling back")
+}
+
+func TestApplyAddonAtomicRollbackRunsInAddOnDirectory(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-atomic-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnContent := `# Name: failing
+# Description: Addon failing on its second command
+
+echo applying
+cat missing.txt
+`
+	removeContent := `# Name: failing
+# Description: Removes the failing addon
+
+cat rollback.txt
+`
+	manager, _ := createAtomicTestAddOn(t, testDir, addOnContent, removeContent)
+	rollbackFile := filepath.Join(testDir, "failing", "rollback.txt")
+	assert.NoError(t, ioutil.WriteFile(rollbackFile, []byte("rolled back relative to the addon"), 0644))
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	tee := cli.CreateTee(t, false)
+	err = manager.ApplyAddOnAtomic(manager.Get("failing"), context)
+	tee.Close()
+
+	assert.Error(t, err)
+	assert.Contains(t, tee.StdoutBuffer.String(), "rolled back relative to the addon")
+}
+
+func TestApplyAddonAtomicFailedVerificationKeepsHistory(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-atomic-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnContent := `# Name: failing
+# Description: Addon requiring a variable
+# Required-Vars: project
+
+echo applying #{project}
+`
+	removeContent := `# Name: failing
+# Description: Removes the failing addon
+
+echo rolling back
+`
+	manager, addOnConfigs := createAtomicTestAddOn(t, testDir, addOnContent, removeContent)
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	tee := cli.CreateTee(t, false)
+	err = manager.ApplyAddOnAtomic(manager.Get("failing"), context)
+	tee.Close()
+
+	assert.Error(t, err)
+	assert.NotContains(t, tee.StdoutBuffer.String(), "rolling back")
+	assert.Empty(t, addOnConfigs, "Addon state should be unchanged")
+}
+
+func createAtomicTestAddOn(t *testing.T, testDir string, addOnContent string, removeContent string) (*AddOnManager, map[string]*config.AddOnConfig) {
+	addOnDir := filepath.Join(testDir, "failing")
+	assert.NoError(t, os.Mkdir(addOnDir, 0777))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(addOnDir, "failing.addon"), []byte(addOnContent), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(addOnDir, "failing.addon.remove"), []byte(removeContent), 0644))
+
+	addOnConfigs := make(map[string]*config.AddOnConfig)
+	manager, err := NewAddOnManager(testDir, addOnConfigs)
+	assert.NoError(t, err, "Unexpected error creating manager in directory '%s'", testDir)
+	return manager, addOnConfigs
+}
+
+func TestApplyAddonRecordsHistory(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-history-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnDir := filepath.Join(testDir, "history")
+	assert.NoError(t, os.Mkdir(addOnDir, 0777))
+	addOnFile := filepath.Join(addOnDir, "history.addon")
+	addOnContent := `# Name: history
+# Description: Addon echoing a variable
+# Required-Vars: greeting
+
+echo #{greeting} #{password}
+`
+	assert.NoError(t, ioutil.WriteFile(addOnFile, []byte(addOnContent), 0644))
+
+	addOnConfigs := make(map[string]*config.AddOnConfig)
+	manager, err := NewAddOnManager(testDir, addOnConfigs)
+	assert.NoError(t, err, "Unexpected error creating manager in directory '%s'", testDir)
+
+	addOn := manager.Get("history")
+	status, lastApply := manager.ApplyStatus(addOn)
+	assert.Equal(t, NotAppliedStatus, status)
+	assert.Nil(t, lastApply)
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	context.AddToContext("greeting", "hello")
+	context.AddToContext("password", "s3cret")
+	context.MarkSecret("password")
+
+	tee := cli.CreateTee(t, false)
+	err = manager.ApplyAddOn(addOn, context)
+	tee.Close()
+	assert.NoError(t, err)
+
+	status, lastApply = manager.ApplyStatus(addOn)
+	assert.Equal(t, AppliedStatus, status)
+	assert.True(t, lastApply.Success)
+	assert.NotEmpty(t, lastApply.Checksum)
+	assert.Equal(t, "hello", lastApply.Variables["greeting"])
+	assert.Equal(t, "*****", lastApply.Variables["password"])
+	assert.Len(t, addOnConfigs["history"].History, 1)
+
+	// changing the addon content makes the applied addon stale
+	assert.NoError(t, ioutil.WriteFile(addOnFile, []byte(addOnContent+"echo changed\n"), 0644))
+	status, _ = manager.ApplyStatus(addOn)
+	assert.Equal(t, StaleStatus, status)
+
+	// enabling the addon keeps its history
+	addOnConfig, err := manager.Enable("history", 1)
+	assert.NoError(t, err)
+	assert.Len(t, addOnConfig.History, 1)
+
+	context.RemoveFromContext("greeting")
+	tee = cli.CreateTee(t, false)
+	err = manager.ApplyAddOn(manager.Get("history"), context)
+	tee.Close()
+	assert.Error(t, err)
+
+	status, lastApply = manager.ApplyStatus(addOn)
+	assert.Equal(t, FailedStatus, status)
+	assert.False(t, lastApply.Success)
+	assert.Contains(t, lastApply.Error, "greeting")
+	assert.Len(t, addOnConfigs["history"].History, 2)
+}
+
+func TestApplyAddonValidatesAndMasksTypedVariables(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-typed-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnDir := filepath.Join(testDir, "typed")
+	assert.NoError(t, os.Mkdir(addOnDir, 0777))
+	addOnContent := `# Name: typed
+# Description: Addon with typed variables
+# Required-Vars: HTTP_PORT, TOKEN
+# Var: HTTP_PORT int The port the proxy listens on
+# Var: TOKEN secret The registry token
+
+echo Using port #{HTTP_PORT} and token #{TOKEN}
+`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(addOnDir, "typed.addon"), []byte(addOnContent), 0644))
+
+	manager, err := NewAddOnManager(testDir, make(map[string]*config.AddOnConfig))
+	assert.NoError(t, err, "Unexpected error creating manager in directory '%s'", testDir)
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	context.AddToContext("HTTP_PORT", "abc")
+	context.AddToContext("TOKEN", "s3cret")
+
+	tee := cli.CreateTee(t, false)
+	err = manager.ApplyAddOn(manager.Get("typed"), context)
+	tee.Close()
+	assert.EqualError(t, err, "Invalid add-on variable(s): The value 'abc' of variable 'HTTP_PORT' is not of type int")
+
+	context.AddToContext("HTTP_PORT", "8080")
+	tee = cli.CreateTee(t, false)
+	err = manager.ApplyAddOn(manager.Get("typed"), context)
+	tee.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "-- Applying addon 'typed':\nUsing port 8080 and token *****\n", tee.StdoutBuffer.String())
+}
+
 func TestRemoveAddon(t *testing.T) {
 	var expectedRemoveAddonOutput = `-- Removing addon 'testaddon':
 Removing testaddon with variable TEST of foo value
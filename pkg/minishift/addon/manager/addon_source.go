@@ -0,0 +1,325 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minishift/minishift/pkg/util/archive"
+	"github.com/minishift/minishift/pkg/util/filehelper"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DirectorySource is the source type of add-ons installed from a local directory
+	DirectorySource = "directory"
+	// GitSource is the source type of add-ons installed from a Git repository
+	GitSource = "git"
+	// ArchiveSource is the source type of add-ons installed from a tar.gz or zip archive
+	ArchiveSource = "archive"
+
+	// AddOnSourceFile is the name of the file within an installed add-on directory which records the add-on source
+	AddOnSourceFile = ".addon-source.json"
+
+	gitPrefix         = "git+"
+	subDirSeparator   = "//"
+	schemeSeparator   = "://"
+	refQueryParameter = "ref"
+)
+
+var archiveSuffixes = []string{".tar.gz", ".tgz", ".zip"}
+
+// downloadClient is used to download add-on archives. The timeout covers the whole download, so that an unresponsive
+// server does not block the installation forever.
+var downloadClient = &http.Client{Timeout: 5 * time.Minute}
+
+// AddOnSource describes where an add-on got installed from. Location is either a local directory, the URL of a Git
+// repository or the URL of an archive. Ref is the optional Git reference (branch, tag or commit) to check out and
+// SubDir the optional path of the add-on within the repository or archive.
+type AddOnSource struct {
+	Type     string
+	Location string
+	Ref      string `json:",omitempty"`
+	SubDir   string `json:",omitempty"`
+}
+
+// ParseAddOnSource parses the specified add-on source. Supported are local directories, Git repositories
+// (git://, git@, ssh://, git+<url> or URLs ending in .git) as well as http(s):// and file:// archives in tar.gz or zip
+// format. Remote sources can specify a sub directory via '//<sub-dir>' and Git sources a reference via '?ref=<ref>',
+// eg https://github.com/minishift/minishift-addons.git//add-ons/che?ref=master.
+func ParseAddOnSource(source string) (*AddOnSource, error) {
+	if !isRemoteSource(source) {
+		absPath, err := filepath.Abs(source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Unable to determine absolute path of '%s'", source)
+		}
+		return &AddOnSource{Type: DirectorySource, Location: absPath}, nil
+	}
+
+	location := strings.TrimPrefix(source, gitPrefix)
+	addOnSource := &AddOnSource{}
+
+	if i := strings.Index(location, "?"); i != -1 {
+		query, err := url.ParseQuery(location[i+1:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "Unable to parse add-on source '%s'", source)
+		}
+		addOnSource.Ref = query.Get(refQueryParameter)
+		location = location[:i]
+	}
+
+	searchOffset := 0
+	if i := strings.Index(location, schemeSeparator); i != -1 {
+		searchOffset = i + len(schemeSeparator)
+	}
+	if i := strings.Index(location[searchOffset:], subDirSeparator); i != -1 {
+		addOnSource.SubDir = strings.Trim(location[searchOffset+i+len(subDirSeparator):], "/")
+		location = location[:searchOffset+i]
+	}
+	addOnSource.Location = location
+
+	switch {
+	case isGitSource(source, location):
+		addOnSource.Type = GitSource
+	case hasArchiveSuffix(location):
+		addOnSource.Type = ArchiveSource
+		if addOnSource.Ref != "" {
+			return nil, errors.New(fmt.Sprintf("A reference can only be specified for Git add-on sources. '%s' is an archive", source))
+		}
+	default:
+		return nil, errors.New(fmt.Sprintf("Unsupported add-on source '%s'. Specify a directory, a Git repository or a tar.gz/zip archive", source))
+	}
+
+	return addOnSource, nil
+}
+
+// ReadAddOnSource reads the source recorded at installation time for the add-on installed in addOnDir.
+func ReadAddOnSource(addOnDir string) (*AddOnSource, error) {
+	data, err := ioutil.ReadFile(filepath.Join(addOnDir, AddOnSourceFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New(fmt.Sprintf("No add-on source recorded in '%s'", addOnDir))
+		}
+		return nil, errors.Wrapf(err, "Unable to read add-on source in '%s'", addOnDir)
+	}
+
+	addOnSource := &AddOnSource{}
+	if err := json.Unmarshal(data, addOnSource); err != nil {
+		return nil, errors.Wrapf(err, "Unable to parse add-on source in '%s'", addOnDir)
+	}
+	return addOnSource, nil
+}
+
+// Write records this source in the specified add-on directory.
+func (s *AddOnSource) Write(addOnDir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Unable to serialize add-on source")
+	}
+	return ioutil.WriteFile(filepath.Join(addOnDir, AddOnSourceFile), data, 0644)
+}
+
+// Fetch retrieves the add-on from this source, using workDir for any downloaded or extracted content.
+// It returns the directory containing the add-on.
+func (s *AddOnSource) Fetch(workDir string) (string, error) {
+	var (
+		root string
+		err  error
+	)
+
+	switch s.Type {
+	case DirectorySource:
+		if !filehelper.IsDirectory(s.Location) {
+			return "", errors.New(fmt.Sprintf("The source of a addon needs to be a directory. '%s' is not", s.Location))
+		}
+		return s.Location, nil
+	case GitSource:
+		root, err = s.fetchGit(workDir)
+	case ArchiveSource:
+		root, err = s.fetchArchive(workDir)
+	default:
+		return "", errors.New(fmt.Sprintf("Unknown add-on source type '%s'", s.Type))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	addOnDir := filepath.Join(root, filepath.FromSlash(s.SubDir))
+	if !filehelper.IsDirectory(addOnDir) {
+		return "", errors.New(fmt.Sprintf("The directory '%s' does not exist in add-on source '%s'", s.SubDir, s.Location))
+	}
+	return addOnDir, nil
+}
+
+func (s *AddOnSource) String() string {
+	source := s.Location
+	if s.Type == GitSource && !isGitSource(s.Location, s.Location) {
+		source = gitPrefix + source
+	}
+	if s.SubDir != "" {
+		source = source + subDirSeparator + s.SubDir
+	}
+	if s.Ref != "" {
+		source = fmt.Sprintf("%s?%s=%s", source, refQueryParameter, s.Ref)
+	}
+	return source
+}
+
+func (s *AddOnSource) fetchGit(workDir string) (string, error) {
+	repoName := strings.TrimSuffix(lastPathElement(s.Location), ".git")
+	cloneDir := filepath.Join(workDir, repoName)
+
+	fmt.Println(fmt.Sprintf("-- Cloning add-on repository '%s'", s.Location))
+	if out, err := exec.Command("git", "clone", "--quiet", "--", s.Location, cloneDir).CombinedOutput(); err != nil {
+		return "", errors.New(fmt.Sprintf("Unable to clone add-on repository '%s': %s", s.Location, strings.TrimSpace(string(out))))
+	}
+
+	if s.Ref != "" {
+		if strings.HasPrefix(s.Ref, "-") {
+			return "", errors.New(fmt.Sprintf("Invalid reference '%s' of add-on repository '%s'", s.Ref, s.Location))
+		}
+		if out, err := exec.Command("git", "-C", cloneDir, "checkout", "--quiet", s.Ref).CombinedOutput(); err != nil {
+			return "", errors.New(fmt.Sprintf("Unable to check out '%s' of add-on repository '%s': %s", s.Ref, s.Location, strings.TrimSpace(string(out))))
+		}
+	}
+
+	// the repository metadata is not part of the add-on
+	if err := os.RemoveAll(filepath.Join(cloneDir, ".git")); err != nil {
+		return "", errors.Wrapf(err, "Unable to clean up clone of add-on repository '%s'", s.Location)
+	}
+
+	return cloneDir, nil
+}
+
+func (s *AddOnSource) fetchArchive(workDir string) (string, error) {
+	archiveName := lastPathElement(s.Location)
+	archiveFile := filepath.Join(workDir, archiveName)
+
+	if err := download(s.Location, archiveFile); err != nil {
+		return "", err
+	}
+
+	extractDir := filepath.Join(workDir, trimArchiveSuffix(archiveName))
+	switch {
+	case strings.HasSuffix(archiveName, ".zip"):
+		if err := archive.Unzip(archiveFile, extractDir); err != nil {
+			return "", errors.Wrapf(err, "Unable to unzip add-on archive '%s'", s.Location)
+		}
+	default:
+		tarFile := filepath.Join(workDir, trimArchiveSuffix(archiveName)+".tar")
+		if err := archive.Ungzip(archiveFile, tarFile); err != nil {
+			return "", errors.Wrapf(err, "Unable to ungzip add-on archive '%s'", s.Location)
+		}
+		if err := archive.Untar(tarFile, extractDir); err != nil {
+			return "", errors.Wrapf(err, "Unable to untar add-on archive '%s'", s.Location)
+		}
+	}
+
+	// archives commonly wrap their content into a single top level directory
+	files, err := ioutil.ReadDir(extractDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "Unable to read content of add-on archive '%s'", s.Location)
+	}
+	if len(files) == 1 && files[0].IsDir() {
+		return filepath.Join(extractDir, files[0].Name()), nil
+	}
+	return extractDir, nil
+}
+
+func download(location string, target string) error {
+	u, err := url.Parse(location)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid add-on archive URL '%s'", location)
+	}
+
+	var reader io.ReadCloser
+	if u.Scheme == "file" {
+		reader, err = os.Open(filepath.FromSlash(u.Path))
+		if err != nil {
+			return errors.Wrapf(err, "Unable to open add-on archive '%s'", location)
+		}
+	} else {
+		fmt.Println(fmt.Sprintf("-- Downloading add-on archive '%s'", location))
+		resp, err := downloadClient.Get(location)
+		if err != nil {
+			return errors.Wrapf(err, "Unable to download add-on archive '%s'", location)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return errors.New(fmt.Sprintf("Unable to download add-on archive '%s': %s", location, resp.Status))
+		}
+		reader = resp.Body
+	}
+	defer reader.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return errors.Wrapf(err, "Unable to create '%s'", target)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, reader); err != nil {
+		return errors.Wrapf(err, "Unable to retrieve add-on archive '%s'", location)
+	}
+	return nil
+}
+
+func isRemoteSource(source string) bool {
+	return strings.Contains(source, schemeSeparator) || strings.HasPrefix(source, gitPrefix) || strings.HasPrefix(source, "git@")
+}
+
+func isGitSource(source string, location string) bool {
+	return strings.HasPrefix(source, gitPrefix) ||
+		strings.HasPrefix(location, "git://") ||
+		strings.HasPrefix(location, "git@") ||
+		strings.HasPrefix(location, "ssh://") ||
+		strings.HasSuffix(location, ".git")
+}
+
+func hasArchiveSuffix(location string) bool {
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(location, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func trimArchiveSuffix(name string) string {
+	for _, suffix := range archiveSuffixes {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return name
+}
+
+func lastPathElement(location string) string {
+	location = strings.TrimRight(location, "/")
+	if i := strings.LastIndexAny(location, "/:"); i != -1 {
+		return location[i+1:]
+	}
+	return location
+}
@@ -0,0 +1,109 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minishift/minishift/pkg/minishift/addon/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddOnSource(t *testing.T) {
+	var testCases = []struct {
+		source   string
+		expected AddOnSource
+	}{
+		{"https://github.com/minishift/minishift-addons.git",
+			AddOnSource{Type: GitSource, Location: "https://github.com/minishift/minishift-addons.git"}},
+		{"https://github.com/minishift/minishift-addons.git//add-ons/che?ref=v1.0",
+			AddOnSource{Type: GitSource, Location: "https://github.com/minishift/minishift-addons.git", Ref: "v1.0", SubDir: "add-ons/che"}},
+		{"git+https://git.example.com/addons?ref=master",
+			AddOnSource{Type: GitSource, Location: "https://git.example.com/addons", Ref: "master"}},
+		{"git@github.com:minishift/minishift-addons.git//add-ons/che",
+			AddOnSource{Type: GitSource, Location: "git@github.com:minishift/minishift-addons.git", SubDir: "add-ons/che"}},
+		{"https://artifacts.example.com/anyuid.tar.gz",
+			AddOnSource{Type: ArchiveSource, Location: "https://artifacts.example.com/anyuid.tar.gz"}},
+		{"file:///tmp/addons.zip//anyuid",
+			AddOnSource{Type: ArchiveSource, Location: "file:///tmp/addons.zip", SubDir: "anyuid"}},
+	}
+
+	for _, testCase := range testCases {
+		addOnSource, err := ParseAddOnSource(testCase.source)
+		assert.NoError(t, err, "Unexpected error parsing '%s'", testCase.source)
+		assert.Equal(t, testCase.expected, *addOnSource)
+		assert.Equal(t, testCase.source, addOnSource.String())
+	}
+}
+
+func TestParseInvalidAddOnSource(t *testing.T) {
+	_, err := ParseAddOnSource("https://artifacts.example.com/anyuid.rpm")
+	assert.Error(t, err)
+
+	_, err = ParseAddOnSource("https://artifacts.example.com/anyuid.tar.gz?ref=master")
+	assert.Error(t, err)
+}
+
+func TestInstallFromArchive(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-source-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnsDir := filepath.Join(testDir, "addons")
+	assert.NoError(t, os.Mkdir(addOnsDir, 0777))
+
+	archiveFile := filepath.Join(testDir, "anyuid-1.0.tar.gz")
+	createTarGz(t, archiveFile, map[string]string{"anyuid-1.0/anyuid/anyuid.addon": anyuid})
+
+	manager, err := NewAddOnManager(addOnsDir, make(map[string]*config.AddOnConfig))
+	assert.NoError(t, err, "Error creating addon manager")
+
+	source := "file://" + filepath.ToSlash(archiveFile) + "//anyuid"
+	name, err := manager.Install(source, false)
+	assert.NoError(t, err, "Error installing addon from archive")
+	assert.Equal(t, "anyuid", name)
+
+	addOnSource, err := ReadAddOnSource(filepath.Join(addOnsDir, "anyuid"))
+	assert.NoError(t, err, "Error reading addon source")
+	assert.Equal(t, source, addOnSource.String())
+
+	_, err = manager.Install(source, false)
+	assert.Error(t, err, "Installing an existing addon without force should fail")
+}
+
+func createTarGz(t *testing.T, archiveFile string, content map[string]string) {
+	f, err := os.Create(archiveFile)
+	assert.NoError(t, err, "Error creating archive")
+	defer f.Close()
+
+	gzipWriter := gzip.NewWriter(f)
+	defer gzipWriter.Close()
+	tarWriter := tar.NewWriter(gzipWriter)
+	defer tarWriter.Close()
+
+	for name, data := range content {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}
+		assert.NoError(t, tarWriter.WriteHeader(header))
+		_, err := tarWriter.Write([]byte(data))
+		assert.NoError(t, err)
+	}
+}
@@ -36,6 +36,10 @@ const (
 	ignoreErrorChar = "!"
 	evaluationChar  = ":="
 
+	ifKeyword   = "if"
+	elseKeyword = "else"
+	endKeyword  = "end"
+
 	noAddOnDefinitionFoundError         = "There needs to be an addon file per addon directory. Found none in '%s'"
 	multipleAddOnDefinitionsError       = "There can only be one addon file per addon directory. Found '%s'"
 	multipleAddOnRemoveDefinitionsError = "There can only be one addon.remove file per addon directory. Found '%s'"
@@ -71,6 +75,15 @@ func NewAddOnParser() *AddOnParser {
 	catHandler := &CatCommandHandler{&defaultCommandHandler{}}
 	echoHandler.SetNext(catHandler)
 
+	copyHandler := &CopyCommandHandler{&defaultCommandHandler{}}
+	catHandler.SetNext(copyHandler)
+
+	hostHandler := &HostCommandHandler{&defaultCommandHandler{}}
+	copyHandler.SetNext(hostHandler)
+
+	waitForHandler := &WaitForCommandHandler{&defaultCommandHandler{}, ocHandler}
+	hostHandler.SetNext(waitForHandler)
+
 	parser.handler = ocHandler
 
 	return &parser
@@ -83,7 +96,7 @@ func (parser *AddOnParser) getAddOnContent(addOnDir, fileSuffix string) (addon.A
 		err      error
 	)
 
-	addonReader, err := parser.getAddOnContentReader(addOnDir, fileSuffix)
+	addonReader, addonFile, err := parser.getAddOnContentReader(addOnDir, fileSuffix)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -94,10 +107,17 @@ func (parser *AddOnParser) getAddOnContent(addOnDir, fileSuffix string) (addon.A
 			name = meta.Name()
 		}
 		if err != nil {
-			return nil, nil, NewParseError(err.Error(), name, addOnDir)
+			parseError := NewParseError(err.Error(), name, addOnDir)
+			parseError.file = addonFile
+			if lineError, ok := err.(ParseError); ok {
+				parseError.line = lineError.Line()
+			}
+			return nil, nil, parseError
 		}
 		if filepath.Base(addOnDir) != name {
-			return nil, nil, NewParseError(fmt.Sprintf("Add-on directory name should match to addon name"), "", addOnDir)
+			parseError := NewParseError(fmt.Sprintf("Add-on directory name should match to addon name"), "", addOnDir)
+			parseError.file = addonFile
+			return nil, nil, parseError
 		}
 	}
 
@@ -119,55 +139,66 @@ func (parser *AddOnParser) Parse(addOnDir string) (addon.AddOn, error) {
 	return addon.NewAddOn(meta, removeMeta, commands, removeCommands, addOnDir), nil
 }
 
-func (parser *AddOnParser) getAddOnContentReader(addOnDir string, fileSuffix string) (io.Reader, error) {
+// getAddOnContentReader returns a reader for the addon file with the specified suffix as well as the path of the file.
+func (parser *AddOnParser) getAddOnContentReader(addOnDir string, fileSuffix string) (io.Reader, string, error) {
 	if !filehelper.Exists(addOnDir) {
-		return nil, NewParseError("Addon directory does not exist", addOnDir, "")
+		return nil, "", NewParseError("Addon directory does not exist", addOnDir, "")
 	}
 
-	files, err := ioutil.ReadDir(addOnDir)
+	addOnFiles, err := findAddOnFiles(addOnDir, fileSuffix)
 	if err != nil {
-		return nil, NewParseError(fmt.Sprintf("Unexpected error reading addon content in '%s'", addOnDir), addOnDir, "")
-	}
-	var addOnFiles []string
-	for _, fileInfo := range files {
-		if strings.HasSuffix(fileInfo.Name(), fileSuffix) {
-			addOnFiles = append(addOnFiles, fileInfo.Name())
-		}
+		return nil, "", NewParseError(fmt.Sprintf("Unexpected error reading addon content in '%s'", addOnDir), addOnDir, "")
 	}
 
 	if fileSuffix == ".addon.remove" {
 		if len(addOnFiles) == 0 {
-			return nil, nil
+			return nil, "", nil
 		}
 	}
 
 	if len(addOnFiles) == 0 {
-		return nil, NewParseError(fmt.Sprintf(noAddOnDefinitionFoundError, addOnDir), addOnDir, "")
+		return nil, "", NewParseError(fmt.Sprintf(noAddOnDefinitionFoundError, addOnDir), addOnDir, "")
 	}
 
 	if len(addOnFiles) > 1 {
 		if fileSuffix == ".addon" {
-			return nil, NewParseError(fmt.Sprintf(multipleAddOnDefinitionsError, strings.Join(addOnFiles, ", ")), addOnDir, "")
+			return nil, "", NewParseError(fmt.Sprintf(multipleAddOnDefinitionsError, strings.Join(addOnFiles, ", ")), addOnDir, "")
 		}
-		return nil, NewParseError(fmt.Sprintf(multipleAddOnRemoveDefinitionsError, strings.Join(addOnFiles, ", ")), addOnDir, "")
+		return nil, "", NewParseError(fmt.Sprintf(multipleAddOnRemoveDefinitionsError, strings.Join(addOnFiles, ", ")), addOnDir, "")
 	}
 
-	file, err := ioutil.ReadFile(filepath.Join(addOnDir, addOnFiles[0]))
+	addOnFile := filepath.Join(addOnDir, addOnFiles[0])
+	file, err := ioutil.ReadFile(addOnFile)
 	if err != nil {
-		return nil, NewParseError(fmt.Sprintf("Unable to open addon definition '%s'", addOnFiles[0]), addOnDir, "")
+		return nil, "", NewParseError(fmt.Sprintf("Unable to open addon definition '%s'", addOnFiles[0]), addOnDir, "")
 	}
 	reader := strings.NewReader(string(file))
-	return bufio.NewReader(reader), nil
+	return bufio.NewReader(reader), addOnFile, nil
+}
+
+// findAddOnFiles returns the names of the files in addOnDir ending with the specified suffix.
+func findAddOnFiles(addOnDir string, fileSuffix string) ([]string, error) {
+	files, err := ioutil.ReadDir(addOnDir)
+	if err != nil {
+		return nil, err
+	}
+	var addOnFiles []string
+	for _, fileInfo := range files {
+		if strings.HasSuffix(fileInfo.Name(), fileSuffix) {
+			addOnFiles = append(addOnFiles, fileInfo.Name())
+		}
+	}
+	return addOnFiles, nil
 }
 
 func (parser *AddOnParser) parseAddOnContent(reader io.Reader) (addon.AddOnMeta, []command.Command, error) {
 	scanner := bufio.NewScanner(reader)
-	meta, err := parser.parseHeader(scanner)
+	meta, headerLines, err := parser.parseHeader(scanner)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	commands, err := parser.parseCommands(scanner)
+	commands, err := parser.parseCommands(scanner, headerLines)
 	if err != nil {
 		return meta, nil, err
 	}
@@ -175,10 +206,13 @@ func (parser *AddOnParser) parseAddOnContent(reader io.Reader) (addon.AddOnMeta,
 	return meta, commands, nil
 }
 
-func (parser *AddOnParser) parseHeader(scanner *bufio.Scanner) (addon.AddOnMeta, error) {
+// parseHeader parses the addon meta data. Besides the meta data, the number of lines consumed from scanner is returned.
+func (parser *AddOnParser) parseHeader(scanner *bufio.Scanner) (addon.AddOnMeta, int, error) {
 	var header []string
 	var line string
+	linesRead := 0
 	for scanner.Scan() {
+		linesRead++
 		line = scanner.Text()
 		if !strings.HasPrefix(line, commentChar) {
 			break
@@ -188,15 +222,69 @@ func (parser *AddOnParser) parseHeader(scanner *bufio.Scanner) (addon.AddOnMeta,
 
 	headerMeta, err := createMetaData(header)
 	if err != nil {
-		return nil, err
+		return nil, linesRead, err
+	}
+
+	return headerMeta, linesRead, nil
+}
+
+// conditionalBlock keeps track of an 'if' block while its commands get parsed.
+type conditionalBlock struct {
+	line         int
+	rawCommand   string
+	condition    *command.Condition
+	commands     []command.Command
+	elseCommands []command.Command
+	inElse       bool
+}
+
+func (b *conditionalBlock) add(c command.Command) {
+	if b.inElse {
+		b.elseCommands = append(b.elseCommands, c)
+	} else {
+		b.commands = append(b.commands, c)
+	}
+}
+
+// parseCommands parses the addon commands. lineOffset is the number of lines consumed by the header and is used to
+// report the line number of parse errors.
+func (parser *AddOnParser) parseCommands(scanner *bufio.Scanner, lineOffset int) ([]command.Command, error) {
+	parsed := parser.parseCommandLines(scanner, lineOffset)
+	if len(parsed.errors) > 0 {
+		return nil, parsed.errors[0]
 	}
+	return parsed.commands, nil
+}
 
-	return headerMeta, nil
+// parsedCommands are the commands of an addon file together with the lines they were parsed from.
+type parsedCommands struct {
+	commands []command.Command
+	lines    map[command.Command]int
+	// errors are the parse errors in the order they were found
+	errors []error
 }
 
-func (parser *AddOnParser) parseCommands(scanner *bufio.Scanner) ([]command.Command, error) {
-	var commands []command.Command
+// parseCommandLines parses the addon commands like parseCommands. Lines which cannot be parsed are skipped, so that
+// the errors of all lines are returned.
+func (parser *AddOnParser) parseCommandLines(scanner *bufio.Scanner, lineOffset int) *parsedCommands {
+	parsed := &parsedCommands{lines: make(map[command.Command]int)}
+	var blocks []*conditionalBlock
+	lineNumber := lineOffset
+
+	addCommand := func(c command.Command, line int) {
+		parsed.lines[c] = line
+		if len(blocks) > 0 {
+			blocks[len(blocks)-1].add(c)
+		} else {
+			parsed.commands = append(parsed.commands, c)
+		}
+	}
+	addError := func(err error) {
+		parsed.errors = append(parsed.errors, err)
+	}
+
 	for scanner.Scan() {
+		lineNumber++
 		var outputVariable string
 		ignoreError := false
 		line := scanner.Text()
@@ -206,26 +294,67 @@ func (parser *AddOnParser) parseCommands(scanner *bufio.Scanner) ([]command.Comm
 		if len(line) == 0 || strings.HasPrefix(line, commentChar) {
 			continue
 		}
+
+		switch {
+		case strings.HasPrefix(line, ifKeyword+" "):
+			condition, err := command.NewCondition(strings.TrimPrefix(line, ifKeyword+" "))
+			if err != nil {
+				addError(newLineParseError(lineNumber, "Invalid condition in '%s': %s", line, err.Error()))
+			}
+			// the block is tracked despite an invalid condition, so that its 'else' and 'end' match
+			blocks = append(blocks, &conditionalBlock{line: lineNumber, rawCommand: line, condition: condition})
+			continue
+		case line == elseKeyword:
+			if len(blocks) == 0 {
+				addError(newLineParseError(lineNumber, "'%s' without matching '%s'", elseKeyword, ifKeyword))
+				continue
+			}
+			block := blocks[len(blocks)-1]
+			if block.inElse {
+				addError(newLineParseError(lineNumber, "Duplicate '%s' for '%s' in line %d", elseKeyword, ifKeyword, block.line))
+			}
+			block.inElse = true
+			continue
+		case line == endKeyword:
+			if len(blocks) == 0 {
+				addError(newLineParseError(lineNumber, "'%s' without matching '%s'", endKeyword, ifKeyword))
+				continue
+			}
+			block := blocks[len(blocks)-1]
+			blocks = blocks[:len(blocks)-1]
+			if block.condition != nil {
+				addCommand(command.NewConditionalCommand(block.rawCommand, block.condition, block.commands, block.elseCommands), block.line)
+			}
+			continue
+		}
+
 		if strings.HasPrefix(line, ignoreErrorChar) {
 			ignoreError = true
 			line = strings.TrimPrefix(line, ignoreErrorChar)
 		}
+
 		if strings.Contains(line, evaluationChar) {
 			cmdToken, err := minishiftStrings.SplitAndTrim(line, evaluationChar)
 			if err != nil {
-				return nil, err
+				addError(err)
+				continue
 			}
 			outputVariable, line = cmdToken[0], cmdToken[1]
 		}
+
 		newCommand, err := parser.handler.Handle(parser.handler, line, ignoreError, outputVariable)
 		if err != nil {
-			return nil, err
+			addError(newLineParseError(lineNumber, "%s", err.Error()))
+			continue
 		}
+		addCommand(newCommand, lineNumber)
+	}
 
-		commands = append(commands, newCommand)
+	for i := len(blocks) - 1; i >= 0; i-- {
+		addError(newLineParseError(blocks[i].line, "Missing '%s' for '%s'", endKeyword, blocks[i].rawCommand))
 	}
 
-	return commands, nil
+	return parsed
 }
 
 func createMetaData(header []string) (addon.AddOnMeta, error) {
@@ -247,6 +376,12 @@ func createMetaData(header []string) (addon.AddOnMeta, error) {
 				metaMap[key] = value
 				continue
 			}
+			// a variable is declared per 'Var' entry
+			if key == addon.VarMetaTagName {
+				vars, _ := metaMap[key].([]string)
+				metaMap[key] = append(vars, strings.Trim(match[2], " "))
+				continue
+			}
 			metaMap[key] = strings.Trim(match[2], " ")
 		}
 	}
@@ -24,6 +24,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/minishift/minishift/pkg/minishift/addon/command"
 	"github.com/stretchr/testify/assert"
@@ -97,6 +98,51 @@ oc foo
 snafu
 `
 
+var addOnWithConditionals string = `# Name: test
+# Description: test
+
+oc foo
+if defined PROXY
+  oc set env dc/foo HTTP_PROXY=#{PROXY}
+  if #{version} =~ ^v3\.11
+    oc bar
+  end
+else
+  echo No proxy configured
+end
+`
+
+var addOnWithUnbalancedElse string = `# Name: test
+# Description: test
+
+oc foo
+else
+`
+
+var addOnWithMissingEnd string = `# Name: test
+# Description: test
+
+oc foo
+if defined PROXY
+oc bar
+`
+
+var addOnWithInvalidCondition string = `# Name: test
+# Description: test
+
+if #{PROXY}
+oc bar
+end
+`
+
+var addOnWithWaitFor string = `# Name: test
+# Description: test
+
+oc rollout latest dc/foo
+wait-for timeout=120 interval=2 oc rollout status dc/foo
+phase := wait-for match=Running oc get pod foo -o jsonpath={.status.phase}
+`
+
 var testParser = NewAddOnParser()
 
 var (
@@ -174,7 +220,7 @@ func Test_addon_with_unknown_command_returns_error(t *testing.T) {
 	_, _, err := testParser.parseAddOnContent(strings.NewReader(addOnWithUnknownCommand))
 	assert.Error(t, err, "Error in parsing addon content")
 
-	expectedError := "Unable to process command: 'snafu'"
+	expectedError := "Line 8: Unable to process command: 'snafu'"
 	assert.EqualError(t, err, expectedError)
 }
 
@@ -280,3 +326,114 @@ func TestInvalidAddonDirAndName(t *testing.T) {
 	_, _, err := testParser.getAddOnContent(testAddonDir, ".addon")
 	assert.EqualError(t, err, "Add-on directory name should match to addon name")
 }
+
+func Test_conditional_blocks_are_parsed(t *testing.T) {
+	_, commands, err := testParser.parseAddOnContent(strings.NewReader(addOnWithConditionals))
+	assert.NoError(t, err, "Error in parsing addon content")
+	assert.Len(t, commands, 2)
+
+	conditional, ok := commands[1].(*command.ConditionalCommand)
+	assert.True(t, ok)
+	assert.Equal(t, "defined PROXY", conditional.Condition().String())
+	assert.Len(t, conditional.Commands(), 2)
+	assert.Len(t, conditional.ElseCommands(), 1)
+
+	nested, ok := conditional.Commands()[1].(*command.ConditionalCommand)
+	assert.True(t, ok)
+	assert.Len(t, nested.Commands(), 1)
+	assert.Empty(t, nested.ElseCommands())
+}
+
+func Test_conditional_block_errors_report_line(t *testing.T) {
+	testCases := []struct {
+		content       string
+		expectedLine  int
+		expectedError string
+	}{
+		{addOnWithUnbalancedElse, 5, "Line 5: 'else' without matching 'if'"},
+		{addOnWithMissingEnd, 5, "Line 5: Missing 'end' for 'if defined PROXY'"},
+		{addOnWithInvalidCondition, 4, "Line 4: Invalid condition in 'if #{PROXY}'"},
+	}
+
+	for _, testCase := range testCases {
+		_, _, err := testParser.parseAddOnContent(strings.NewReader(testCase.content))
+		assert.Error(t, err)
+		parseError, ok := err.(ParseError)
+		assert.True(t, ok)
+		assert.Equal(t, testCase.expectedLine, parseError.Line())
+		assert.Contains(t, err.Error(), testCase.expectedError)
+	}
+}
+
+func Test_wait_for_commands_are_parsed(t *testing.T) {
+	_, commands, err := testParser.parseAddOnContent(strings.NewReader(addOnWithWaitFor))
+	assert.NoError(t, err, "Error in parsing addon content")
+	assert.Len(t, commands, 3)
+
+	waitFor, ok := commands[1].(*command.WaitForCommand)
+	assert.True(t, ok)
+	assert.Equal(t, 120*time.Second, waitFor.Options().Timeout)
+	assert.Equal(t, 2*time.Second, waitFor.Options().Interval)
+	_, ok = waitFor.Check().(*command.OcCommand)
+	assert.True(t, ok)
+
+	waitFor, ok = commands[2].(*command.WaitForCommand)
+	assert.True(t, ok)
+	assert.Equal(t, "Running", waitFor.Options().Match.String())
+}
+
+func Test_wait_for_requires_supported_check(t *testing.T) {
+	for _, line := range []string{"wait-for sleep 5", "wait-for timeout=foo oc get pods", "wait-for snafu"} {
+		_, err := testParser.handler.Handle(testParser.handler, line, false, "")
+		assert.Error(t, err, "Expected error parsing '%s'", line)
+	}
+}
+
+func Test_variable_declarations_are_parsed(t *testing.T) {
+	content := `# Name: typed
+# Description: Addon with typed variables
+# Required-Vars: HTTP_PORT, MODE
+# Var: HTTP_PORT int The port the proxy listens on
+# Var: MODE enum(dev|prod) The deployment mode
+# Var: TOKEN secret The registry token
+
+echo #{HTTP_PORT}
+`
+	meta, _, err := testParser.parseAddOnContent(strings.NewReader(content))
+	assert.NoError(t, err, "Error in parsing addon content")
+
+	declarations, err := meta.VarDeclarations()
+	assert.NoError(t, err)
+	assert.Len(t, declarations, 3)
+	assert.Equal(t, "HTTP_PORT int The port the proxy listens on", declarations[0].String())
+	assert.Equal(t, "MODE enum(dev|prod) The deployment mode", declarations[1].String())
+	assert.True(t, declarations[2].IsSecret())
+}
+
+func Test_copy_and_template_commands_are_parsed(t *testing.T) {
+	_, commands, err := testParser.parseAddOnContent(strings.NewReader(`# Name: copy
+# Description: Copies files into the VM
+
+copy files/registry.crt /etc/docker/certs.d/registry/ca.crt
+template --mode 0600 files/proxy.conf /var/lib/minishift/#{addon-name}.conf
+`))
+	assert.NoError(t, err, "Error in parsing addon content")
+	assert.Len(t, commands, 2)
+
+	_, ok := commands[0].(*command.CopyCommand)
+	assert.True(t, ok)
+	_, ok = commands[1].(*command.CopyCommand)
+	assert.True(t, ok)
+
+	for _, line := range []string{"copy files/registry.crt", "template --mode 0999 a b", "copy --mode"} {
+		_, err := testParser.handler.Handle(testParser.handler, line, false, "")
+		assert.Error(t, err, "Expected error parsing '%s'", line)
+	}
+}
+
+func Test_host_commands_are_parsed(t *testing.T) {
+	newCommand, err := testParser.handler.Handle(testParser.handler, "host ./generate-certs.sh #{ip}", false, "")
+	assert.NoError(t, err)
+	_, ok := newCommand.(*command.HostCommand)
+	assert.True(t, ok)
+}
@@ -0,0 +1,368 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
+	minishiftStrings "github.com/minishift/minishift/pkg/util/strings"
+)
+
+const (
+	ErrorSeverity   = "error"
+	WarningSeverity = "warning"
+)
+
+var (
+	// builtInVariables are the variables available for interpolation in every addon
+	builtInVariables = []string{"ip", "routing-suffix", "addon-name", "user"}
+
+	knownCommands = []string{dockerCommand, ocCommand, openShiftCommand, sleepCommand, sshCommand, echoCommand, catCommand, waitForCommand, copyCommand, templateCommand, hostCommand}
+
+	variableReferenceRegexp = regexp.MustCompile(`#{([^}]+)}`)
+)
+
+// Diagnostic describes a problem found while verifying an addon.
+type Diagnostic struct {
+	AddOn    string `json:"addon"`
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// addOnVerifier collects the diagnostics of a single addon.
+type addOnVerifier struct {
+	parser      *AddOnParser
+	dir         string
+	name        string
+	diagnostics []Diagnostic
+}
+
+// addOnFileContent is the header of an addon file together with the metadata created from it.
+type addOnFileContent struct {
+	file   string
+	header []string
+	meta   addon.AddOnMeta
+}
+
+// Verify checks the addon in addOnDir without applying it. Besides parsing the addon, it checks that every
+// interpolated variable is declared or assigned by a preceding command, that every command is known, that the
+// addons it depends on exist in addOnsDir and that the metadata of the remove file matches the one of the addon.
+// An empty list is returned if no problem is found.
+func (parser *AddOnParser) Verify(addOnDir string, addOnsDir string) []Diagnostic {
+	v := &addOnVerifier{parser: parser, dir: addOnDir, name: filepath.Base(addOnDir)}
+
+	_, parseErr := parser.Parse(addOnDir)
+
+	var content, removeContent *addOnFileContent
+	if file := v.findFile(".addon"); file != "" {
+		content = v.verifyFile(file, nil)
+	}
+	if file := v.findFile(".addon.remove"); file != "" {
+		var addOnVars []string
+		if content != nil && content.meta != nil {
+			addOnVars = metaVariables(content.meta)
+		}
+		removeContent = v.verifyFile(file, addOnVars)
+	}
+
+	if content != nil && content.meta != nil {
+		v.verifyDependencies(content, addOnsDir)
+		if removeContent != nil && removeContent.meta != nil {
+			v.verifyRemoveMetaData(content.meta, removeContent)
+		}
+	}
+
+	if parseErr != nil {
+		v.reportParseError(parseErr)
+	}
+
+	if v.diagnostics == nil {
+		return []Diagnostic{}
+	}
+	return v.diagnostics
+}
+
+// HasErrors returns true if any of the specified diagnostics is an error.
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == ErrorSeverity {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *addOnVerifier) report(file string, line int, severity string, format string, a ...interface{}) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		AddOn:    v.name,
+		File:     file,
+		Line:     line,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+// reportParseError reports the error returned by the parser, unless the same problem was already reported by one
+// of the other checks.
+func (v *addOnVerifier) reportParseError(err error) {
+	file := v.dir
+	line := 0
+	if parseError, ok := err.(*DefaultParseError); ok {
+		if parseError.File() != "" {
+			file = parseError.File()
+		}
+		line = parseError.Line()
+	}
+
+	for _, diagnostic := range v.diagnostics {
+		if diagnostic.File == file && diagnostic.Severity == ErrorSeverity && ((line > 0 && diagnostic.Line == line) || diagnostic.Message == err.Error()) {
+			return
+		}
+	}
+	v.report(file, line, ErrorSeverity, "%s", err.Error())
+}
+
+// findFile returns the path of the addon file with the specified suffix, if there is exactly one.
+func (v *addOnVerifier) findFile(fileSuffix string) string {
+	files, err := findAddOnFiles(v.dir, fileSuffix)
+	if err != nil || len(files) != 1 {
+		return ""
+	}
+	return filepath.Join(v.dir, files[0])
+}
+
+// verifyFile checks the header and the commands of the specified addon file. knownVars are the variables which are
+// available in addition to the built-in variables and the ones declared in the header of the file.
+func (v *addOnVerifier) verifyFile(file string, knownVars []string) *addOnFileContent {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		v.report(file, 0, ErrorSeverity, "Unable to read the addon file: %s", err.Error())
+		return nil
+	}
+
+	content := &addOnFileContent{file: file}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if !strings.HasPrefix(line, commentChar) {
+			// the parser consumes the first line after the header without processing it
+			if strings.TrimSpace(line) != "" {
+				v.report(file, lineNumber, ErrorSeverity, "The first line after the header is ignored. Separate the header and the commands by an empty line")
+			}
+			break
+		}
+		content.header = append(content.header, line)
+	}
+
+	content.meta, err = createMetaData(content.header)
+	if err != nil {
+		v.report(file, 0, ErrorSeverity, "%s", err.Error())
+	} else {
+		knownVars = append(knownVars, metaVariables(content.meta)...)
+	}
+
+	v.verifyCommands(file, scanner, lineNumber, knownVars)
+	return content
+}
+
+// verifyCommands parses the commands of the addon file like the addon is parsed when it is applied and checks the
+// parsed commands. knownVars are the variables which are available in addition to the built-in variables.
+func (v *addOnVerifier) verifyCommands(file string, scanner *bufio.Scanner, lineNumber int, knownVars []string) {
+	parsed := v.parser.parseCommandLines(scanner, lineNumber)
+	for _, err := range parsed.errors {
+		line := 0
+		if parseError, ok := err.(*DefaultParseError); ok {
+			line = parseError.Line()
+		}
+		v.report(file, line, ErrorSeverity, "%s", strings.TrimPrefix(err.Error(), fmt.Sprintf("Line %d: ", line)))
+	}
+
+	known := make(map[string]bool)
+	for _, name := range builtInVariables {
+		known[name] = true
+	}
+	for _, name := range knownVars {
+		known[name] = true
+	}
+	v.verifyParsedCommands(file, parsed, parsed.commands, known, nil)
+}
+
+// verifyParsedCommands checks that the specified commands are known and that the variables they interpolate are
+// known. scopedVars are the variables known within the enclosing 'if' blocks only.
+func (v *addOnVerifier) verifyParsedCommands(file string, parsed *parsedCommands, commands []command.Command, known map[string]bool, scopedVars []string) {
+	isKnown := func(name string) bool {
+		return known[name] || minishiftStrings.Contains(scopedVars, name)
+	}
+
+	for _, c := range commands {
+		line := parsed.lines[c]
+		if conditional, ok := c.(*command.ConditionalCommand); ok {
+			v.verifyVariables(file, line, conditional.Condition().String(), isKnown)
+			thenVars, elseVars := scopedVars, scopedVars
+			if name, defined := conditional.Condition().Variable(); name != "" && defined {
+				thenVars = append(append([]string{}, scopedVars...), name)
+			} else if name != "" {
+				elseVars = append(append([]string{}, scopedVars...), name)
+			}
+			v.verifyParsedCommands(file, parsed, conditional.Commands(), known, thenVars)
+			v.verifyParsedCommands(file, parsed, conditional.ElseCommands(), known, elseVars)
+			continue
+		}
+
+		if name := strings.Fields(c.String())[0]; !minishiftStrings.Contains(knownCommands, name) {
+			v.report(file, line, ErrorSeverity, "Unknown command '%s'. Supported commands are %s", name, strings.Join(knownCommands, ", "))
+		}
+		v.verifyVariables(file, line, c.String(), isKnown)
+		if copyCommand, ok := c.(*command.CopyCommand); ok {
+			v.verifyCopySource(file, line, copyCommand, isKnown)
+		}
+
+		if output, ok := c.(interface {
+			OutputVariable() string
+		}); ok && output.OutputVariable() != "" {
+			known[output.OutputVariable()] = true
+		}
+	}
+}
+
+// verifyVariables reports the variables interpolated in s which are not known.
+func (v *addOnVerifier) verifyVariables(file string, line int, s string, isKnown func(string) bool) {
+	reported := make(map[string]bool)
+	for _, match := range variableReferenceRegexp.FindAllStringSubmatch(s, -1) {
+		name := match[1]
+		if isKnown(name) || reported[name] {
+			continue
+		}
+		reported[name] = true
+		v.report(file, line, ErrorSeverity, "Variable '%s' is neither declared via Required-Vars, Var-Defaults or Var nor assigned by a preceding command", name)
+	}
+}
+
+// verifyCopySource checks that the source file of the specified copy or template command exists inside of the addon
+// directory. For templates, the variables interpolated in the file are verified as well. Sources whose path is
+// interpolated are skipped.
+func (v *addOnVerifier) verifyCopySource(file string, lineNumber int, copyCommand *command.CopyCommand, isKnown func(string) bool) {
+	args := copyCommand.Arguments()
+	if strings.Contains(args.Source, "#{") {
+		return
+	}
+
+	source, err := command.ResolveAddOnFile(v.dir, args.Source)
+	if err != nil {
+		v.report(file, lineNumber, ErrorSeverity, "Unable to read '%s': %s", args.Source, err.Error())
+		return
+	}
+	data, err := ioutil.ReadFile(source)
+	if err != nil {
+		v.report(file, lineNumber, ErrorSeverity, "Unable to read '%s': %s", args.Source, err.Error())
+		return
+	}
+	if !copyCommand.IsTemplate() {
+		return
+	}
+	for i, templateLine := range strings.Split(string(data), "\n") {
+		v.verifyVariables(source, i+1, templateLine, isKnown)
+	}
+}
+
+// verifyDependencies checks that the addons listed in Depends-On exist in addOnsDir.
+func (v *addOnVerifier) verifyDependencies(content *addOnFileContent, addOnsDir string) {
+	dependencies, err := content.meta.Dependency()
+	if err != nil {
+		return
+	}
+	for _, dependency := range dependencies {
+		files, err := findAddOnFiles(filepath.Join(addOnsDir, dependency), ".addon")
+		if err != nil || len(files) == 0 {
+			v.report(content.file, headerLine(content.header, addon.DependsOnMetaTagName), ErrorSeverity, "Dependency '%s' cannot be found in '%s'", dependency, addOnsDir)
+		}
+	}
+}
+
+// verifyRemoveMetaData checks that the metadata of the remove file is consistent with the one of the addon.
+func (v *addOnVerifier) verifyRemoveMetaData(meta addon.AddOnMeta, removeContent *addOnFileContent) {
+	removeMeta := removeContent.meta
+	if removeMeta.OpenShiftVersion() != meta.OpenShiftVersion() {
+		v.report(removeContent.file, headerLine(removeContent.header, addon.RequiredOpenShiftVersion), WarningSeverity,
+			"OpenShift-Version '%s' differs from the one of the addon '%s'", removeMeta.OpenShiftVersion(), meta.OpenShiftVersion())
+	}
+	if removeMeta.MinishiftVersion() != meta.MinishiftVersion() {
+		v.report(removeContent.file, headerLine(removeContent.header, addon.RequiredMinishiftVersion), WarningSeverity,
+			"Minishift-Version '%s' differs from the one of the addon '%s'", removeMeta.MinishiftVersion(), meta.MinishiftVersion())
+	}
+
+	addOnVars := metaVariables(meta)
+	requiredVars, _ := removeMeta.RequiredVars()
+	for _, requiredVar := range requiredVars {
+		if !minishiftStrings.Contains(addOnVars, requiredVar) {
+			v.report(removeContent.file, headerLine(removeContent.header, addon.RequiredVarsMetaTagName), WarningSeverity,
+				"Variable '%s' is required to remove the addon, but not used by the addon", requiredVar)
+		}
+	}
+
+	declarations, _ := meta.VarDeclarations()
+	removeDeclarations, _ := removeMeta.VarDeclarations()
+	for _, removeDeclaration := range removeDeclarations {
+		for _, declaration := range declarations {
+			if declaration.Name == removeDeclaration.Name && declaration.TypeString() != removeDeclaration.TypeString() {
+				v.report(removeContent.file, headerLine(removeContent.header, addon.VarMetaTagName), ErrorSeverity,
+					"Variable '%s' is declared as %s, but the addon declares it as %s", removeDeclaration.Name, removeDeclaration.TypeString(), declaration.TypeString())
+			}
+		}
+	}
+}
+
+// metaVariables returns the variables declared in the specified metadata.
+func metaVariables(meta addon.AddOnMeta) []string {
+	var vars []string
+	requiredVars, _ := meta.RequiredVars()
+	vars = append(vars, requiredVars...)
+	varDefaults, _ := meta.VarDefaults()
+	for _, varDefault := range varDefaults {
+		vars = append(vars, varDefault.Key)
+	}
+	declarations, _ := meta.VarDeclarations()
+	for _, declaration := range declarations {
+		vars = append(vars, declaration.Name)
+	}
+	return vars
+}
+
+// headerLine returns the line of the header containing the specified metadata tag, 0 if there is none.
+func headerLine(header []string, tag string) int {
+	regex := regexp.MustCompile(regexToGetMetaTagInfo)
+	for i, line := range header {
+		for _, match := range regex.FindAllStringSubmatch(line, -1) {
+			if strings.TrimSpace(match[1]) == tag {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,196 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var verifiedAddOn = `# Name: verified
+# Description: Addon to verify
+# Required-Vars: PROJECT
+# Var-Defaults: REPLICAS=1
+# Depends-On: base, missing
+
+oc new-project #{PROJECT}
+version := oc version
+echo #{version} #{ip}
+if defined TOKEN
+  echo #{TOKEN}
+else
+  echo #{TOKEN}
+end
+ocp scale --replicas=#{REPLICAS} dc/app
+echo #{UNKNOWN}
+`
+
+var verifiedAddOnRemove = `# Name: verified
+# Description: Removes the addon
+# Required-Vars: PROJECT, FORCE
+
+oc delete project #{PROJECT}
+`
+
+func Test_verify_reports_diagnostics_with_file_and_line(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-verifier-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnDir := filepath.Join(testDir, "verified")
+	writeVerifierTestFile(t, filepath.Join(addOnDir, "verified.addon"), verifiedAddOn)
+	writeVerifierTestFile(t, filepath.Join(addOnDir, "verified.addon.remove"), verifiedAddOnRemove)
+	writeVerifierTestFile(t, filepath.Join(testDir, "base", "base.addon"), "# Name: base\n# Description: Base addon\n\necho base\n")
+
+	diagnostics := testParser.Verify(addOnDir, testDir)
+
+	addOnFile := filepath.Join(addOnDir, "verified.addon")
+	removeFile := filepath.Join(addOnDir, "verified.addon.remove")
+	expected := []Diagnostic{
+		{AddOn: "verified", File: addOnFile, Line: 13, Severity: ErrorSeverity, Message: "Variable 'TOKEN' is neither declared via Required-Vars, Var-Defaults or Var nor assigned by a preceding command"},
+		{AddOn: "verified", File: addOnFile, Line: 15, Severity: ErrorSeverity, Message: "Unknown command 'ocp'. Supported commands are docker, oc, openshift, sleep, ssh, echo, cat, wait-for, copy, template, host"},
+		{AddOn: "verified", File: addOnFile, Line: 16, Severity: ErrorSeverity, Message: "Variable 'UNKNOWN' is neither declared via Required-Vars, Var-Defaults or Var nor assigned by a preceding command"},
+		{AddOn: "verified", File: addOnFile, Line: 5, Severity: ErrorSeverity, Message: "Dependency 'missing' cannot be found in '" + testDir + "'"},
+		{AddOn: "verified", File: removeFile, Line: 3, Severity: WarningSeverity, Message: "Variable 'FORCE' is required to remove the addon, but not used by the addon"},
+	}
+	assert.Equal(t, expected, diagnostics)
+	assert.True(t, HasErrors(diagnostics))
+}
+
+func Test_verify_reports_parse_errors(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-verifier-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnDir := filepath.Join(testDir, "broken")
+	addOnFile := filepath.Join(addOnDir, "broken.addon")
+	writeVerifierTestFile(t, addOnFile, "# Name: broken\n# Description: Broken addon\n\nif defined FOO\n  echo foo\n")
+
+	diagnostics := testParser.Verify(addOnDir, testDir)
+	assert.Equal(t, []Diagnostic{{AddOn: "broken", File: addOnFile, Line: 4, Severity: ErrorSeverity, Message: "Missing 'end' for 'if defined FOO'"}}, diagnostics)
+
+	writeVerifierTestFile(t, addOnFile, "# Name: other\n# Description: Wrong name\n\necho foo\n")
+	diagnostics = testParser.Verify(addOnDir, testDir)
+	assert.Equal(t, []Diagnostic{{AddOn: "broken", File: addOnFile, Severity: ErrorSeverity, Message: "Add-on directory name should match to addon name"}}, diagnostics)
+}
+
+func Test_verify_reports_all_parse_errors_of_the_parser(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-verifier-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnDir := filepath.Join(testDir, "blocks")
+	addOnFile := filepath.Join(addOnDir, "blocks.addon")
+	content := "# Name: blocks\n# Description: Addon with broken blocks\n\nelse\nif #{FOO} <> bar\n  echo foo\nend\nif defined FOO\n  echo #{FOO}\nelse\nelse\nend\n"
+	writeVerifierTestFile(t, addOnFile, content)
+
+	diagnostics := testParser.Verify(addOnDir, testDir)
+	expected := []Diagnostic{
+		{AddOn: "blocks", File: addOnFile, Line: 4, Severity: ErrorSeverity, Message: "'else' without matching 'if'"},
+		{AddOn: "blocks", File: addOnFile, Line: 5, Severity: ErrorSeverity, Message: "Invalid condition in 'if #{FOO} <> bar': Unable to parse condition '#{FOO} <> bar'. Use 'defined VAR', 'undefined VAR' or one of the operators ==, !=, =~, !~"},
+		{AddOn: "blocks", File: addOnFile, Line: 11, Severity: ErrorSeverity, Message: "Duplicate 'else' for 'if' in line 8"},
+	}
+	assert.Equal(t, expected, diagnostics)
+
+	_, parseErr := testParser.Parse(addOnDir)
+	assert.EqualError(t, parseErr, "Line 4: 'else' without matching 'if'", "the parser must fail on the first reported error")
+}
+
+func Test_verify_reports_empty_commands(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-verifier-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnDir := filepath.Join(testDir, "empty")
+	addOnFile := filepath.Join(addOnDir, "empty.addon")
+	writeVerifierTestFile(t, addOnFile, "# Name: empty\n# Description: Addon with empty commands\n\n!\nx := \necho foo\n")
+
+	diagnostics := testParser.Verify(addOnDir, testDir)
+	expected := []Diagnostic{
+		{AddOn: "empty", File: addOnFile, Line: 4, Severity: ErrorSeverity, Message: "Unable to process command: ''"},
+		{AddOn: "empty", File: addOnFile, Line: 5, Severity: ErrorSeverity, Message: "Unable to process command: ''"},
+	}
+	assert.Equal(t, expected, diagnostics)
+}
+
+func Test_verify_of_valid_addon_returns_no_diagnostics(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-verifier-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnDir := filepath.Join(testDir, "anyuid")
+	writeVerifierTestFile(t, filepath.Join(addOnDir, "anyuid.addon"), anyuid)
+
+	diagnostics := testParser.Verify(addOnDir, testDir)
+	assert.Empty(t, diagnostics)
+	assert.NotNil(t, diagnostics)
+	assert.False(t, HasErrors(diagnostics))
+}
+
+func Test_verify_checks_copy_sources_and_template_variables(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-verifier-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnDir := filepath.Join(testDir, "templated")
+	addOnFile := filepath.Join(addOnDir, "templated.addon")
+	writeVerifierTestFile(t, addOnFile, "# Name: templated\n# Required-Vars: PORT\n# Description: Addon with templates\n\ntemplate app.conf /etc/app.conf\ncopy missing.conf /etc/missing.conf\n")
+	writeVerifierTestFile(t, filepath.Join(addOnDir, "app.conf"), "port=#{PORT}\nhost=#{HOST}\n")
+
+	diagnostics := testParser.Verify(addOnDir, testDir)
+	assert.Len(t, diagnostics, 2)
+	assert.Equal(t, Diagnostic{AddOn: "templated", File: filepath.Join(addOnDir, "app.conf"), Line: 2, Severity: ErrorSeverity,
+		Message: "Variable 'HOST' is neither declared via Required-Vars, Var-Defaults or Var nor assigned by a preceding command"}, diagnostics[0])
+	assert.Equal(t, addOnFile, diagnostics[1].File)
+	assert.Equal(t, 6, diagnostics[1].Line)
+	assert.Contains(t, diagnostics[1].Message, "Unable to read 'missing.conf'")
+}
+
+func Test_verify_rejects_copy_sources_outside_of_add_on_directory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Creating symbolic links requires privileges on Windows")
+	}
+
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-verifier-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	addOnDir := filepath.Join(testDir, "leaking")
+	addOnFile := filepath.Join(addOnDir, "leaking.addon")
+	secret := filepath.Join(testDir, "secret")
+	writeVerifierTestFile(t, secret, "secret")
+	writeVerifierTestFile(t, addOnFile, "# Name: leaking\n# Description: Addon copying host files\n\ncopy "+secret+" /tmp/a\ncopy ../secret /tmp/b\ntemplate link /tmp/c\n")
+	assert.NoError(t, os.Symlink(secret, filepath.Join(addOnDir, "link")))
+
+	diagnostics := testParser.Verify(addOnDir, testDir)
+	expected := []Diagnostic{
+		{AddOn: "leaking", File: addOnFile, Line: 4, Severity: ErrorSeverity, Message: "Unable to read '" + secret + "': Absolute path '" + secret + "' is not allowed. Use a path relative to the add-on directory"},
+		{AddOn: "leaking", File: addOnFile, Line: 5, Severity: ErrorSeverity, Message: "Unable to read '../secret': '../secret' is not inside of the add-on directory"},
+		{AddOn: "leaking", File: addOnFile, Line: 6, Severity: ErrorSeverity, Message: "Unable to read 'link': 'link' is not inside of the add-on directory"},
+	}
+	assert.Equal(t, expected, diagnostics)
+}
+
+func writeVerifierTestFile(t *testing.T, path string, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
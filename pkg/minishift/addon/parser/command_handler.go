@@ -32,6 +32,10 @@ const (
 	sshCommand       = "ssh"
 	echoCommand      = "echo"
 	catCommand       = "cat"
+	waitForCommand   = "wait-for"
+	copyCommand      = "copy"
+	templateCommand  = "template"
+	hostCommand      = "host"
 )
 
 type CommandHandler interface {
@@ -142,3 +146,81 @@ func (c *CatCommandHandler) Parse(s string, ignoreError bool, outputVariable str
 	}
 	return nil
 }
+
+type HostCommandHandler struct {
+	*defaultCommandHandler
+}
+
+func (c *HostCommandHandler) Parse(s string, ignoreError bool, outputVariable string) command.Command {
+	if strings.HasPrefix(s, hostCommand+" ") {
+		return command.NewHostCommand(s, ignoreError, outputVariable)
+	}
+	return nil
+}
+
+// CopyCommandHandler handles copy and template commands. Their arguments are parsed in Handle, so that invalid
+// arguments can be reported.
+type CopyCommandHandler struct {
+	*defaultCommandHandler
+}
+
+func (c *CopyCommandHandler) Handle(next CommandHandler, s string, ignoreError bool, outputVariable string) (command.Command, error) {
+	var keyword string
+	for _, candidate := range []string{copyCommand, templateCommand} {
+		if strings.HasPrefix(s, candidate+" ") {
+			keyword = candidate
+		}
+	}
+	if keyword == "" {
+		return c.defaultCommandHandler.Handle(c, s, ignoreError, outputVariable)
+	}
+
+	args, err := command.ParseCopyArguments(strings.TrimPrefix(s, keyword))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to process command '%s': %s", s, err.Error()))
+	}
+	if keyword == templateCommand {
+		return command.NewTemplateCommand(s, args, ignoreError), nil
+	}
+	return command.NewCopyCommand(s, args, ignoreError), nil
+}
+
+func (c *CopyCommandHandler) Parse(s string, ignoreError bool, outputVariable string) command.Command {
+	// copy and template commands are created in Handle, so that argument errors can be reported
+	return nil
+}
+
+// WaitForCommandHandler handles wait-for commands. The check to wait for is parsed via checkHandler and needs to be
+// an oc, openshift, ssh or docker command.
+type WaitForCommandHandler struct {
+	*defaultCommandHandler
+	checkHandler CommandHandler
+}
+
+func (c *WaitForCommandHandler) Handle(next CommandHandler, s string, ignoreError bool, outputVariable string) (command.Command, error) {
+	if !strings.HasPrefix(s, waitForCommand+" ") {
+		return c.defaultCommandHandler.Handle(c, s, ignoreError, outputVariable)
+	}
+
+	options, check, err := command.ParseWaitForOptions(strings.TrimPrefix(s, waitForCommand))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to process command '%s': %s", s, err.Error()))
+	}
+
+	checkCommand, err := c.checkHandler.Handle(c.checkHandler, check, false, command.WaitForOutputVariable)
+	if err != nil {
+		return nil, err
+	}
+	switch checkCommand.(type) {
+	case *command.OcCommand, *command.OpenShiftCommand, *command.SSHCommand, *command.DockerCommand:
+	default:
+		return nil, errors.New(fmt.Sprintf("Unable to process command '%s': only oc, openshift, ssh and docker commands can be waited for", s))
+	}
+
+	return command.NewWaitForCommand(s, checkCommand, options, ignoreError, outputVariable), nil
+}
+
+func (c *WaitForCommandHandler) Parse(s string, ignoreError bool, outputVariable string) command.Command {
+	// wait-for commands are created in Handle, so that option errors can be reported
+	return nil
+}
@@ -16,16 +16,22 @@ limitations under the License.
 
 package parser
 
+import "fmt"
+
 type ParseError interface {
 	error
 	AddonName() string
 	AddonDir() string
+	// Line returns the line of the addon file in which the error occurred, 0 if the error is not related to a line.
+	Line() int
 }
 
 type DefaultParseError struct {
 	msg       string
 	addonDir  string
 	addonName string
+	file      string
+	line      int
 }
 
 func NewParseError(msg string, name string, dir string) *DefaultParseError {
@@ -53,3 +59,20 @@ func (e *DefaultParseError) AddonDir() string {
 		return ""
 	}
 }
+
+// File returns the path of the addon file in which the error occurred, if known.
+func (e *DefaultParseError) File() string {
+	return e.file
+}
+
+func (e *DefaultParseError) Line() int {
+	return e.line
+}
+
+// newLineParseError creates a parse error for the specified line of the addon file.
+func newLineParseError(line int, format string, a ...interface{}) *DefaultParseError {
+	return &DefaultParseError{
+		msg:  fmt.Sprintf("Line %d: %s", line, fmt.Sprintf(format, a...)),
+		line: line,
+	}
+}
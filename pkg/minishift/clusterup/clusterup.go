@@ -58,6 +58,8 @@ type ClusterUpConfig struct {
 	KubeConfigPath       string
 	OcPath               string
 	AddonEnv             []string
+	AddonWorkers         int
+	AllowHostCommands    bool
 	PublicHostname       string
 	SSHCommander         provision.SSHCommander
 	OcBinaryPathInsideVM string
@@ -143,7 +145,7 @@ func PostClusterUp(clusterUpConfig *ClusterUpConfig, sshCommander provision.SSHC
 		return err
 	}
 
-	err = applyAddOns(addOnManager, clusterUpConfig.Ip, clusterUpConfig.RoutingSuffix, clusterUpConfig.SshUser, clusterUpConfig.AddonEnv, ocRunner, sshCommander)
+	err = applyAddOns(addOnManager, clusterUpConfig.Ip, clusterUpConfig.RoutingSuffix, clusterUpConfig.SshUser, clusterUpConfig.AddonEnv, clusterUpConfig.AddonWorkers, clusterUpConfig.AllowHostCommands, ocRunner, sshCommander)
 	if err != nil {
 		return err
 	}
@@ -188,6 +190,8 @@ func GetExecutionContext(ip string, routingSuffix string, sshUser string, addOnE
 		if strings.HasPrefix(value, envPrefix) {
 			if os.Getenv(strings.TrimPrefix(value, envPrefix)) != "" {
 				value = os.Getenv(strings.TrimPrefix(value, envPrefix))
+				// values taken from the host environment are treated as secrets, eg passwords or tokens
+				context.MarkSecret(key)
 			} else {
 				continue
 			}
@@ -215,13 +219,26 @@ func CopyOcBinaryFromImageToVM(dockerCommander docker.DockerCommander, image str
 	return nil
 }
 
-func applyAddOns(addOnManager *manager.AddOnManager, ip string, routingSuffix string, sshUser string, addonEnv []string, ocRunner *oc.OcRunner, sshCommander provision.SSHCommander) error {
+// applyAddOns applies the enabled addons. With more than one worker, independent addons are applied concurrently.
+func applyAddOns(addOnManager *manager.AddOnManager, ip string, routingSuffix string, sshUser string, addonEnv []string, addonWorkers int, allowHostCommands bool, ocRunner *oc.OcRunner, sshCommander provision.SSHCommander) error {
 	context, err := GetExecutionContext(ip, routingSuffix, sshUser, addonEnv, ocRunner, sshCommander)
 	if err != nil {
 		return err
 	}
+	context.SetAllowHostCommands(allowHostCommands)
 
-	err = addOnManager.Apply(context)
+	if addonWorkers > 1 {
+		err = addOnManager.ApplyConcurrently(context, addonWorkers)
+	} else {
+		err = addOnManager.Apply(context)
+	}
+
+	// the apply history is recorded in the addon configuration of the instance, regardless of the outcome
+	if minishiftConfig.InstanceConfig != nil {
+		if writeErr := minishiftConfig.InstanceConfig.Write(); writeErr != nil && err == nil {
+			err = errors.New(fmt.Sprintf("Error writing addon config data: %s", writeErr.Error()))
+		}
+	}
 	if err != nil {
 		return err
 	}
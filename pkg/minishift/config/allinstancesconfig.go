@@ -34,6 +34,11 @@ type GlobalConfigType struct {
 	SftpdPID      int
 	ProxyPID      int
 	SystrayPID    int
+	NfsdPID       int
+	// NfsdAddress is the IP of the host-only interface the NFS daemon listens on
+	NfsdAddress string
+	// NfsdClients are the IP addresses of the VMs which are allowed to mount NFS host folders by profile
+	NfsdClients map[string]string
 }
 
 // Create new object with data if file exists or
@@ -33,6 +33,8 @@ type InstanceConfigType struct {
 	CacheImages []string `json:"cache-images"`
 	HostFolders []hostFolderConfig.HostFolderConfig
 	AddonConfig map[string]*addOnConfig.AddOnConfig `json:"addons"`
+	// HostFolderWatcherPID is the PID of the daemon remounting the stale host folders of the instance
+	HostFolderWatcherPID int `json:"hostfolder-watcher-pid,omitempty"`
 }
 
 // Create new object with data if file exists or
@@ -43,13 +43,15 @@ const (
 	MinishiftEnableExperimental    = "MINISHIFT_ENABLE_EXPERIMENTAL"
 	SystemtrayDaemon               = "systemtray"
 	SftpdDaemon                    = "sftpd"
+	NfsdDaemon                     = "nfsd"
+	HostFolderWatcherDaemon        = "hostfolder-watcher"
 	ProxyDaemon                    = "proxy"
 )
 
 var (
 	ValidIsoAliases = []string{CentOsIsoAlias}
 	ValidComponents = []string{"automation-service-broker", "service-catalog", "template-service-broker"}
-	ValidServices   = []string{SystemtrayDaemon, SftpdDaemon, ProxyDaemon}
+	ValidServices   = []string{SystemtrayDaemon, SftpdDaemon, NfsdDaemon, HostFolderWatcherDaemon, ProxyDaemon}
 )
 
 // ProfileAuthorizedKeysPath returns the path of authorized_keys file in profile dir used for authentication purpose
@@ -36,6 +36,8 @@ type ImageCacheConfig struct {
 	CachedImages      []string
 	Out               io.Writer
 	ImageMissStrategy ImageMissStrategy
+	// Workers is the maximum number of images which are imported or exported at the same time
+	Workers int
 }
 
 // GetOpenShiftImageNames returns the full images names for the images requires for a fully functioning OpenShift instance
@@ -50,7 +50,7 @@ type ImageHandler interface {
 	// IsImageCached returns true if the specified image is cached, false otherwise.
 	IsImageCached(config *ImageCacheConfig, image string) bool
 
-	// AreImagesCached returns true if all images specified in the config are cached, false otherwise.
+	// AreImagesCached returns true if all images specified in the config are cached and intact, false otherwise.
 	AreImagesCached(config *ImageCacheConfig) bool
 
 	// GetCachedImages returns a map of cached image names. A map is used to make lookup for a specific image easier.
@@ -0,0 +1,416 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// BundleManifestFile is the first entry of a bundle, listing its content
+	BundleManifestFile = "bundle.json"
+	bundleVersion      = 1
+
+	ociLayoutFile    = "oci-layout"
+	ociLayoutContent = `{"imageLayoutVersion": "1.0.0"}`
+)
+
+var digestRegexp = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// BundleManifest describes the content of an image bundle, a tar.gz archive of an OCI layout.
+type BundleManifest struct {
+	Version int          `json:"version"`
+	Created time.Time    `json:"created"`
+	Images  []string     `json:"images"`
+	Files   []BundleFile `json:"files"`
+}
+
+// BundleFile is a file of the OCI layout contained in a bundle together with its checksum.
+type BundleFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Digest string `json:"digest"`
+}
+
+// CreateBundle writes the cached images specified in the config, or all cached images if none are specified, as
+// bundle to the specified file. The bundle contains an index with the entries of the images and the blobs referenced
+// by them, preceded by a manifest listing the checksums of these files.
+func (handler *OciImageHandler) CreateBundle(config *ImageCacheConfig, bundlePath string) (*BundleManifest, error) {
+	cachedIndex, err := handler.getIndex(config.HostCacheDir)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read the index of the image cache: %s", err.Error()))
+	}
+	if cachedIndex == nil {
+		cachedIndex = &Index{Manifests: Manifests{}, SchemaVersion: 2}
+	}
+
+	images := config.CachedImages
+	if len(images) == 0 {
+		images = sortImageNames(handler.GetCachedImages(config))
+	}
+
+	index := &Index{Manifests: Manifests{}, SchemaVersion: 2}
+	var blobs []Descriptor
+	seenBlobs := make(map[string]bool)
+	addBlob := func(blob Descriptor) {
+		if !seenBlobs[blob.Digest] {
+			seenBlobs[blob.Digest] = true
+			blobs = append(blobs, blob)
+		}
+	}
+	for _, image := range images {
+		if !hasImage(cachedIndex, image) {
+			return nil, errors.New(fmt.Sprintf("Image %s is not cached", image))
+		}
+		for _, entry := range cachedIndex.Manifests {
+			if entry.Annotations.Name != image {
+				continue
+			}
+			manifest, err := readManifest(config.HostCacheDir, entry.Digest)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("Unable to read the manifest of image '%s': %s", image, err.Error()))
+			}
+			index.Manifests = append(index.Manifests, entry)
+			addBlob(Descriptor{Digest: entry.Digest, Size: entry.Size})
+			addBlob(manifest.Config)
+			for _, layer := range manifest.Layers {
+				addBlob(layer)
+			}
+		}
+	}
+
+	indexData, err := json.MarshalIndent(index, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	bundleManifest := &BundleManifest{Version: bundleVersion, Created: time.Now().UTC(), Images: images}
+	bundleManifest.Files = append(bundleManifest.Files,
+		bundleFileFor(ociLayoutFile, []byte(ociLayoutContent)),
+		bundleFileFor(indexFile, indexData))
+	for _, blob := range blobs {
+		info, err := os.Stat(blobPath(config.HostCacheDir, blob.Digest))
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Blob %s is missing in the image cache", blob.Digest))
+		}
+		bundleManifest.Files = append(bundleManifest.Files, BundleFile{
+			Path:   bundleBlobPath(blob.Digest),
+			Size:   info.Size(),
+			Digest: blob.Digest,
+		})
+	}
+
+	if err := writeBundle(bundlePath, bundleManifest, indexData, config.HostCacheDir); err != nil {
+		os.Remove(bundlePath)
+		return nil, err
+	}
+	return bundleManifest, nil
+}
+
+func writeBundle(bundlePath string, bundleManifest *BundleManifest, indexData []byte, cacheDir string) error {
+	bundleFile, err := os.Create(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer bundleFile.Close()
+
+	gzipWriter := gzip.NewWriter(bundleFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	manifestData, err := json.MarshalIndent(bundleManifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{{BundleManifestFile, manifestData}, {ociLayoutFile, []byte(ociLayoutContent)}, {indexFile, indexData}} {
+		if err := writeTarEntry(tarWriter, file.name, int64(len(file.data)), strings.NewReader(string(file.data))); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range bundleManifest.Files {
+		if !strings.HasPrefix(file.Path, blobsDir+"/") {
+			continue
+		}
+		blob, err := os.Open(blobPath(cacheDir, file.Digest))
+		if err != nil {
+			return err
+		}
+		err = writeTarEntry(tarWriter, file.Path, file.Size, blob)
+		blob.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+	return bundleFile.Close()
+}
+
+func writeTarEntry(tarWriter *tar.Writer, name string, size int64, content io.Reader) error {
+	header := &tar.Header{Name: name, Mode: 0644, Size: size, ModTime: time.Now(), Typeflag: tar.TypeReg}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := io.CopyN(tarWriter, content, size)
+	return err
+}
+
+// LoadBundle loads the images of the specified bundle into the image cache. The checksum of each file is verified
+// against the bundle manifest before the file is added to the cache. The index entries of the bundle are merged into
+// the index of the cache, replacing the entries of images with the same name. The names of the loaded images are
+// returned.
+func (handler *OciImageHandler) LoadBundle(config *ImageCacheConfig, bundlePath string) ([]string, error) {
+	bundleFile, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	defer bundleFile.Close()
+
+	gzipReader, err := gzip.NewReader(bundleFile)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("%s is not an image bundle: %s", bundlePath, err.Error()))
+	}
+	tarReader := tar.NewReader(gzipReader)
+
+	header, err := tarReader.Next()
+	if err != nil || header.Name != BundleManifestFile {
+		return nil, errors.New(fmt.Sprintf("%s is not an image bundle: the first entry must be %s", bundlePath, BundleManifestFile))
+	}
+	var bundleManifest BundleManifest
+	if err := json.NewDecoder(tarReader).Decode(&bundleManifest); err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid manifest of image bundle %s: %s", bundlePath, err.Error()))
+	}
+	if bundleManifest.Version != bundleVersion {
+		return nil, errors.New(fmt.Sprintf("Unsupported version %d of image bundle %s", bundleManifest.Version, bundlePath))
+	}
+
+	expectedFiles := make(map[string]BundleFile)
+	for _, file := range bundleManifest.Files {
+		expectedFiles[file.Path] = file
+	}
+
+	if err := os.MkdirAll(filepath.Join(config.HostCacheDir, blobsDir, "sha256"), 0755); err != nil {
+		return nil, err
+	}
+	endWrite, err := beginCacheWrite(config.HostCacheDir)
+	if err != nil {
+		return nil, err
+	}
+	defer endWrite()
+	loadDir, err := ioutil.TempDir(config.HostCacheDir, "bundle-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(loadDir)
+
+	var bundleIndex *Index
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Error reading image bundle %s: %s", bundlePath, err.Error()))
+		}
+
+		name := path.Clean(header.Name)
+		expected, found := expectedFiles[name]
+		if !found {
+			return nil, errors.New(fmt.Sprintf("Image bundle %s contains the unexpected file %s", bundlePath, header.Name))
+		}
+		delete(expectedFiles, name)
+
+		switch {
+		case name == indexFile:
+			data, err := ioutil.ReadAll(io.LimitReader(tarReader, expected.Size+1))
+			if err != nil {
+				return nil, err
+			}
+			if err := verifyChecksum(expected, int64(len(data)), fmt.Sprintf("%s%x", digestPrefix, sha256.Sum256(data))); err != nil {
+				return nil, err
+			}
+			bundleIndex = &Index{}
+			if err := json.Unmarshal(data, bundleIndex); err != nil {
+				return nil, errors.New(fmt.Sprintf("Invalid index in image bundle %s: %s", bundlePath, err.Error()))
+			}
+		case name == ociLayoutFile:
+			// the image cache has its own layout file
+			if _, err := io.Copy(ioutil.Discard, tarReader); err != nil {
+				return nil, err
+			}
+		default:
+			if err := loadBlob(tarReader, expected, config.HostCacheDir, loadDir); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(expectedFiles) > 0 {
+		var missing []string
+		for name := range expectedFiles {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return nil, errors.New(fmt.Sprintf("Image bundle %s is incomplete, %s is missing", bundlePath, strings.Join(missing, ", ")))
+	}
+	if bundleIndex == nil {
+		return nil, errors.New(fmt.Sprintf("Image bundle %s does not contain an index", bundlePath))
+	}
+	if err := verifyBundleIndex(bundleIndex, config.HostCacheDir); err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid index in image bundle %s: %s", bundlePath, err.Error()))
+	}
+
+	loadedImages := []string{}
+	err = handler.modifyIndex(config.HostCacheDir, func(index *Index) error {
+		replaced := make(map[string]bool)
+		for _, entry := range bundleIndex.Manifests {
+			if !containsManifest(index, entry) {
+				replaced[entry.Annotations.Name] = true
+			}
+			loadedImages = append(loadedImages, entry.Annotations.Name)
+		}
+
+		manifests := Manifests{}
+		for _, manifest := range index.Manifests {
+			if !replaced[manifest.Annotations.Name] {
+				manifests = append(manifests, manifest)
+			}
+		}
+		for _, entry := range bundleIndex.Manifests {
+			if replaced[entry.Annotations.Name] {
+				manifests = append(manifests, entry)
+			}
+		}
+		index.Manifests = manifests
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loadedImages, nil
+}
+
+// verifyBundleIndex verifies that the digest of each index entry is valid and that the manifest, config and layers
+// of each entry are available in the cache, either loaded from the bundle or cached before.
+func verifyBundleIndex(bundleIndex *Index, cacheDir string) error {
+	for _, entry := range bundleIndex.Manifests {
+		if !digestRegexp.MatchString(entry.Digest) {
+			return errors.New(fmt.Sprintf("Invalid digest '%s' of image %s", entry.Digest, entry.Annotations.Name))
+		}
+		manifest, err := readManifest(cacheDir, entry.Digest)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Unable to read the manifest of image %s: %s", entry.Annotations.Name, err.Error()))
+		}
+		for _, blob := range append([]Descriptor{manifest.Config}, manifest.Layers...) {
+			if !digestRegexp.MatchString(blob.Digest) {
+				return errors.New(fmt.Sprintf("Invalid digest '%s' of a blob of image %s", blob.Digest, entry.Annotations.Name))
+			}
+			if _, err := os.Stat(blobPath(cacheDir, blob.Digest)); err != nil {
+				return errors.New(fmt.Sprintf("Blob %s of image %s is missing", blob.Digest, entry.Annotations.Name))
+			}
+		}
+	}
+	return nil
+}
+
+// loadBlob writes the blob read from the bundle into the blob directory of the cache. The blob is written to a
+// temporary file in tmpDir first, which is only moved into place once its digest is verified.
+func loadBlob(content io.Reader, expected BundleFile, cacheDir string, tmpDir string) error {
+	if !digestRegexp.MatchString(expected.Digest) || expected.Path != bundleBlobPath(expected.Digest) {
+		return errors.New(fmt.Sprintf("Path %s does not match the digest %s", expected.Path, expected.Digest))
+	}
+
+	target := blobPath(cacheDir, expected.Digest)
+	tmpFile, err := ioutil.TempFile(tmpDir, "blob")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmpFile, hash), io.LimitReader(content, expected.Size+1))
+	tmpFile.Close()
+	if err != nil {
+		return err
+	}
+	if err := verifyChecksum(expected, size, fmt.Sprintf("%s%x", digestPrefix, hash.Sum(nil))); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpFile.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), target)
+}
+
+func verifyChecksum(expected BundleFile, size int64, digest string) error {
+	if size != expected.Size {
+		return errors.New(fmt.Sprintf("Size mismatch of %s, expected %d, got %d", expected.Path, expected.Size, size))
+	}
+	if digest != expected.Digest {
+		return errors.New(fmt.Sprintf("Digest mismatch of %s, expected %s, got %s", expected.Path, expected.Digest, digest))
+	}
+	return nil
+}
+
+// containsManifest returns true if the index already contains the specified entry for the same image.
+func containsManifest(index *Index, entry Manifest) bool {
+	for _, manifest := range index.Manifests {
+		if manifest.Annotations.Name == entry.Annotations.Name && manifest.Digest == entry.Digest {
+			return true
+		}
+	}
+	return false
+}
+
+func bundleFileFor(name string, data []byte) BundleFile {
+	return BundleFile{Path: name, Size: int64(len(data)), Digest: fmt.Sprintf("%s%x", digestPrefix, sha256.Sum256(data))}
+}
+
+func bundleBlobPath(digest string) string {
+	return path.Join(blobsDir, "sha256", strings.TrimPrefix(digest, digestPrefix))
+}
+
+func sortImageNames(images map[string]bool) []string {
+	var names []string
+	for name := range images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
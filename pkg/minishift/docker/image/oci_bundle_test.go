@@ -0,0 +1,178 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_bundle_can_be_loaded_into_another_cache(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "minishift-test-image-bundle-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	sourceDir := filepath.Join(tmpDir, "source")
+	shared := writeTestBlob(t, sourceDir, "shared layer")
+	foo := writeTestImage(t, sourceDir, "foo", shared)
+	bar := writeTestImage(t, sourceDir, "bar", shared)
+	writeTestIndex(t, sourceDir, map[string]Descriptor{"foo:latest": foo, "bar:latest": bar})
+
+	handler := &OciImageHandler{}
+	bundlePath := filepath.Join(tmpDir, "bundle.tar.gz")
+	bundleManifest, err := handler.CreateBundle(&ImageCacheConfig{HostCacheDir: sourceDir, CachedImages: []string{"foo:latest"}}, bundlePath)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo:latest"}, bundleManifest.Images)
+	// layout, index, manifest, config and both layers of foo
+	assert.Len(t, bundleManifest.Files, 6)
+
+	targetDir := filepath.Join(tmpDir, "target")
+	writeTestIndex(t, targetDir, map[string]Descriptor{"bar:latest": writeTestImage(t, targetDir, "bar")})
+	targetConfig := &ImageCacheConfig{HostCacheDir: targetDir}
+
+	loaded, err := handler.LoadBundle(targetConfig, bundlePath)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo:latest"}, loaded)
+	assert.True(t, fileExists(blobPath(targetDir, foo.Digest)))
+	assert.True(t, fileExists(blobPath(targetDir, shared.Digest)))
+	assert.Equal(t, map[string]bool{"foo:latest": true, "bar:latest": true}, handler.GetCachedImages(targetConfig))
+
+	// loading the bundle a second time does not duplicate the index entry
+	_, err = handler.LoadBundle(targetConfig, bundlePath)
+	assert.NoError(t, err)
+	index, err := handler.getIndex(targetDir)
+	assert.NoError(t, err)
+	assert.Len(t, index.Manifests, 2)
+
+	entries, err := ioutil.ReadDir(targetDir)
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		assert.False(t, strings.HasPrefix(entry.Name(), "bundle-"), "The temporary load directory should be removed")
+	}
+	writers, err := activeCacheWriters(targetDir)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, writers, "The load should be unregistered")
+}
+
+func Test_bundle_with_corrupt_blob_is_rejected(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "minishift-test-image-bundle-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	blob := writeTestBlob(t, filepath.Join(tmpDir, "source"), "layer")
+	bundleManifest := &BundleManifest{
+		Version: bundleVersion,
+		Files:   []BundleFile{{Path: bundleBlobPath(blob.Digest), Size: blob.Size, Digest: blob.Digest}},
+	}
+	bundlePath := filepath.Join(tmpDir, "bundle.tar.gz")
+	writeTestBundle(t, bundlePath, bundleManifest, map[string]string{bundleBlobPath(blob.Digest): "LAYER"})
+
+	targetDir := filepath.Join(tmpDir, "target")
+	handler := &OciImageHandler{}
+	_, err = handler.LoadBundle(&ImageCacheConfig{HostCacheDir: targetDir}, bundlePath)
+	assert.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "Digest mismatch of "+bundleBlobPath(blob.Digest)), err.Error())
+	assert.False(t, fileExists(blobPath(targetDir, blob.Digest)))
+}
+
+func Test_bundle_with_invalid_index_entries_is_rejected(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "minishift-test-image-bundle-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	sourceDir := filepath.Join(tmpDir, "source")
+	foo := writeTestImage(t, sourceDir, "foo")
+	missingLayer := digestPrefix + strings.Repeat("0", 64)
+	incomplete := writeTestImage(t, sourceDir, "incomplete", Descriptor{Digest: missingLayer, Size: 1})
+
+	var tests = []struct {
+		name          string
+		entry         Descriptor
+		expectedError string
+	}{
+		{"path traversal", Descriptor{Digest: "sha256:../../escape", Size: 1}, "Invalid digest 'sha256:../../escape' of image bar:latest"},
+		{"missing manifest", Descriptor{Digest: digestPrefix + strings.Repeat("1", 64), Size: 1}, "Unable to read the manifest of image bar:latest"},
+		{"missing layer", incomplete, "Blob " + missingLayer + " of image bar:latest is missing"},
+	}
+
+	// all blobs of the source cache, which lacks the missing layer
+	var blobs []string
+	for _, image := range []Descriptor{foo, incomplete} {
+		blobs = append(blobs, image.Digest)
+		manifest, err := readManifest(sourceDir, image.Digest)
+		assert.NoError(t, err)
+		for _, blob := range append([]Descriptor{manifest.Config}, manifest.Layers...) {
+			if blob.Digest != missingLayer {
+				blobs = append(blobs, blob.Digest)
+			}
+		}
+	}
+
+	handler := &OciImageHandler{}
+	for _, test := range tests {
+		indexData, err := json.Marshal(&Index{SchemaVersion: 2, Manifests: Manifests{
+			{Annotations: Annotations{Name: "foo:latest"}, Digest: foo.Digest, Size: foo.Size},
+			{Annotations: Annotations{Name: "bar:latest"}, Digest: test.entry.Digest, Size: test.entry.Size},
+		}})
+		assert.NoError(t, err)
+		bundleManifest := &BundleManifest{Version: bundleVersion, Files: []BundleFile{bundleFileFor(indexFile, indexData)}}
+		files := map[string]string{indexFile: string(indexData)}
+		for _, blob := range blobs {
+			content, err := ioutil.ReadFile(blobPath(sourceDir, blob))
+			assert.NoError(t, err)
+			bundleManifest.Files = append(bundleManifest.Files, bundleFileFor(bundleBlobPath(blob), content))
+			files[bundleBlobPath(blob)] = string(content)
+		}
+		bundlePath := filepath.Join(tmpDir, "bundle.tar.gz")
+		writeTestBundle(t, bundlePath, bundleManifest, files)
+
+		targetDir := filepath.Join(tmpDir, "target")
+		targetConfig := &ImageCacheConfig{HostCacheDir: targetDir}
+		_, err = handler.LoadBundle(targetConfig, bundlePath)
+		assert.Error(t, err, test.name)
+		if err != nil {
+			assert.Contains(t, err.Error(), test.expectedError, test.name)
+		}
+		assert.Empty(t, handler.GetCachedImages(targetConfig), "No image of the bundle should be added for "+test.name)
+		assert.NoError(t, os.RemoveAll(targetDir))
+	}
+}
+
+func writeTestBundle(t *testing.T, bundlePath string, bundleManifest *BundleManifest, files map[string]string) {
+	bundleFile, err := os.Create(bundlePath)
+	assert.NoError(t, err)
+	defer bundleFile.Close()
+
+	gzipWriter := gzip.NewWriter(bundleFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+	manifestData, err := json.Marshal(bundleManifest)
+	assert.NoError(t, err)
+	assert.NoError(t, writeTarEntry(tarWriter, BundleManifestFile, int64(len(manifestData)), strings.NewReader(string(manifestData))))
+	for name, content := range files {
+		assert.NoError(t, writeTarEntry(tarWriter, name, int64(len(content)), strings.NewReader(content)))
+	}
+	assert.NoError(t, tarWriter.Close())
+	assert.NoError(t, gzipWriter.Close())
+}
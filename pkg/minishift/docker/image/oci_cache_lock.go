@@ -0,0 +1,106 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// cacheWritersDir contains a registration for each export or bundle load writing blobs into the image cache
+	cacheWritersDir = "writers"
+
+	cacheWritersTimeout = indexLockTimeout
+)
+
+// beginCacheWrite registers a writer of blobs into the image cache, eg an export. As long as writers are registered,
+// the garbage collection does not run, since the blobs written are not referenced by the index before the writer
+// is done. Registrations of writers which crashed are ignored. The returned function ends the write.
+func beginCacheWrite(cacheDir string) (func(), error) {
+	// the index lock is held by the garbage collection, so no writer can start during the sweep
+	unlock, err := lockIndex(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	writersDir := filepath.Join(cacheDir, cacheWritersDir)
+	if err := os.MkdirAll(writersDir, 0755); err != nil {
+		return nil, err
+	}
+	registration, err := ioutil.TempFile(writersDir, fmt.Sprintf("%d-", os.Getpid()))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(registration, "%d", os.Getpid())
+	registration.Close()
+	return func() { os.Remove(registration.Name()) }, nil
+}
+
+// lockCacheExclusively waits until no writer is registered and acquires the index lock. While the lock is held, no
+// writer can start. The returned function releases the lock.
+func lockCacheExclusively(cacheDir string) (func(), error) {
+	deadline := time.Now().Add(cacheWritersTimeout)
+	for {
+		unlock, err := lockIndex(cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		writers, err := activeCacheWriters(cacheDir)
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+		if writers == 0 {
+			return unlock, nil
+		}
+		unlock()
+
+		if time.Now().After(deadline) {
+			return nil, errors.New(fmt.Sprintf("Timed out waiting for %d image exports or bundle loads to finish", writers))
+		}
+		time.Sleep(indexLockRetryInterval)
+	}
+}
+
+// activeCacheWriters returns the number of registered writers. Registrations left behind by crashed writers are
+// removed.
+func activeCacheWriters(cacheDir string) (int, error) {
+	registrations, err := ioutil.ReadDir(filepath.Join(cacheDir, cacheWritersDir))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	writers := 0
+	for _, registration := range registrations {
+		registrationPath := filepath.Join(cacheDir, cacheWritersDir, registration.Name())
+		if isStaleLock(registrationPath) {
+			os.Remove(registrationPath)
+			continue
+		}
+		writers++
+	}
+	return writers, nil
+}
@@ -0,0 +1,209 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// CachePolicy limits the disk space used by the image cache.
+type CachePolicy struct {
+	// MaxSize is the maximum size of the cached images in bytes, 0 for no limit
+	MaxSize int64
+	// MaxOpenShiftVersions is the maximum number of OpenShift versions whose images are cached, 0 for no limit
+	MaxOpenShiftVersions int
+}
+
+// EvictionResult describes the images evicted from the image cache when enforcing a cache policy.
+type EvictionResult struct {
+	EvictedImages  []string
+	ReclaimedBytes int64
+}
+
+// evictionCandidate is either the set of cached images of an OpenShift version or a single other image.
+type evictionCandidate struct {
+	version  string
+	images   []string
+	lastUsed time.Time
+}
+
+// EnforceCachePolicy evicts images from the cache until it complies with the policy. The images of the OpenShift
+// versions which were least recently imported or exported are evicted first, followed by the other images in the same
+// order. The images specified as part of the ImageCacheConfig are never evicted. The blobs of the evicted images are
+// deleted via CollectGarbage.
+func (handler *OciImageHandler) EnforceCachePolicy(config *ImageCacheConfig, policy *CachePolicy) (*EvictionResult, error) {
+	result := &EvictionResult{EvictedImages: []string{}}
+	if policy.MaxSize <= 0 && policy.MaxOpenShiftVersions <= 0 {
+		return result, nil
+	}
+
+	keep := make(map[string]bool)
+	for _, image := range config.CachedImages {
+		keep[image] = true
+	}
+
+	err := handler.modifyIndex(config.HostCacheDir, func(index *Index) error {
+		candidates, versionCount := evictionCandidates(index, keep)
+		evicted := make(map[string]bool)
+		evict := func(candidate evictionCandidate) {
+			for _, image := range candidate.images {
+				evicted[image] = true
+				result.EvictedImages = append(result.EvictedImages, image)
+			}
+		}
+
+		var remaining []evictionCandidate
+		for _, candidate := range candidates {
+			if candidate.version != "" && policy.MaxOpenShiftVersions > 0 && versionCount > policy.MaxOpenShiftVersions {
+				evict(candidate)
+				versionCount--
+				continue
+			}
+			remaining = append(remaining, candidate)
+		}
+
+		if policy.MaxSize > 0 {
+			for _, candidate := range remaining {
+				if cachedSize(config.HostCacheDir, index, evicted) <= policy.MaxSize {
+					break
+				}
+				evict(candidate)
+			}
+		}
+
+		manifests := Manifests{}
+		for _, manifest := range index.Manifests {
+			if !evicted[manifest.Annotations.Name] {
+				manifests = append(manifests, manifest)
+			}
+		}
+		index.Manifests = manifests
+		return nil
+	})
+	if err != nil || len(result.EvictedImages) == 0 {
+		return result, err
+	}
+
+	gcResult, err := handler.CollectGarbage(config, false)
+	if err != nil {
+		return result, err
+	}
+	result.ReclaimedBytes = gcResult.ReclaimedBytes
+	return result, nil
+}
+
+// evictionCandidates returns the candidates for eviction, the OpenShift versions first, each group ordered from the
+// least to the most recently used. Candidates containing an image to keep are omitted. The number of cached
+// OpenShift versions, including the ones which cannot be evicted, is returned as well.
+func evictionCandidates(index *Index, keep map[string]bool) ([]evictionCandidate, int) {
+	versions := make(map[string]*evictionCandidate)
+	protectedVersions := make(map[string]bool)
+	var others []evictionCandidate
+	seen := make(map[string]bool)
+	for _, entry := range index.Manifests {
+		name := entry.Annotations.Name
+		lastUsed := lastUsed(entry)
+		version, isOpenShift := openShiftVersion(name)
+		if isOpenShift {
+			candidate, found := versions[version]
+			if !found {
+				candidate = &evictionCandidate{version: version}
+				versions[version] = candidate
+			}
+			if !seen[name] {
+				candidate.images = append(candidate.images, name)
+			}
+			if lastUsed.After(candidate.lastUsed) {
+				candidate.lastUsed = lastUsed
+			}
+			if keep[name] {
+				protectedVersions[version] = true
+			}
+		} else if !keep[name] && !seen[name] {
+			others = append(others, evictionCandidate{images: []string{name}, lastUsed: lastUsed})
+		}
+		seen[name] = true
+	}
+
+	var candidates []evictionCandidate
+	for version, candidate := range versions {
+		if !protectedVersions[version] {
+			candidates = append(candidates, *candidate)
+		}
+	}
+	sortByLastUsed(candidates)
+	sortByLastUsed(others)
+	return append(candidates, others...), len(versions)
+}
+
+func sortByLastUsed(candidates []evictionCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].lastUsed.Equal(candidates[j].lastUsed) {
+			return candidates[i].images[0] < candidates[j].images[0]
+		}
+		return candidates[i].lastUsed.Before(candidates[j].lastUsed)
+	})
+}
+
+// openShiftVersion returns the OpenShift version of the image, if it is one of the images of GetOpenShiftImageNames.
+func openShiftVersion(image string) (string, bool) {
+	for _, prefix := range GetOpenShiftImageNames("") {
+		if strings.HasPrefix(image, prefix) && len(image) > len(prefix) {
+			return strings.TrimPrefix(image, prefix), true
+		}
+	}
+	return "", false
+}
+
+// lastUsed returns the time the image was last imported or exported.
+func lastUsed(entry Manifest) time.Time {
+	var last time.Time
+	for _, timestamp := range []string{entry.Annotations.Exported, entry.Annotations.LastImported} {
+		if t, err := time.Parse(time.RFC3339, timestamp); err == nil && t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+// cachedSize returns the size of the blobs referenced by the images of the index which are not evicted. Blobs shared
+// between images are only counted once.
+func cachedSize(cacheDir string, index *Index, evicted map[string]bool) int64 {
+	blobs := make(map[string]int64)
+	for _, entry := range index.Manifests {
+		if evicted[entry.Annotations.Name] {
+			continue
+		}
+		blobs[entry.Digest] = entry.Size
+		manifest, err := readManifest(cacheDir, entry.Digest)
+		if err != nil {
+			continue
+		}
+		blobs[manifest.Config.Digest] = manifest.Config.Size
+		for _, layer := range manifest.Layers {
+			blobs[layer.Digest] = layer.Size
+		}
+	}
+
+	var size int64
+	for _, blobSize := range blobs {
+		size += blobSize
+	}
+	return size
+}
@@ -0,0 +1,90 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_cache_policy_evicts_least_recently_used_openshift_versions(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-image-policy-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	handler := &OciImageHandler{}
+	images := make(map[string]Descriptor)
+	lastUsed := make(map[string]time.Time)
+	now := time.Now()
+	for i, version := range []string{"v3.11.0", "v3.9.0", "v3.10.0"} {
+		for _, name := range GetOpenShiftImageNames(version) {
+			images[name] = writeTestImage(t, cacheDir, name)
+			lastUsed[name] = now.Add(time.Duration(-i) * time.Hour)
+		}
+	}
+	images["foo:latest"] = writeTestImage(t, cacheDir, "foo")
+	lastUsed["foo:latest"] = now.Add(-time.Minute)
+	writeTestIndex(t, cacheDir, images)
+	assert.NoError(t, handler.modifyIndex(cacheDir, func(index *Index) error {
+		for i := range index.Manifests {
+			index.Manifests[i].Annotations.LastImported = lastUsed[index.Manifests[i].Annotations.Name].UTC().Format(time.RFC3339)
+		}
+		return nil
+	}))
+
+	config := &ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: GetOpenShiftImageNames("v3.9.0")}
+	result, err := handler.EnforceCachePolicy(config, &CachePolicy{MaxOpenShiftVersions: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, GetOpenShiftImageNames("v3.10.0"), sorted(result.EvictedImages), "The least recently used version which is not kept should be evicted")
+	assert.True(t, result.ReclaimedBytes > 0)
+
+	// the kept version and the most recently used other image are evicted last
+	result, err = handler.EnforceCachePolicy(config, &CachePolicy{MaxSize: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, append(GetOpenShiftImageNames("v3.11.0"), "foo:latest"), result.EvictedImages)
+
+	cachedImages := handler.GetCachedImages(config)
+	assert.Len(t, cachedImages, 3)
+	for _, name := range GetOpenShiftImageNames("v3.9.0") {
+		assert.True(t, cachedImages[name])
+	}
+}
+
+func Test_openshift_version_of_image(t *testing.T) {
+	version, found := openShiftVersion(GetOpenShiftImageNames("v3.10.0")[0])
+	assert.True(t, found)
+	assert.Equal(t, "v3.10.0", version)
+
+	_, found = openShiftVersion("openshift/origin:v3.10.0")
+	assert.False(t, found)
+}
+
+func sorted(images []string) []string {
+	return sortImageNames(toSet(images))
+}
+
+func toSet(images []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, image := range images {
+		set[image] = true
+	}
+	return set
+}
@@ -0,0 +1,144 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	blobsDir     = "blobs"
+	digestPrefix = "sha256:"
+)
+
+// Descriptor references a blob of the OCI layout.
+type Descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// OciManifest is the image manifest referenced by an entry of the OCI index.
+type OciManifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+}
+
+// GarbageCollectionResult describes the blobs deleted, or to be deleted in dry-run mode, by a garbage collection.
+type GarbageCollectionResult struct {
+	DeletedBlobs   []string
+	ReclaimedBytes int64
+}
+
+// CollectGarbage deletes the blobs of the image cache which are not referenced by any image of the OCI index. A blob
+// is referenced if it is the manifest of an index entry or the config or one of the layers of such a manifest. In
+// dry-run mode the unreferenced blobs are determined, but not deleted.
+func (handler *OciImageHandler) CollectGarbage(config *ImageCacheConfig, dryRun bool) (*GarbageCollectionResult, error) {
+	result := &GarbageCollectionResult{DeletedBlobs: []string{}}
+
+	blobDir := filepath.Join(config.HostCacheDir, blobsDir, "sha256")
+	if _, err := os.Stat(blobDir); os.IsNotExist(err) {
+		return result, nil
+	}
+
+	// no image must be added to or removed from the index and no blobs must be written while the blobs are swept
+	unlock, err := lockCacheExclusively(config.HostCacheDir)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Cannot delete unused blobs: %s", err.Error()))
+	}
+	defer unlock()
+
+	referenced, err := handler.referencedBlobs(config.HostCacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	blobs, err := ioutil.ReadDir(blobDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, blob := range blobs {
+		digest := digestPrefix + blob.Name()
+		if blob.IsDir() || referenced[digest] {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(filepath.Join(blobDir, blob.Name())); err != nil {
+				return result, err
+			}
+		}
+		result.DeletedBlobs = append(result.DeletedBlobs, digest)
+		result.ReclaimedBytes += blob.Size()
+	}
+
+	return result, nil
+}
+
+// referencedBlobs marks the manifests of the index as well as the configs and layers referenced by them.
+func (handler *OciImageHandler) referencedBlobs(cacheDir string) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+
+	index, err := handler.getIndex(cacheDir)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read the index of the image cache: %s", err.Error()))
+	}
+	if index == nil {
+		return referenced, nil
+	}
+
+	for _, entry := range index.Manifests {
+		referenced[entry.Digest] = true
+		manifest, err := readManifest(cacheDir, entry.Digest)
+		if err != nil {
+			// without its manifest the blobs of the image cannot be determined, deleting them could remove
+			// blobs which are still in use
+			return nil, errors.New(fmt.Sprintf("Unable to read the manifest of image '%s': %s", entry.Annotations.Name, err.Error()))
+		}
+		referenced[manifest.Config.Digest] = true
+		for _, layer := range manifest.Layers {
+			referenced[layer.Digest] = true
+		}
+	}
+
+	return referenced, nil
+}
+
+// readManifest reads the image manifest with the specified digest from the blobs of the image cache.
+func readManifest(cacheDir string, digest string) (*OciManifest, error) {
+	raw, err := ioutil.ReadFile(blobPath(cacheDir, digest))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest OciManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// blobPath returns the path of the blob with the specified digest.
+func blobPath(cacheDir string, digest string) string {
+	return filepath.Join(cacheDir, blobsDir, "sha256", strings.TrimPrefix(digest, digestPrefix))
+}
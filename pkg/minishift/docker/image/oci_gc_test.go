@@ -0,0 +1,161 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_collect_garbage_deletes_unreferenced_blobs(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-image-gc-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	handler := &OciImageHandler{}
+	config := &ImageCacheConfig{HostCacheDir: cacheDir}
+
+	shared := writeTestBlob(t, cacheDir, "shared layer")
+	foo := writeTestImage(t, cacheDir, "foo", shared)
+	bar := writeTestImage(t, cacheDir, "bar", shared)
+	writeTestIndex(t, cacheDir, map[string]Descriptor{"foo:latest": foo, "bar:latest": bar})
+	orphan := writeTestBlob(t, cacheDir, "orphaned layer")
+
+	result, err := handler.CollectGarbage(config, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{orphan.Digest}, result.DeletedBlobs)
+	assert.Equal(t, orphan.Size, result.ReclaimedBytes)
+	assert.False(t, fileExists(blobPath(cacheDir, orphan.Digest)))
+
+	// deleting an image from the index orphans its manifest, config and layers, but not the shared layer
+	assert.NoError(t, handler.pruneImage("foo:latest", config))
+	result, err = handler.CollectGarbage(config, true)
+	assert.NoError(t, err)
+	assert.Len(t, result.DeletedBlobs, 3)
+	assert.NotContains(t, result.DeletedBlobs, shared.Digest)
+	assert.True(t, fileExists(blobPath(cacheDir, foo.Digest)), "Blobs must not be deleted in dry-run mode")
+
+	result, err = handler.CollectGarbage(config, false)
+	assert.NoError(t, err)
+	assert.Len(t, result.DeletedBlobs, 3)
+	assert.False(t, fileExists(blobPath(cacheDir, foo.Digest)))
+	assert.True(t, fileExists(blobPath(cacheDir, shared.Digest)))
+	assert.True(t, fileExists(blobPath(cacheDir, bar.Digest)))
+}
+
+func Test_collect_garbage_waits_for_exports(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-image-gc-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	endWrite, err := beginCacheWrite(cacheDir)
+	assert.NoError(t, err)
+	layer := writeTestBlob(t, cacheDir, "layer of image being exported")
+
+	finished := make(chan struct{})
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		// the export references its layer before it ends
+		image := writeTestImage(t, cacheDir, "foo", layer)
+		writeTestIndex(t, cacheDir, map[string]Descriptor{"foo:latest": image})
+		close(finished)
+		endWrite()
+	}()
+
+	handler := &OciImageHandler{}
+	result, err := handler.CollectGarbage(&ImageCacheConfig{HostCacheDir: cacheDir}, false)
+	<-finished
+	assert.NoError(t, err)
+	assert.Empty(t, result.DeletedBlobs)
+	assert.True(t, fileExists(blobPath(cacheDir, layer.Digest)))
+}
+
+func Test_collect_garbage_ignores_crashed_exports(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-image-gc-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	// a crashed export leaves its registration and temporary layout behind
+	orphan := writeTestBlob(t, cacheDir, "layer of crashed export")
+	assert.NoError(t, os.MkdirAll(filepath.Join(cacheDir, "foo-latest"), 0755))
+	writersDir := filepath.Join(cacheDir, cacheWritersDir)
+	assert.NoError(t, os.MkdirAll(writersDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(writersDir, "crashed"), []byte(strconv.Itoa(exitedProcessPID(t))), 0644))
+
+	handler := &OciImageHandler{}
+	result, err := handler.CollectGarbage(&ImageCacheConfig{HostCacheDir: cacheDir}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{orphan.Digest}, result.DeletedBlobs)
+	assert.False(t, fileExists(filepath.Join(writersDir, "crashed")))
+}
+
+// writeTestBlob writes the specified content as blob into the OCI layout in cacheDir.
+func writeTestBlob(t *testing.T, cacheDir string, content string) Descriptor {
+	digest := fmt.Sprintf("%s%x", digestPrefix, sha256.Sum256([]byte(content)))
+	path := blobPath(cacheDir, digest)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return Descriptor{Digest: digest, Size: int64(len(content))}
+}
+
+// writeTestImage writes the blobs of an image with a config, its own layer and the specified additional layers and
+// returns the descriptor of its manifest.
+func writeTestImage(t *testing.T, cacheDir string, name string, layers ...Descriptor) Descriptor {
+	manifest := OciManifest{
+		SchemaVersion: 2,
+		Config:        writeTestBlob(t, cacheDir, "config of "+name),
+		Layers:        append([]Descriptor{writeTestBlob(t, cacheDir, "layer of "+name)}, layers...),
+	}
+	raw, err := json.Marshal(manifest)
+	assert.NoError(t, err)
+	return writeTestBlob(t, cacheDir, string(raw))
+}
+
+func writeTestIndex(t *testing.T, cacheDir string, images map[string]Descriptor) {
+	var names []string
+	for name := range images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	index := &Index{Manifests: Manifests{}, SchemaVersion: 2}
+	for _, name := range names {
+		index.Manifests = append(index.Manifests, Manifest{
+			Annotations: Annotations{Name: name},
+			Digest:      images[name].Digest,
+			MediaType:   "application/vnd.oci.image.manifest.v1+json",
+			Size:        images[name].Size,
+		})
+	}
+	handler := &OciImageHandler{}
+	assert.NoError(t, handler.updateIndex(cacheDir, index))
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
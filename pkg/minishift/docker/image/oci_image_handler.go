@@ -40,6 +40,8 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
 // OciImageHandler is an ImageHandler implementation using OCI format to maintain the local cache.
@@ -73,6 +75,12 @@ type Manifest struct {
 
 type Annotations struct {
 	Name string `json:"org.opencontainers.image.ref.name"`
+	// Exported is the time the image was exported into the cache in RFC 3339 format
+	Exported string `json:"io.minishift.image.exported,omitempty"`
+	// LastImported is the time the image was last imported into the Docker daemon in RFC 3339 format
+	LastImported string `json:"io.minishift.image.last-imported,omitempty"`
+	// SourceDigest is the digest of the manifest in the registry the image was exported from
+	SourceDigest string `json:"io.minishift.image.source-digest,omitempty"`
 }
 
 type Platform struct {
@@ -98,76 +106,137 @@ func NewLocalOnlyOciImageHandler() (*OciImageHandler, error) {
 
 // ImportImages imports cached images from the host into the Docker daemon of the VM.
 func (handler *OciImageHandler) ImportImages(config *ImageCacheConfig) ([]string, error) {
-	out := handler.getOutputWriter(config)
-	importedImages := []string{}
-
-	policyContext, err := handler.getPolicyContext()
-	if err != nil {
-		return importedImages, fmt.Errorf("Error creating security context: %s", err.Error())
-	}
-
 	availableImages, err := handler.GetDockerImages()
 	if err != nil {
-		return importedImages, err
+		return []string{}, err
 	}
 
-	multiError := util.MultiError{}
-	for _, imageName := range config.CachedImages {
-		fmt.Fprint(out, fmt.Sprintf("   Importing '%s' ", imageName))
-		progressDots := progressdots.New()
-		progressDots.SetWriter(out)
-		progressDots.Start()
+	importedImages, err := handler.processImages(config, "   Importing '%s'", func(imageName string, out io.Writer) (ProgressStatus, error) {
 		if _, found := availableImages[imageName]; found {
-			handler.endProgress(progressDots, out, OK)
-			importedImages = append(importedImages, imageName)
-			continue
-
+			return OK, nil
 		}
 
 		if !handler.IsImageCached(config, imageName) {
-			handler.endProgress(progressDots, out, CACHE_MISS)
-			continue
+			return CACHE_MISS, nil
 		}
 
-		err := handler.importImage(imageName, config, policyContext, out)
-		handler.endProgress(progressDots, out, handler.progressStatusForError(err))
-		multiError.Collect(err)
-		if err == nil {
-			importedImages = append(importedImages, imageName)
+		// a policy context must not be used by concurrent copies
+		policyContext, err := handler.getPolicyContext()
+		if err != nil {
+			return FAIL, err
 		}
+		defer policyContext.Destroy()
+
+		err = handler.importImage(imageName, config, policyContext, out)
+		return handler.progressStatusForError(err), err
+	})
+
+	// the import time determines which images are evicted first by the cache policy
+	if recordErr := handler.recordImport(config.HostCacheDir, importedImages); recordErr != nil {
+		fmt.Fprintln(handler.getOutputWriter(config), fmt.Sprintf("   WARN: Unable to record the import time of the images: %v", recordErr))
 	}
-	return importedImages, multiError.ToError()
+	return importedImages, err
+}
+
+// recordImport sets the last import time of the specified cached images to the current time.
+func (handler *OciImageHandler) recordImport(cacheDir string, images []string) error {
+	if len(images) == 0 || !filehelper.Exists(filepath.Join(cacheDir, indexFile)) {
+		return nil
+	}
+
+	imported := make(map[string]bool)
+	for _, image := range images {
+		imported[image] = true
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	return handler.modifyIndex(cacheDir, func(index *Index) error {
+		for i := range index.Manifests {
+			if imported[index.Manifests[i].Annotations.Name] {
+				index.Manifests[i].Annotations.LastImported = now
+			}
+		}
+		return nil
+	})
 }
 
 // ExportImages exports the images specified as part of the ImageCacheConfig from the VM to the host.
+// The names of the successfully exported images are returned.
 func (handler *OciImageHandler) ExportImages(config *ImageCacheConfig, overwrite bool) ([]string, error) {
-	out := handler.getOutputWriter(config)
-	exportedImages := []string{}
+	blobs := newSharedBlobs()
+	return handler.processImages(config, "Exporting '%s'", func(imageName string, out io.Writer) (ProgressStatus, error) {
+		if handler.isImageComplete(config, imageName) && !overwrite {
+			return OK, nil
+		}
 
-	policyContext, err := handler.getPolicyContext()
-	if err != nil {
-		return exportedImages, fmt.Errorf("Error creating security context: %s", err.Error())
-	}
+		// a policy context must not be used by concurrent copies
+		policyContext, err := handler.getPolicyContext()
+		if err != nil {
+			return FAIL, err
+		}
+		defer policyContext.Destroy()
+
+		err = handler.exportImage(imageName, config, policyContext, out, overwrite, blobs)
+		return handler.progressStatusForError(err), err
+	})
+}
 
+// processImages runs process for each image of the config, with up to config.Workers images being processed at the
+// same time. With a single worker, the progress of the current image is shown via progress dots. Otherwise a line is
+// printed per image once it is processed. The names of the images processed with status OK are returned.
+func (handler *OciImageHandler) processImages(config *ImageCacheConfig, progressFormat string, process func(imageName string, out io.Writer) (ProgressStatus, error)) ([]string, error) {
+	out := handler.getOutputWriter(config)
+	processedImages := []string{}
 	multiError := util.MultiError{}
-	for _, imageName := range config.CachedImages {
-		fmt.Fprint(out, fmt.Sprintf("Exporting '%s'", imageName))
-		err = nil
-		progressDots := progressdots.New()
-		progressDots.SetWriter(out)
-		progressDots.Start()
 
-		if !handler.IsImageCached(config, imageName) || overwrite {
-			err = handler.exportImage(imageName, config, policyContext, out, overwrite)
-		}
-		handler.endProgress(progressDots, out, handler.progressStatusForError(err))
-		multiError.Collect(err)
-		if err != nil {
-			exportedImages = append(exportedImages, imageName)
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	if workers == 1 {
+		for _, imageName := range config.CachedImages {
+			fmt.Fprint(out, fmt.Sprintf(progressFormat, imageName))
+			progressDots := progressdots.New()
+			progressDots.SetWriter(out)
+			progressDots.Start()
+			status, err := process(imageName, out)
+			handler.endProgress(progressDots, out, status)
+			multiError.Collect(err)
+			if status == OK {
+				processedImages = append(processedImages, imageName)
+			}
 		}
+		return processedImages, multiError.ToError()
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	images := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for imageName := range images {
+				start := time.Now()
+				status, err := process(imageName, out)
+
+				mutex.Lock()
+				fmt.Fprintf(out, "%s %s (%s)\n", fmt.Sprintf(progressFormat, imageName), status.String(), time.Since(start).Round(time.Second))
+				multiError.Collect(err)
+				if status == OK {
+					processedImages = append(processedImages, imageName)
+				}
+				mutex.Unlock()
+			}
+		}()
 	}
+	for _, imageName := range config.CachedImages {
+		images <- imageName
+	}
+	close(images)
+	wg.Wait()
 
-	return exportedImages, multiError.ToError()
+	return processedImages, multiError.ToError()
 }
 
 // PruneImages delete the specified as command line option.
@@ -203,19 +272,37 @@ func (handler *OciImageHandler) IsImageCached(config *ImageCacheConfig, image st
 	return found
 }
 
-// AreImagesCached returns true if all images specified in the config are cached, false otherwise.
+// AreImagesCached returns true if all images specified in the config are cached and intact, false otherwise. An
+// image is intact if its manifest, config and layers exist and their content matches their digest, the same check
+// VerifyCache applies. Only the blobs of the specified images are re-hashed.
 func (handler *OciImageHandler) AreImagesCached(config *ImageCacheConfig) bool {
-	cachedImages := handler.GetCachedImages(config)
+	index, err := handler.getIndex(config.HostCacheDir)
+	if err != nil {
+		return false
+	}
+	if index == nil {
+		return len(config.CachedImages) == 0
+	}
 
 	for _, image := range config.CachedImages {
-		if _, found := cachedImages[image]; !found {
+		if !hasImage(index, image) {
 			return false
 		}
+		for _, entry := range index.Manifests {
+			if entry.Annotations.Name == image && !isImageIntact(config.HostCacheDir, entry) {
+				return false
+			}
+		}
 	}
 
 	return true
 }
 
+// isImageComplete returns true if the image is cached and its manifest, config and layers exist and are intact.
+func (handler *OciImageHandler) isImageComplete(config *ImageCacheConfig, image string) bool {
+	return handler.AreImagesCached(&ImageCacheConfig{HostCacheDir: config.HostCacheDir, CachedImages: []string{image}})
+}
+
 func (handler *OciImageHandler) GetCachedImages(config *ImageCacheConfig) map[string]bool {
 	cachedImages := make(map[string]bool)
 
@@ -278,7 +365,7 @@ func (handler *OciImageHandler) pullImage(image string, out io.Writer) error {
 }
 
 func (handler *OciImageHandler) getIndex(cacheDir string) (*Index, error) {
-	indexPath := filepath.Join(cacheDir, "index.json")
+	indexPath := filepath.Join(cacheDir, indexFile)
 	if !filehelper.Exists(indexPath) {
 		return nil, nil
 	}
@@ -308,7 +395,7 @@ func (handler *OciImageHandler) importImage(image string, config *ImageCacheConf
 		return fmt.Errorf("Invalid image source '%s': %v", image, err)
 	}
 
-	err = handler.copyImage(srcRef, destRef, policyContext, config.HostCacheDir)
+	err = handler.copyImage(srcRef, destRef, policyContext, handler.getSystemContext(config.HostCacheDir))
 	if err != nil {
 		return err
 	}
@@ -316,7 +403,7 @@ func (handler *OciImageHandler) importImage(image string, config *ImageCacheConf
 	return nil
 }
 
-func (handler *OciImageHandler) exportImage(image string, config *ImageCacheConfig, policyContext *signature.PolicyContext, out io.Writer, overwrite bool) error {
+func (handler *OciImageHandler) exportImage(image string, config *ImageCacheConfig, policyContext *signature.PolicyContext, out io.Writer, overwrite bool, blobs *sharedBlobs) error {
 	availableImages, err := handler.GetDockerImages()
 	if err != nil {
 		return err
@@ -334,84 +421,101 @@ func (handler *OciImageHandler) exportImage(image string, config *ImageCacheConf
 		return fmt.Errorf("Invalid image source '%s': %v", image, err)
 	}
 
-	// ImageIndexLocation should be a directory location which will be atomic for each image.
-	// for an image "openshift/origin-control-plane:v3.10.0"
-	// it will be $HOME/.minishift/cache/image/openshift-origin-control-plane-v3.10.0
-	r := strings.NewReplacer(":", "-", "/", "-")
-	ImageIndexLocation := filepath.Join(config.HostCacheDir, r.Replace(image))
+	return handler.copyToCache(srcRef, image, "", config, policyContext, handler.getSystemContext(config.HostCacheDir), blobs)
+}
 
-	if _, err := os.Stat(ImageIndexLocation); !os.IsNotExist(err) {
-		return fmt.Errorf("Importing %s is already in progress", image)
+// copyToCache copies the image into a temporary OCI layout in the cache directory, which shares the blob directory of
+// the cache. Once copied, the entries of the temporary layout are merged into the index of the cache, annotated with the
+// export time and the digest of the source, if known.
+func (handler *OciImageHandler) copyToCache(srcRef types.ImageReference, image string, sourceDigest string, config *ImageCacheConfig, policyContext *signature.PolicyContext, systemContext *types.SystemContext, blobs *sharedBlobs) error {
+	if err := os.MkdirAll(config.HostCacheDir, 0755); err != nil {
+		return err
 	}
-
-	destRef, err := layout.NewReference(ImageIndexLocation, image)
+	endWrite, err := beginCacheWrite(config.HostCacheDir)
 	if err != nil {
-		return fmt.Errorf("Invalid image destination '%v': %v", destRef, err)
+		return err
 	}
+	defer endWrite()
 
-	err = handler.copyImage(srcRef, destRef, policyContext, config.HostCacheDir)
+	// The temporary layout is named after the image, eg for "openshift/origin-control-plane:v3.10.0" it will be
+	// $HOME/.minishift/cache/images/openshift-origin-control-plane-v3.10.0-<random suffix>
+	r := strings.NewReplacer(":", "-", "/", "-")
+	imageIndexLocation, err := ioutil.TempDir(config.HostCacheDir, r.Replace(image)+"-")
 	if err != nil {
 		return err
 	}
+	defer os.RemoveAll(imageIndexLocation)
 
-	// Get index of current export image
-	pulledImageIndex, err := handler.getIndex(ImageIndexLocation)
+	layoutRef, err := layout.NewReference(imageIndexLocation, image)
 	if err != nil {
-		return err
+		return fmt.Errorf("Invalid image destination '%v': %v", layoutRef, err)
 	}
+	destRef := sharedBlobsReference{ImageReference: layoutRef, blobs: blobs}
 
-	// Remove tmpImageIndexLocation
-	if err := os.RemoveAll(ImageIndexLocation); err != nil {
+	err = handler.copyImage(srcRef, destRef, policyContext, systemContext)
+	if err != nil {
 		return err
 	}
 
-	// Get index of already available image
-	availableImageIndex, err := handler.getIndex(config.HostCacheDir)
+	// Get index of current export image
+	pulledImageIndex, err := handler.getIndex(imageIndexLocation)
 	if err != nil {
 		return err
 	}
-
-	if availableImageIndex == nil {
-		availableImageIndex = &Index{Manifests: Manifests{}, SchemaVersion: 2}
+	if pulledImageIndex == nil {
+		return fmt.Errorf("The export of %s did not create an index", image)
 	}
 
-	availableImageIndex.Manifests = append(availableImageIndex.Manifests, pulledImageIndex.Manifests...)
-
-	if err := handler.updateIndex(config.HostCacheDir, availableImageIndex); err != nil {
-		return err
+	exported := time.Now().UTC().Format(time.RFC3339)
+	for i := range pulledImageIndex.Manifests {
+		pulledImageIndex.Manifests[i].Annotations.Exported = exported
+		pulledImageIndex.Manifests[i].Annotations.SourceDigest = sourceDigest
 	}
 
-	return nil
+	return handler.modifyIndex(config.HostCacheDir, func(index *Index) error {
+		index.Manifests = append(withoutImage(index, image), pulledImageIndex.Manifests...)
+		return nil
+	})
 }
 
+// pruneImage removes the specified image from the index. Its blobs are deleted by CollectGarbage, since they might
+// be shared with other images.
 func (handler *OciImageHandler) pruneImage(image string, config *ImageCacheConfig) error {
-	index, err := handler.getIndex(config.HostCacheDir)
-	if index == nil || err != nil {
-		return err
-	}
+	return handler.modifyIndex(config.HostCacheDir, func(index *Index) error {
+		index.Manifests = withoutImage(index, image)
+		return nil
+	})
+}
 
-	for i, manifest := range index.Manifests {
+// hasImage returns true if the index contains an entry for the specified image.
+func hasImage(index *Index, image string) bool {
+	for _, manifest := range index.Manifests {
 		if manifest.Annotations.Name == image {
-			if err := os.RemoveAll(filepath.Join(config.HostCacheDir, "blobs", "sha256", strings.TrimPrefix(manifest.Digest, "sha256:"))); err != nil {
-				return err
-			}
-			index.Manifests = append(index.Manifests[:i], index.Manifests[i+1:]...)
-			if err := handler.updateIndex(config.HostCacheDir, index); err != nil {
-				return err
-			}
+			return true
 		}
 	}
-	return nil
+	return false
 }
 
-func (handler *OciImageHandler) copyImage(srcRef types.ImageReference, destRef types.ImageReference, policyContext *signature.PolicyContext, cacheDir string) error {
+// withoutImage returns the entries of the index which do not belong to the specified image.
+func withoutImage(index *Index, image string) Manifests {
+	manifests := Manifests{}
+	for _, manifest := range index.Manifests {
+		if manifest.Annotations.Name != image {
+			manifests = append(manifests, manifest)
+		}
+	}
+	return manifests
+}
+
+func (handler *OciImageHandler) copyImage(srcRef types.ImageReference, destRef types.ImageReference, policyContext *signature.PolicyContext, systemContext *types.SystemContext) error {
 	ctx := context.TODO()
 	err := copy.Image(ctx, policyContext, destRef, srcRef, &copy.Options{
 		RemoveSignatures: false,
 		SignBy:           "",
 		ReportWriter:     nil,
-		SourceCtx:        handler.getSystemContext(cacheDir),
-		DestinationCtx:   handler.getSystemContext(cacheDir),
+		SourceCtx:        systemContext,
+		DestinationCtx:   systemContext,
 	})
 	if err != nil {
 		return err
@@ -508,13 +612,10 @@ func (handler *OciImageHandler) progressStatusForError(err error) ProgressStatus
 }
 
 func (handler *OciImageHandler) updateIndex(cacheDir string, index *Index) error {
-	indexPath := filepath.Join(cacheDir, "index.json")
+	indexPath := filepath.Join(cacheDir, indexFile)
 	jsonData, err := json.MarshalIndent(index, "", "\t")
 	if err != nil {
 		return err
 	}
-	if err = ioutil.WriteFile(indexPath, jsonData, 0644); err != nil {
-		return err
-	}
-	return nil
+	return writeFileAtomically(indexPath, jsonData, 0644)
 }
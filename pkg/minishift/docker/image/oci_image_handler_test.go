@@ -17,10 +17,20 @@ limitations under the License.
 package image
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/minishift/minishift/pkg/minikube/tests"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -76,3 +86,78 @@ func Test_Get_Docker_Settings(t *testing.T) {
 		assert.EqualValues(t, envTest.dockerSettings, clientConfig)
 	}
 }
+
+func Test_process_images_concurrently(t *testing.T) {
+	images := []string{"a:latest", "b:latest", "c:latest", "d:latest"}
+	out := new(bytes.Buffer)
+	config := &ImageCacheConfig{CachedImages: images, Out: out, Workers: 2}
+
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	handler := &OciImageHandler{}
+	processed, err := handler.processImages(config, "Exporting '%s'", func(imageName string, out io.Writer) (ProgressStatus, error) {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+
+		if imageName == "c:latest" {
+			return FAIL, errors.New("export failed")
+		}
+		return OK, nil
+	})
+
+	assert.EqualError(t, err, "export failed")
+	assert.Equal(t, 2, maxRunning, "Two images should be processed at the same time")
+	sort.Strings(processed)
+	assert.Equal(t, []string{"a:latest", "b:latest", "d:latest"}, processed)
+	for _, image := range images {
+		assert.Contains(t, out.String(), fmt.Sprintf("Exporting '%s' ", image))
+	}
+	assert.Contains(t, out.String(), "Exporting 'c:latest' FAIL")
+	assert.Equal(t, len(images), strings.Count(out.String(), "\n"), "One line per image expected")
+}
+
+func Test_export_images_returns_exported_images(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-export-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+	writeTestIndex(t, cacheDir, map[string]Descriptor{"cached:latest": writeTestImage(t, cacheDir, "cached")})
+
+	// the VM cannot be reached, so that the export of images which are not cached yet fails
+	handler := &OciImageHandler{driver: &tests.MockDriver{HostError: true}}
+	config := &ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: []string{"cached:latest", "missing:latest"}, Out: new(bytes.Buffer), Workers: 2}
+	exported, err := handler.ExportImages(config, false)
+
+	assert.EqualError(t, err, "Error getting host.")
+	assert.Equal(t, []string{"cached:latest"}, exported)
+}
+
+func Test_cached_image_infos(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-image-info-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	shared := writeTestBlob(t, cacheDir, "shared layer")
+	foo := writeTestImage(t, cacheDir, "foo", shared)
+	writeTestIndex(t, cacheDir, map[string]Descriptor{"foo:latest": foo})
+	handler := &OciImageHandler{}
+	assert.NoError(t, handler.recordImport(cacheDir, []string{"foo:latest"}))
+
+	infos, err := handler.GetCachedImageInfos(&ImageCacheConfig{HostCacheDir: cacheDir})
+	assert.NoError(t, err)
+	assert.Len(t, infos, 1)
+	assert.Equal(t, "foo:latest", infos[0].Name)
+	assert.Equal(t, foo.Digest, infos[0].Digest)
+	expectedSize := foo.Size + int64(len("config of foo")) + int64(len("layer of foo")) + shared.Size
+	assert.Equal(t, expectedSize, infos[0].CompressedSize)
+	assert.WithinDuration(t, time.Now(), infos[0].LastImported, time.Minute)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CachedImageInfo describes a cached image.
+type CachedImageInfo struct {
+	Name   string
+	Digest string
+	// CompressedSize is the size of the manifest, config and layers of the image as stored in the cache
+	CompressedSize int64
+	// LastImported is the time the image was last imported into the Docker daemon, the zero time if it is unknown
+	LastImported time.Time
+}
+
+// GetCachedImageInfos returns the information about each image of the cache, in the order of the index. The sizes are
+// computed from the blob descriptors of the index and the manifests.
+func (handler *OciImageHandler) GetCachedImageInfos(config *ImageCacheConfig) ([]CachedImageInfo, error) {
+	infos := []CachedImageInfo{}
+
+	index, err := handler.getIndex(config.HostCacheDir)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read the index of the image cache: %s", err.Error()))
+	}
+	if index == nil {
+		return infos, nil
+	}
+
+	for _, entry := range index.Manifests {
+		info := CachedImageInfo{Name: entry.Annotations.Name, Digest: entry.Digest, CompressedSize: entry.Size}
+		if manifest, err := readManifest(config.HostCacheDir, entry.Digest); err == nil {
+			info.CompressedSize += manifest.Config.Size
+			for _, layer := range manifest.Layers {
+				info.CompressedSize += layer.Size
+			}
+		}
+		if lastImported, err := time.Parse(time.RFC3339, entry.Annotations.LastImported); err == nil {
+			info.LastImported = lastImported
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
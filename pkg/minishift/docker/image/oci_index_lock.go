@@ -0,0 +1,155 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	indexFile     = "index.json"
+	indexLockFile = "index.json.lock"
+
+	indexLockTimeout       = 2 * time.Minute
+	indexLockRetryInterval = 100 * time.Millisecond
+	// staleIndexLockAge is the age after which a lock without a valid PID is considered to be left behind by a process
+	// which crashed right after creating it
+	staleIndexLockAge = 1 * time.Minute
+)
+
+// lockIndex acquires the lock guarding the updates of the index of the image cache. The lock is a file created
+// exclusively in the cache directory, so that it is shared with the image commands running in other processes, eg the
+// background export started by 'minishift start'. The returned function releases the lock.
+func lockIndex(cacheDir string) (func(), error) {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, err
+	}
+
+	lockPath := filepath.Join(cacheDir, indexLockFile)
+	deadline := time.Now().Add(indexLockTimeout)
+	for {
+		lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(lockFile, "%d", os.Getpid())
+			lockFile.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if isStaleLock(lockPath) {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New(fmt.Sprintf("Timed out waiting for the lock '%s' of the image cache index", lockPath))
+		}
+		time.Sleep(indexLockRetryInterval)
+	}
+}
+
+// isStaleLock returns true if the process which created the specified lock file is not running anymore. A lock held
+// by a running process is never stale, no matter how long it is held.
+func isStaleLock(lockPath string) bool {
+	content, err := ioutil.ReadFile(lockPath)
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil || pid <= 0 {
+		// the PID might not be written yet
+		info, err := os.Stat(lockPath)
+		return err == nil && time.Since(info.ModTime()) > staleIndexLockAge
+	}
+	return !isProcessRunning(pid)
+}
+
+// isProcessRunning returns true if a process with the specified PID is running.
+func isProcessRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	// for Windows FindProcess is enough
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// modifyIndex applies the specified modification to the index of the image cache while holding the index lock. If
+// the cache does not have an index yet, an empty one is passed to modify.
+func (handler *OciImageHandler) modifyIndex(cacheDir string, modify func(index *Index) error) error {
+	unlock, err := lockIndex(cacheDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return handler.modifyLockedIndex(cacheDir, modify)
+}
+
+// modifyLockedIndex applies the specified modification to the index of the image cache. The caller must hold the
+// index lock.
+func (handler *OciImageHandler) modifyLockedIndex(cacheDir string, modify func(index *Index) error) error {
+	index, err := handler.getIndex(cacheDir)
+	if err != nil {
+		return err
+	}
+	if index == nil {
+		index = &Index{Manifests: Manifests{}, SchemaVersion: 2}
+	}
+
+	if err := modify(index); err != nil {
+		return err
+	}
+	return handler.updateIndex(cacheDir, index)
+}
+
+// writeFileAtomically writes the data to a temporary file which is then renamed to the specified path, so that
+// concurrent readers never see a partially written file.
+func writeFileAtomically(path string, data []byte, perm os.FileMode) error {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := os.Chmod(tmpFile.Name(), perm); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
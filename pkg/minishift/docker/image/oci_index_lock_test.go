@@ -0,0 +1,101 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_concurrent_index_modifications_are_not_lost(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-index-lock-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	handler := &OciImageHandler{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := handler.modifyIndex(cacheDir, func(index *Index) error {
+				index.Manifests = append(index.Manifests, Manifest{Annotations: Annotations{Name: fmt.Sprintf("image-%d:latest", i)}})
+				return nil
+			})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	index, err := handler.getIndex(cacheDir)
+	assert.NoError(t, err)
+	assert.Len(t, index.Manifests, 10)
+	assert.False(t, fileExists(filepath.Join(cacheDir, indexLockFile)), "The lock should be released")
+}
+
+func Test_stale_index_lock_is_removed(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-index-lock-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	lockPath := filepath.Join(cacheDir, indexLockFile)
+	assert.NoError(t, ioutil.WriteFile(lockPath, []byte(strconv.Itoa(exitedProcessPID(t))), 0644))
+
+	unlock, err := lockIndex(cacheDir)
+	assert.NoError(t, err)
+	unlock()
+	assert.False(t, fileExists(lockPath))
+
+	// a lock whose PID has not been written yet is only stale once it is old
+	assert.NoError(t, ioutil.WriteFile(lockPath, []byte{}, 0644))
+	assert.False(t, isStaleLock(lockPath))
+	staleTime := time.Now().Add(-2 * staleIndexLockAge)
+	assert.NoError(t, os.Chtimes(lockPath, staleTime, staleTime))
+	assert.True(t, isStaleLock(lockPath))
+}
+
+func Test_index_lock_of_running_process_is_not_stale(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-index-lock-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	unlock, err := lockIndex(cacheDir)
+	assert.NoError(t, err)
+	defer unlock()
+
+	// a lock held longer than the stale age by a running process, eg during a slow garbage collection
+	lockPath := filepath.Join(cacheDir, indexLockFile)
+	oldTime := time.Now().Add(-2 * staleIndexLockAge)
+	assert.NoError(t, os.Chtimes(lockPath, oldTime, oldTime))
+	assert.False(t, isStaleLock(lockPath))
+}
+
+// exitedProcessPID returns the PID of a process which is not running anymore.
+func exitedProcessPID(t *testing.T) int {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	assert.NoError(t, cmd.Run())
+	return cmd.Process.Pid
+}
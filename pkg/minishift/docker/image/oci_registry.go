@@ -0,0 +1,222 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/containers/image/docker/reference"
+	"github.com/containers/image/manifest"
+	"github.com/containers/image/transports/alltransports"
+	"github.com/containers/image/types"
+	"github.com/minishift/minishift/pkg/util"
+)
+
+const dockerHubDomain = "docker.io"
+
+// RegistryConfig configures the access to the registries from which images are exported directly.
+type RegistryConfig struct {
+	// InsecureRegistries are the registries, in the form host[:port], which are accessed without TLS verification
+	// or via plain HTTP
+	InsecureRegistries []string
+	// RegistryMirrors are the mirrors of Docker Hub which are tried before Docker Hub itself
+	RegistryMirrors []string
+}
+
+// registrySource is a docker:// reference from which an image can be copied.
+type registrySource struct {
+	reference string
+	insecure  bool
+}
+
+// ExportImagesFromRegistry copies the images specified as part of the ImageCacheConfig from their registries directly
+// into the image cache, without the Docker daemon of the VM being involved. Proxies are honoured via the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables.
+func (handler *OciImageHandler) ExportImagesFromRegistry(config *ImageCacheConfig, registryConfig *RegistryConfig, overwrite bool) ([]string, error) {
+	blobs := newSharedBlobs()
+	return handler.processImages(config, "Exporting '%s'", func(imageName string, out io.Writer) (ProgressStatus, error) {
+		var err error
+		if !handler.isImageComplete(config, imageName) || overwrite {
+			_, err = handler.updateImageFromRegistry(imageName, config, registryConfig, blobs, true)
+		}
+		return handler.progressStatusForError(err), err
+	})
+}
+
+// RefreshImages compares the digests of the cached images specified as part of the ImageCacheConfig with the digests
+// in their registries and exports the images which changed, or whose source digest is unknown, again. The names of the
+// exported images are returned.
+func (handler *OciImageHandler) RefreshImages(config *ImageCacheConfig, registryConfig *RegistryConfig) ([]string, error) {
+	var mutex sync.Mutex
+	updatedImages := []string{}
+	blobs := newSharedBlobs()
+	_, err := handler.processImages(config, "Refreshing '%s'", func(imageName string, out io.Writer) (ProgressStatus, error) {
+		if !handler.IsImageCached(config, imageName) {
+			return CACHE_MISS, nil
+		}
+
+		updated, err := handler.updateImageFromRegistry(imageName, config, registryConfig, blobs, false)
+		if updated {
+			mutex.Lock()
+			updatedImages = append(updatedImages, imageName)
+			mutex.Unlock()
+		}
+		return handler.progressStatusForError(err), err
+	})
+	sort.Strings(updatedImages)
+	return updatedImages, err
+}
+
+// updateImageFromRegistry copies the image from the first of its registry sources which succeeds. Unless force is
+// set, the image is only copied if the digest in the registry differs from the source digest of the cached image.
+// It returns true if the image was copied.
+func (handler *OciImageHandler) updateImageFromRegistry(image string, config *ImageCacheConfig, registryConfig *RegistryConfig, blobs *sharedBlobs, force bool) (bool, error) {
+	sources, err := registrySources(image, registryConfig)
+	if err != nil {
+		return false, fmt.Errorf("Invalid image name '%s': %v", image, err)
+	}
+
+	multiError := util.MultiError{}
+	for _, source := range sources {
+		srcRef, err := alltransports.ParseImageName(fmt.Sprintf("docker://%s", source.reference))
+		if err != nil {
+			return false, fmt.Errorf("Invalid image source '%s': %v", source.reference, err)
+		}
+		systemContext := registrySystemContext(config.HostCacheDir, source.insecure)
+
+		sourceDigest, err := registryDigest(srcRef, systemContext)
+		if err != nil {
+			multiError.Collect(fmt.Errorf("Error reading the manifest of image '%s': %v", source.reference, err))
+			continue
+		}
+		if !force && sourceDigest == handler.cachedSourceDigest(config.HostCacheDir, image) {
+			return false, nil
+		}
+
+		// a policy context must not be used by concurrent copies
+		policyContext, err := handler.getPolicyContext()
+		if err != nil {
+			return false, fmt.Errorf("Error creating security context: %s", err.Error())
+		}
+		err = handler.copyToCache(srcRef, image, sourceDigest, config, policyContext, systemContext, blobs)
+		policyContext.Destroy()
+		if err == nil {
+			return true, nil
+		}
+		multiError.Collect(fmt.Errorf("Error copying image '%s': %v", source.reference, err))
+	}
+	return false, multiError.ToError()
+}
+
+// registryDigest returns the digest of the manifest of the image in the registry.
+func registryDigest(srcRef types.ImageReference, systemContext *types.SystemContext) (string, error) {
+	ctx := context.TODO()
+	src, err := srcRef.NewImageSource(ctx, systemContext)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	raw, _, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	manifestDigest, err := manifest.Digest(raw)
+	if err != nil {
+		return "", err
+	}
+	return manifestDigest.String(), nil
+}
+
+// cachedSourceDigest returns the source digest recorded for the cached image, or an empty string if it is unknown.
+func (handler *OciImageHandler) cachedSourceDigest(cacheDir string, image string) string {
+	index, err := handler.getIndex(cacheDir)
+	if index == nil || err != nil {
+		return ""
+	}
+	for _, entry := range index.Manifests {
+		if entry.Annotations.Name == image {
+			return entry.Annotations.SourceDigest
+		}
+	}
+	return ""
+}
+
+// registrySources returns the references from which the image can be pulled, in the order in which they are tried.
+// Images of Docker Hub are pulled from the configured mirrors first.
+func registrySources(image string, registryConfig *RegistryConfig) ([]registrySource, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil, err
+	}
+	named = reference.TagNameOnly(named)
+
+	var suffix string
+	if digested, ok := named.(reference.Digested); ok {
+		suffix = "@" + digested.Digest().String()
+	} else if tagged, ok := named.(reference.Tagged); ok {
+		suffix = ":" + tagged.Tag()
+	}
+
+	var sources []registrySource
+	domain := reference.Domain(named)
+	if domain == dockerHubDomain {
+		for _, mirror := range registryConfig.RegistryMirrors {
+			host := strings.TrimRight(mirror, "/")
+			insecure := strings.HasPrefix(host, "http://")
+			host = strings.TrimPrefix(strings.TrimPrefix(host, "http://"), "https://")
+			if host == "" {
+				continue
+			}
+			sources = append(sources, registrySource{
+				reference: fmt.Sprintf("%s/%s%s", host, reference.Path(named), suffix),
+				insecure:  insecure || isInsecureRegistry(host, registryConfig),
+			})
+		}
+	}
+	sources = append(sources, registrySource{reference: named.String(), insecure: isInsecureRegistry(domain, registryConfig)})
+
+	return sources, nil
+}
+
+func isInsecureRegistry(host string, registryConfig *RegistryConfig) bool {
+	for _, registry := range registryConfig.InsecureRegistries {
+		registry = strings.TrimRight(strings.TrimPrefix(strings.TrimPrefix(registry, "http://"), "https://"), "/")
+		if registry == host {
+			return true
+		}
+	}
+	return false
+}
+
+// registrySystemContext returns the system context used to copy images from a registry into the OCI layout of the
+// image cache.
+func registrySystemContext(cacheDir string, insecure bool) *types.SystemContext {
+	return &types.SystemContext{
+		DockerInsecureSkipTLSVerify: insecure,
+		OSChoice:                    "linux",
+		ArchitectureChoice:          "amd64",
+		OCIAcceptUncompressedLayers: true,
+		OCISharedBlobDirPath:        filepath.Join(cacheDir, blobsDir),
+	}
+}
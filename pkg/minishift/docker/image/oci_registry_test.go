@@ -0,0 +1,59 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_registry_sources(t *testing.T) {
+	registryConfig := &RegistryConfig{
+		InsecureRegistries: []string{"registry.local:5000", "http://insecure-mirror.local/"},
+		RegistryMirrors:    []string{"https://mirror.local", "insecure-mirror.local", "http://http-mirror.local/"},
+	}
+
+	var sourceTests = []struct {
+		image   string
+		sources []registrySource
+	}{
+		{"alpine", []registrySource{
+			{"mirror.local/library/alpine:latest", false},
+			{"insecure-mirror.local/library/alpine:latest", true},
+			{"http-mirror.local/library/alpine:latest", true},
+			{"docker.io/library/alpine:latest", false},
+		}},
+		{"openshift/origin:v3.6.0", []registrySource{
+			{"mirror.local/openshift/origin:v3.6.0", false},
+			{"insecure-mirror.local/openshift/origin:v3.6.0", true},
+			{"http-mirror.local/openshift/origin:v3.6.0", true},
+			{"docker.io/openshift/origin:v3.6.0", false},
+		}},
+		{"registry.local:5000/foo/bar:1.0", []registrySource{{"registry.local:5000/foo/bar:1.0", true}}},
+		{"registry.access.redhat.com/openshift3/ose-control-plane:v3.10", []registrySource{{"registry.access.redhat.com/openshift3/ose-control-plane:v3.10", false}}},
+	}
+
+	for _, sourceTest := range sourceTests {
+		sources, err := registrySources(sourceTest.image, registryConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, sourceTest.sources, sources, sourceTest.image)
+	}
+
+	_, err := registrySources("Invalid:Name:", registryConfig)
+	assert.Error(t, err)
+}
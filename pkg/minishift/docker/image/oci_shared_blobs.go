@@ -0,0 +1,128 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/containers/image/types"
+	"github.com/opencontainers/go-digest"
+)
+
+// sharedBlobs keeps track of the blobs which are currently written to the shared blob directory by one of the
+// concurrent exports. Exports of images sharing a layer wait for the export writing it, instead of fetching the same
+// layer a second time.
+type sharedBlobs struct {
+	mutex    sync.Mutex
+	inFlight map[digest.Digest]chan struct{}
+}
+
+func newSharedBlobs() *sharedBlobs {
+	return &sharedBlobs{inFlight: make(map[digest.Digest]chan struct{})}
+}
+
+// claim marks the blob as being written. If another export is already writing it, claim returns false together
+// with a channel which is closed once that export is done with the blob.
+func (s *sharedBlobs) claim(blob digest.Digest) (bool, chan struct{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if done, found := s.inFlight[blob]; found {
+		return false, done
+	}
+	s.inFlight[blob] = make(chan struct{})
+	return true, nil
+}
+
+// release marks the blob as written, or as failed to be written, and wakes up the exports waiting for it.
+func (s *sharedBlobs) release(blob digest.Digest) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if done, found := s.inFlight[blob]; found {
+		close(done)
+		delete(s.inFlight, blob)
+	}
+}
+
+// sharedBlobsReference wraps the OCI layout reference of an export, so that its destination coordinates the writes
+// to the shared blob directory via sharedBlobs.
+type sharedBlobsReference struct {
+	types.ImageReference
+	blobs *sharedBlobs
+}
+
+func (ref sharedBlobsReference) NewImageDestination(ctx context.Context, sys *types.SystemContext) (types.ImageDestination, error) {
+	dest, err := ref.ImageReference.NewImageDestination(ctx, sys)
+	if err != nil {
+		return nil, err
+	}
+	return &sharedBlobsDestination{ImageDestination: dest, blobs: ref.blobs, claimed: make(map[digest.Digest]bool)}, nil
+}
+
+type sharedBlobsDestination struct {
+	types.ImageDestination
+	blobs   *sharedBlobs
+	claimed map[digest.Digest]bool
+}
+
+// HasBlob waits for any other export writing the same blob before checking whether the blob exists. If it does not
+// exist, this destination claims the blob until it is written via PutBlob.
+func (d *sharedBlobsDestination) HasBlob(ctx context.Context, info types.BlobInfo) (bool, int64, error) {
+	for !d.claimed[info.Digest] {
+		claimed, done := d.blobs.claim(info.Digest)
+		if claimed {
+			d.claimed[info.Digest] = true
+			break
+		}
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return false, -1, ctx.Err()
+		}
+	}
+
+	found, size, err := d.ImageDestination.HasBlob(ctx, info)
+	if found || err != nil {
+		d.release(info.Digest)
+	}
+	return found, size, err
+}
+
+func (d *sharedBlobsDestination) PutBlob(ctx context.Context, stream io.Reader, inputInfo types.BlobInfo, isConfig bool) (types.BlobInfo, error) {
+	blobInfo, err := d.ImageDestination.PutBlob(ctx, stream, inputInfo, isConfig)
+	d.release(inputInfo.Digest)
+	d.release(blobInfo.Digest)
+	return blobInfo, err
+}
+
+// Close releases the blobs which were claimed, but not written, eg because the export failed.
+func (d *sharedBlobsDestination) Close() error {
+	for blob := range d.claimed {
+		d.release(blob)
+	}
+	return d.ImageDestination.Close()
+}
+
+func (d *sharedBlobsDestination) release(blob digest.Digest) {
+	if d.claimed[blob] {
+		d.blobs.release(blob)
+		delete(d.claimed, blob)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shared_blob_can_only_be_claimed_once(t *testing.T) {
+	blobs := newSharedBlobs()
+	blob := digest.FromString("layer")
+
+	claimed, _ := blobs.claim(blob)
+	assert.True(t, claimed)
+
+	claimed, done := blobs.claim(blob)
+	assert.False(t, claimed, "The blob is written by another export")
+
+	select {
+	case <-done:
+		t.Fatal("The blob has not been released yet")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	blobs.release(blob)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Waiting exports should be woken up once the blob is released")
+	}
+
+	claimed, _ = blobs.claim(blob)
+	assert.True(t, claimed, "A released blob can be claimed again")
+}
@@ -0,0 +1,204 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// VerificationResult describes the problems found by a verification of the image cache.
+type VerificationResult struct {
+	// CorruptBlobs are the digests of the blobs whose content does not match their digest
+	CorruptBlobs []string `json:"corruptBlobs"`
+	// Images are the cached images which are corrupt or incomplete
+	Images []ImageProblem `json:"images"`
+	// Repaired is true if the corrupt blobs and the entries of the corrupt or incomplete images were removed
+	Repaired bool `json:"repaired"`
+}
+
+// ImageProblem describes why a cached image is corrupt or incomplete.
+type ImageProblem struct {
+	Name     string   `json:"name"`
+	Digest   string   `json:"digest"`
+	Problems []string `json:"problems"`
+}
+
+// IsValid returns true if no problem was found.
+func (result *VerificationResult) IsValid() bool {
+	return len(result.CorruptBlobs) == 0 && len(result.Images) == 0
+}
+
+// VerifyCache re-hashes every blob of the image cache and checks that the manifest, config and layers of every image
+// of the index exist and are intact. In repair mode the corrupt blobs are deleted and the entries of the corrupt or
+// incomplete images are removed from the index, so that these images are exported again. The repair holds the cache
+// exclusively from hashing to repairing, so that no blob written by a concurrent export is mistaken as corrupt.
+func (handler *OciImageHandler) VerifyCache(config *ImageCacheConfig, repair bool) (*VerificationResult, error) {
+	result := &VerificationResult{CorruptBlobs: []string{}, Images: []ImageProblem{}}
+
+	if repair {
+		unlock, err := lockCacheExclusively(config.HostCacheDir)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Cannot repair the image cache: %s", err.Error()))
+		}
+		defer unlock()
+	}
+
+	corruptBlobs := make(map[string]bool)
+	blobDir := filepath.Join(config.HostCacheDir, blobsDir, "sha256")
+	if _, err := os.Stat(blobDir); err == nil {
+		blobs, err := ioutil.ReadDir(blobDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range blobs {
+			if blob.IsDir() {
+				continue
+			}
+			digest := digestPrefix + blob.Name()
+			actual, err := hashBlob(filepath.Join(blobDir, blob.Name()))
+			if err != nil {
+				return nil, err
+			}
+			if actual != digest {
+				corruptBlobs[digest] = true
+				result.CorruptBlobs = append(result.CorruptBlobs, digest)
+			}
+		}
+	}
+
+	index, err := handler.getIndex(config.HostCacheDir)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read the index of the image cache: %s", err.Error()))
+	}
+	if index != nil {
+		for _, entry := range index.Manifests {
+			problems := verifyImage(config.HostCacheDir, entry, corruptBlobs)
+			if len(problems) > 0 {
+				result.Images = append(result.Images, ImageProblem{Name: entry.Annotations.Name, Digest: entry.Digest, Problems: problems})
+			}
+		}
+	}
+
+	if !repair || result.IsValid() {
+		return result, nil
+	}
+
+	for _, blob := range result.CorruptBlobs {
+		if err := os.Remove(blobPath(config.HostCacheDir, blob)); err != nil && !os.IsNotExist(err) {
+			return result, err
+		}
+	}
+	err = handler.modifyLockedIndex(config.HostCacheDir, func(index *Index) error {
+		manifests := Manifests{}
+		for _, manifest := range index.Manifests {
+			if !hasProblem(result.Images, manifest) {
+				manifests = append(manifests, manifest)
+			}
+		}
+		index.Manifests = manifests
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Repaired = true
+	return result, nil
+}
+
+// verifyImage checks that the manifest of the index entry as well as the config and layers referenced by it exist
+// with the expected size and are not contained in corruptBlobs. The problems found are returned.
+func verifyImage(cacheDir string, entry Manifest, corruptBlobs map[string]bool) []string {
+	var problems []string
+	if problem := verifyBlob(cacheDir, "manifest", Descriptor{Digest: entry.Digest, Size: entry.Size}, corruptBlobs); problem != "" {
+		return append(problems, problem)
+	}
+
+	manifest, err := readManifest(cacheDir, entry.Digest)
+	if err != nil {
+		return append(problems, fmt.Sprintf("manifest %s cannot be read: %s", entry.Digest, err.Error()))
+	}
+	if problem := verifyBlob(cacheDir, "config", manifest.Config, corruptBlobs); problem != "" {
+		problems = append(problems, problem)
+	}
+	for _, layer := range manifest.Layers {
+		if problem := verifyBlob(cacheDir, "layer", layer, corruptBlobs); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// isImageIntact returns true if the manifest, config and layers of the index entry exist and their content matches
+// their digest.
+func isImageIntact(cacheDir string, entry Manifest) bool {
+	if len(verifyImage(cacheDir, entry, nil)) > 0 {
+		return false
+	}
+	manifest, err := readManifest(cacheDir, entry.Digest)
+	if err != nil {
+		return false
+	}
+	for _, blob := range append([]Descriptor{{Digest: entry.Digest}, manifest.Config}, manifest.Layers...) {
+		if digest, err := hashBlob(blobPath(cacheDir, blob.Digest)); err != nil || digest != blob.Digest {
+			return false
+		}
+	}
+	return true
+}
+
+func verifyBlob(cacheDir string, kind string, blob Descriptor, corruptBlobs map[string]bool) string {
+	info, err := os.Stat(blobPath(cacheDir, blob.Digest))
+	if err != nil {
+		return fmt.Sprintf("%s %s is missing", kind, blob.Digest)
+	}
+	if corruptBlobs[blob.Digest] {
+		return fmt.Sprintf("%s %s is corrupt", kind, blob.Digest)
+	}
+	if info.Size() != blob.Size {
+		return fmt.Sprintf("%s %s has size %d instead of %d", kind, blob.Digest, info.Size(), blob.Size)
+	}
+	return ""
+}
+
+func hasProblem(images []ImageProblem, manifest Manifest) bool {
+	for _, image := range images {
+		if image.Name == manifest.Annotations.Name && image.Digest == manifest.Digest {
+			return true
+		}
+	}
+	return false
+}
+
+func hashBlob(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%x", digestPrefix, hash.Sum(nil)), nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_verify_cache_reports_and_repairs_broken_images(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-image-verify-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	handler := &OciImageHandler{}
+	config := &ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: []string{"foo:latest", "bar:latest", "baz:latest"}}
+
+	corrupt := writeTestBlob(t, cacheDir, "corrupt layer")
+	missing := writeTestBlob(t, cacheDir, "missing layer")
+	foo := writeTestImage(t, cacheDir, "foo")
+	bar := writeTestImage(t, cacheDir, "bar", corrupt)
+	baz := writeTestImage(t, cacheDir, "baz", missing)
+	writeTestIndex(t, cacheDir, map[string]Descriptor{"foo:latest": foo, "bar:latest": bar, "baz:latest": baz})
+
+	result, err := handler.VerifyCache(config, false)
+	assert.NoError(t, err)
+	assert.True(t, result.IsValid())
+	assert.True(t, handler.AreImagesCached(config))
+
+	assert.NoError(t, ioutil.WriteFile(blobPath(cacheDir, corrupt.Digest), []byte("CORRUPT LAYER"), 0644))
+	assert.NoError(t, os.Remove(blobPath(cacheDir, missing.Digest)))
+	assert.False(t, handler.AreImagesCached(config), "An image with a missing layer is not cached")
+	barConfig := &ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: []string{"bar:latest"}}
+	assert.False(t, handler.AreImagesCached(barConfig), "An image with a corrupt layer of the right size is not cached")
+
+	result, err = handler.VerifyCache(config, false)
+	assert.NoError(t, err)
+	assert.False(t, result.Repaired)
+	assert.Equal(t, []string{corrupt.Digest}, result.CorruptBlobs)
+	assert.Equal(t, []ImageProblem{
+		{Name: "bar:latest", Digest: bar.Digest, Problems: []string{"layer " + corrupt.Digest + " is corrupt"}},
+		{Name: "baz:latest", Digest: baz.Digest, Problems: []string{"layer " + missing.Digest + " is missing"}},
+	}, result.Images)
+	assert.True(t, fileExists(blobPath(cacheDir, corrupt.Digest)), "Nothing must be removed without repair")
+
+	result, err = handler.VerifyCache(config, true)
+	assert.NoError(t, err)
+	assert.True(t, result.Repaired)
+	assert.False(t, fileExists(blobPath(cacheDir, corrupt.Digest)))
+	assert.Equal(t, map[string]bool{"foo:latest": true}, handler.GetCachedImages(config))
+
+	result, err = handler.VerifyCache(config, false)
+	assert.NoError(t, err)
+	assert.True(t, result.IsValid())
+}
+
+func Test_verify_cache_repair_waits_for_running_writers(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-image-verify-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	handler := &OciImageHandler{}
+	foo := writeTestImage(t, cacheDir, "foo")
+	writeTestIndex(t, cacheDir, map[string]Descriptor{"foo:latest": foo})
+
+	endWrite, err := beginCacheWrite(cacheDir)
+	assert.NoError(t, err)
+	// a blob which is still being written, which would be considered corrupt by a concurrent repair
+	partial := writeTestBlob(t, cacheDir, "partial layer")
+	assert.NoError(t, ioutil.WriteFile(blobPath(cacheDir, partial.Digest), []byte("part"), 0644))
+
+	done := make(chan *VerificationResult)
+	go func() {
+		result, err := handler.VerifyCache(&ImageCacheConfig{HostCacheDir: cacheDir}, true)
+		assert.NoError(t, err)
+		done <- result
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("The repair must not run while a writer is registered")
+	default:
+	}
+	assert.NoError(t, ioutil.WriteFile(blobPath(cacheDir, partial.Digest), []byte("partial layer"), 0644))
+	endWrite()
+
+	result := <-done
+	assert.True(t, result.IsValid())
+	assert.True(t, fileExists(blobPath(cacheDir, partial.Digest)))
+}
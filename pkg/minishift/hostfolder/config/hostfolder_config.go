@@ -16,6 +16,10 @@ limitations under the License.
 
 package config
 
+import (
+	"strings"
+)
+
 const (
 	Source       = "source"
 	UncPath      = "uncpath"
@@ -24,6 +28,9 @@ const (
 	Password     = "password"
 	Domain       = "domain"
 	ExtraOptions = "extra-options"
+	ReadOnly     = "read-only"
+	Excludes     = "excludes"
+	PullBack     = "pullback"
 )
 
 type HostFolderConfig struct {
@@ -39,3 +46,24 @@ func (hf *HostFolderConfig) Option(key string) string {
 func (hf *HostFolderConfig) MountPoint() string {
 	return hf.Options[MountPoint]
 }
+
+// IsReadOnly returns true if the host folder is shared read-only.
+func (hf *HostFolderConfig) IsReadOnly() bool {
+	return hf.Options[ReadOnly] == "true"
+}
+
+// ExcludePatterns returns the patterns of the files and directories which are not synchronized by sync host folders.
+func (hf *HostFolderConfig) ExcludePatterns() []string {
+	var patterns []string
+	for _, pattern := range strings.Split(hf.Options[Excludes], ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// IsPullBack returns true if changes in the VM are copied back to the host by sync host folders.
+func (hf *HostFolderConfig) IsPullBack() bool {
+	return hf.Options[PullBack] == "true"
+}
@@ -38,4 +38,21 @@ func TestHostFolderConfig(t *testing.T) {
 	assert.Equal(t, "joe@pillow.us", hostFolderConfigActual.Option(UserName))
 	assert.Equal(t, "am!g@4ever", hostFolderConfigActual.Option(Password))
 	assert.Equal(t, "DESKTOP-RHAIMSWIN", hostFolderConfigActual.Option(Domain))
+	assert.False(t, hostFolderConfigActual.IsReadOnly())
+
+	hostFolderConfigActual.Options[ReadOnly] = "true"
+	assert.True(t, hostFolderConfigActual.IsReadOnly())
+}
+
+func TestSyncOptions(t *testing.T) {
+	hostFolder := HostFolderConfig{
+		Name:    "workspace",
+		Type:    "sync",
+		Options: map[string]string{Excludes: ".git, node_modules,,target"},
+	}
+
+	assert.Equal(t, []string{".git", "node_modules", "target"}, hostFolder.ExcludePatterns())
+	assert.False(t, hostFolder.IsPullBack())
+	hostFolder.Options[PullBack] = "true"
+	assert.True(t, hostFolder.IsPullBack())
 }
@@ -0,0 +1,249 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+)
+
+// errReadOnly is returned when a read-only host folder is about to be modified.
+var errReadOnly = errors.New("read-only host folder")
+
+// exportedRoot is a host folder exposed by one of the file servers running on the host.
+type exportedRoot struct {
+	name     string
+	source   string
+	readOnly bool
+}
+
+// exportedRoots is the virtual file system exposed by the file servers of the host folders. Each host folder is
+// exposed as a top level directory named after the host folder, which is jailed to the source of the host folder.
+// Paths outside of the host folders, including symbolic links pointing outside of their host folder, cannot be
+// accessed.
+type exportedRoots map[string]*exportedRoot
+
+// newExportedRoots creates the virtual file system exposing the host folders of the specified type.
+func newExportedRoots(hostFolders []config.HostFolderConfig, hostFolderType Type) exportedRoots {
+	roots := make(exportedRoots)
+	for _, hostFolder := range hostFolders {
+		if hostFolder.Type != hostFolderType.String() || hostFolder.Option(config.Source) == "" {
+			continue
+		}
+		roots[hostFolder.Name] = &exportedRoot{
+			name:     hostFolder.Name,
+			source:   filepath.Clean(hostFolder.Option(config.Source)),
+			readOnly: hostFolder.IsReadOnly(),
+		}
+	}
+	return roots
+}
+
+// exportedPath returns the path under which the specified host folder is exposed.
+func exportedPath(hostFolder config.HostFolderConfig) string {
+	return "/" + hostFolder.Name
+}
+
+// resolve maps the specified virtual path to the host folder containing it and to the corresponding path on the host.
+// For the virtual root directory containing the host folders, the returned root is nil.
+func (roots exportedRoots) resolve(virtualPath string) (*exportedRoot, string, error) {
+	cleaned := path.Clean("/" + virtualPath)
+	if cleaned == "/" {
+		return nil, "", nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(cleaned, "/"), "/", 2)
+	root, ok := roots[parts[0]]
+	if !ok {
+		return nil, "", os.ErrNotExist
+	}
+
+	hostPath := root.source
+	if len(parts) == 2 {
+		hostPath = filepath.Join(root.source, filepath.FromSlash(parts[1]))
+	}
+	if !root.contains(hostPath) {
+		return nil, "", os.ErrPermission
+	}
+	return root, hostPath, nil
+}
+
+// resolveWritable resolves the specified virtual path like resolve, but rejects paths which cannot be modified.
+func (roots exportedRoots) resolveWritable(virtualPath string) (*exportedRoot, string, error) {
+	root, hostPath, err := roots.resolve(virtualPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if root == nil {
+		return nil, "", os.ErrPermission
+	}
+	if root.readOnly {
+		return nil, "", errReadOnly
+	}
+	return root, hostPath, nil
+}
+
+// list returns the file infos of the host folders for the virtual root directory.
+func (roots exportedRoots) list() []os.FileInfo {
+	var names []string
+	for name := range roots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var infos []os.FileInfo
+	for _, name := range names {
+		root := roots[name]
+		info, err := os.Stat(root.source)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, root.fileInfo(renamedFileInfo{info, name}))
+	}
+	return infos
+}
+
+// contains returns true if the specified host path is located within the source of the host folder once all
+// symbolic links are evaluated. For paths which do not exist yet, the symbolic links of the closest existing parent
+// directory are evaluated. Dangling symbolic links are evaluated to the path their target would be created at.
+func (root *exportedRoot) contains(hostPath string) bool {
+	source, err := filepath.EvalSymlinks(root.source)
+	if err != nil {
+		return false
+	}
+
+	resolved, err := evalExistingSymlinks(hostPath)
+	if err != nil {
+		return false
+	}
+	return resolved == source || strings.HasPrefix(resolved, source+string(filepath.Separator))
+}
+
+// stat returns the file info of the specified host path. The root of the host folder is named after the host folder.
+func (root *exportedRoot) stat(hostPath string) (os.FileInfo, error) {
+	info, err := os.Stat(hostPath)
+	if err != nil {
+		return nil, err
+	}
+	if hostPath == root.source {
+		info = renamedFileInfo{info, root.name}
+	}
+	return root.fileInfo(info), nil
+}
+
+// list returns the entries of the specified directory. Symbolic links are resolved and entries pointing outside of
+// the host folder are omitted.
+func (root *exportedRoot) list(hostPath string) ([]os.FileInfo, error) {
+	entries, err := ioutil.ReadDir(hostPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []os.FileInfo
+	for _, entry := range entries {
+		if entry.Mode()&os.ModeSymlink != 0 {
+			entryPath := filepath.Join(hostPath, entry.Name())
+			if !root.contains(entryPath) {
+				continue
+			}
+			target, err := os.Stat(entryPath)
+			if err != nil {
+				continue
+			}
+			entry = renamedFileInfo{target, entry.Name()}
+		}
+		infos = append(infos, root.fileInfo(entry))
+	}
+	return infos, nil
+}
+
+// fileInfo removes the write permissions from the file info if the host folder is read-only.
+func (root *exportedRoot) fileInfo(info os.FileInfo) os.FileInfo {
+	if !root.readOnly {
+		return info
+	}
+	return readOnlyFileInfo{info}
+}
+
+// maxSymlinks is the maximum number of dangling symbolic links followed when evaluating a path.
+const maxSymlinks = 255
+
+// evalExistingSymlinks evaluates the symbolic links of the longest existing prefix of the specified path and appends
+// the remaining path elements. Dangling symbolic links are followed to their target, since creating a file via a
+// dangling link creates its target.
+func evalExistingSymlinks(hostPath string) (string, error) {
+	return evalExistingSymlinksOf(hostPath, 0)
+}
+
+func evalExistingSymlinksOf(hostPath string, followed int) (string, error) {
+	var missing []string
+	current := hostPath
+	for {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if info, lstatErr := os.Lstat(current); lstatErr == nil && info.Mode()&os.ModeSymlink != 0 {
+			if followed == maxSymlinks {
+				return "", errors.New("too many levels of symbolic links")
+			}
+			target, err := os.Readlink(current)
+			if err != nil {
+				return "", err
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(current), target)
+			}
+			return evalExistingSymlinksOf(filepath.Join(append([]string{target}, missing...)...), followed+1)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", err
+		}
+		missing = append([]string{filepath.Base(current)}, missing...)
+		current = parent
+	}
+}
+
+// renamedFileInfo is a file info exposed under a different name.
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (info renamedFileInfo) Name() string {
+	return info.name
+}
+
+// readOnlyFileInfo is a file info without write permissions.
+type readOnlyFileInfo struct {
+	os.FileInfo
+}
+
+func (info readOnlyFileInfo) Mode() os.FileMode {
+	return info.FileInfo.Mode() &^ 0222
+}
@@ -0,0 +1,230 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/minishift/minishift/pkg/util/shell"
+)
+
+const (
+	// canaryFile is the file stat'ed in the mount point by the liveness probe. It does not need to exist, looking it
+	// up requires a response of the server either way.
+	canaryFile = ".minishift-canary"
+
+	// DefaultProbeTimeout is the time after which a mount not answering the liveness probe is considered stale.
+	DefaultProbeTimeout = 5 * time.Second
+)
+
+// The health states of a host folder determined by the liveness probe.
+const (
+	HealthHealthy    = "healthy"
+	HealthStale      = "stale"
+	HealthNotMounted = "not mounted"
+)
+
+// MountHealth is the result of the liveness probe of a host folder.
+type MountHealth struct {
+	Name       string
+	Type       string
+	MountPoint string
+	State      string
+	// Latency is the time the probe took, it is only set for mounted host folders
+	Latency time.Duration
+	// Error describes why a host folder is stale
+	Error string
+}
+
+// IsStale returns true if the host folder is mounted, but does not respond.
+func (h *MountHealth) IsStale() bool {
+	return h.State == HealthStale
+}
+
+// Probe checks whether the host folder with the specified name is mounted and responds. Unlike the mount check of
+// List, it stats a canary file in the mount point and considers the mount stale if the stat fails or does not
+// complete within the timeout. Sync host folders are stale if their sync daemon died or failed.
+func (m *Manager) Probe(driver drivers.Driver, name string, timeout time.Duration) (*MountHealth, error) {
+	if !m.isHostRunning(driver) {
+		return nil, errors.New("host is in the wrong state")
+	}
+
+	hostFolder := m.getHostFolder(name)
+	if hostFolder == nil {
+		return nil, fmt.Errorf("no host folder with the name '%s' defined", name)
+	}
+	hostFolderConfig := hostFolder.Config()
+	health := &MountHealth{Name: name, Type: hostFolderConfig.Type, MountPoint: hostFolderConfig.MountPoint()}
+
+	if syncHostFolder, ok := hostFolder.(*SyncHostFolder); ok {
+		status, err := syncHostFolder.Status()
+		if err != nil {
+			return nil, err
+		}
+		probeSyncStatus(health, status, isProcessRunning, time.Now())
+		return health, nil
+	}
+
+	mounted, err := m.isHostFolderMounted(driver, hostFolderConfig)
+	if err != nil {
+		return nil, err
+	}
+	if !mounted {
+		health.State = HealthNotMounted
+		return health, nil
+	}
+
+	run := func(cmd string) (string, error) {
+		return drivers.RunSSHCommandFromDriver(driver, cmd)
+	}
+	return health, probeMount(health, hostFolderConfig, run, timeout)
+}
+
+// ProbeAll probes all defined host folders. A host folder which cannot be probed is reported as stale with the error
+// of the probe, so that it does not hide the health of the other host folders.
+func (m *Manager) ProbeAll(driver drivers.Driver, timeout time.Duration) ([]*MountHealth, error) {
+	if !m.ExistAny() {
+		return nil, errors.New("no host folders defined")
+	}
+	if !m.isHostRunning(driver) {
+		return nil, errors.New("host is in the wrong state")
+	}
+
+	var healths []*MountHealth
+	for _, hostFolderConfig := range m.hostFolderConfigs() {
+		health, err := m.Probe(driver, hostFolderConfig.Name, timeout)
+		if err != nil {
+			if health == nil {
+				health = &MountHealth{Name: hostFolderConfig.Name, Type: hostFolderConfig.Type, MountPoint: hostFolderConfig.MountPoint()}
+			}
+			health.State = HealthStale
+			health.Error = err.Error()
+		}
+		healths = append(healths, health)
+	}
+	return healths, nil
+}
+
+// Remount unmounts the host folder with the specified name and mounts it again. Stale mounts which cannot be
+// unmounted regularly are detached lazily.
+func (m *Manager) Remount(driver drivers.Driver, name string) error {
+	if !m.isHostRunning(driver) {
+		return errors.New("host is in the wrong state")
+	}
+
+	hostFolder := m.getHostFolder(name)
+	if hostFolder == nil {
+		return fmt.Errorf("no host folder with the name '%s' defined", name)
+	}
+
+	if err := hostFolder.Umount(driver); err != nil {
+		hostFolderConfig := hostFolder.Config()
+		cmd := fmt.Sprintf("sudo umount -l %s", shell.Quote(hostFolderConfig.MountPoint()))
+		if _, lazyErr := drivers.RunSSHCommandFromDriver(driver, cmd); lazyErr != nil {
+			return fmt.Errorf("error during umounting of host folder: %s", err)
+		}
+	}
+	return hostFolder.Mount(driver)
+}
+
+func (m *Manager) hostFolderConfigs() []config.HostFolderConfig {
+	hostFolderConfigs := append([]config.HostFolderConfig{}, m.allInstancesConfig.HostFolders...)
+	return append(hostFolderConfigs, m.instanceConfig.HostFolders...)
+}
+
+// probeMount stats the canary file of a mounted host folder via run. Since a stat on a stale mount might never return,
+// it is killed in the VM after the timeout, which ends the SSH command. Should the SSH command hang nevertheless, it is
+// abandoned after the timeout.
+func probeMount(health *MountHealth, hostFolder config.HostFolderConfig, run func(cmd string) (string, error), timeout time.Duration) error {
+	seconds := int(math.Ceil(timeout.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	// timeout exits with 124 if stat was terminated and with 137 if it had to be killed
+	cmd := fmt.Sprintf("timeout -k 1 %d stat %s 2>&1; [ $? -lt 124 ] || echo 'stat: no response within %ds'",
+		seconds, shell.Quote(strings.TrimRight(hostFolder.MountPoint(), "/")+"/"+canaryFile), seconds)
+
+	type result struct {
+		output string
+		err    error
+	}
+	done := make(chan result, 1)
+	start := time.Now()
+	go func() {
+		output, err := run(cmd)
+		done <- result{output, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return r.err
+		}
+		health.Latency = time.Since(start)
+		if err := canaryError(r.output); err != nil {
+			health.State = HealthStale
+			health.Error = err.Error()
+			return nil
+		}
+		health.State = HealthHealthy
+	case <-time.After(timeout):
+		health.Latency = timeout
+		health.State = HealthStale
+		health.Error = fmt.Sprintf("no response within %s", timeout)
+	}
+	return nil
+}
+
+// canaryError returns the error of the canary stat. A missing canary file is expected, any other error like
+// 'Transport endpoint is not connected' or 'Stale file handle' means that the mount is stale.
+func canaryError(output string) error {
+	output = strings.TrimSpace(output)
+	if output == "" || strings.Contains(output, "No such file or directory") || strings.Contains(output, "File: ") {
+		return nil
+	}
+	if index := strings.LastIndex(output, ": "); index >= 0 {
+		output = output[index+2:]
+	}
+	return errors.New(output)
+}
+
+// probeSyncStatus determines the health of a sync host folder from the status of its sync daemon. Umount removes
+// the status, so a status without running daemon means that the daemon died.
+func probeSyncStatus(health *MountHealth, status *SyncStatus, isRunning func(pid int) bool, now time.Time) {
+	switch {
+	case status == nil:
+		health.State = HealthNotMounted
+	case status.State == SyncStateFailed:
+		health.State = HealthStale
+		health.Error = status.Error
+	case !isRunning(status.PID):
+		health.State = HealthStale
+		health.Error = "sync daemon is not running"
+	case !status.IsAlive(now):
+		health.State = HealthStale
+		health.Error = fmt.Sprintf("sync daemon missed its heartbeat for %s", now.Sub(status.Heartbeat).Round(time.Second))
+	default:
+		health.State = HealthHealthy
+		health.Latency = status.Lag(now)
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/machine/libmachine/state"
+	"github.com/minishift/minishift/pkg/minikube/tests"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var probedHostFolder = config.HostFolderConfig{
+	Name:    "share",
+	Type:    SSHFS.String(),
+	Options: map[string]string{config.MountPoint: "/mnt/sda1/share"},
+}
+
+func Test_canary_error(t *testing.T) {
+	var testCases = []struct {
+		output        string
+		expectedError string
+	}{
+		{"", ""},
+		{"stat: cannot stat '/mnt/sda1/share/.minishift-canary': No such file or directory\n", ""},
+		{"  File: '/mnt/sda1/share/.minishift-canary'\n  Size: 0\n", ""},
+		{"stat: cannot stat '/mnt/sda1/share/.minishift-canary': Transport endpoint is not connected\n", "Transport endpoint is not connected"},
+		{"stat: cannot stat '/mnt/sda1/share/.minishift-canary': Stale file handle", "Stale file handle"},
+		{"stat: no response within 5s\n", "no response within 5s"},
+	}
+
+	for _, testCase := range testCases {
+		err := canaryError(testCase.output)
+		if testCase.expectedError == "" {
+			assert.NoError(t, err, "Unexpected error for output '%s'", testCase.output)
+		} else {
+			assert.EqualError(t, err, testCase.expectedError)
+		}
+	}
+}
+
+func Test_probe_mount_stats_canary_file(t *testing.T) {
+	var executed string
+	run := func(cmd string) (string, error) {
+		executed = cmd
+		return "stat: cannot stat '/mnt/sda1/share/.minishift-canary': No such file or directory", nil
+	}
+
+	health := &MountHealth{Name: "share"}
+	err := probeMount(health, probedHostFolder, run, time.Second)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "timeout -k 1 1 stat '/mnt/sda1/share/.minishift-canary' 2>&1; [ $? -lt 124 ] || echo 'stat: no response within 1s'", executed)
+	assert.Equal(t, HealthHealthy, health.State)
+	assert.False(t, health.IsStale())
+}
+
+func Test_probe_mount_reports_stat_error_as_stale(t *testing.T) {
+	run := func(cmd string) (string, error) {
+		return "stat: cannot stat '/mnt/sda1/share/.minishift-canary': Transport endpoint is not connected", nil
+	}
+
+	health := &MountHealth{Name: "share"}
+	err := probeMount(health, probedHostFolder, run, time.Second)
+
+	assert.NoError(t, err)
+	assert.True(t, health.IsStale())
+	assert.Equal(t, "Transport endpoint is not connected", health.Error)
+}
+
+func Test_probe_mount_reports_timeout_as_stale(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	run := func(cmd string) (string, error) {
+		<-block
+		return "", nil
+	}
+
+	health := &MountHealth{Name: "share"}
+	err := probeMount(health, probedHostFolder, run, 10*time.Millisecond)
+
+	assert.NoError(t, err)
+	assert.True(t, health.IsStale())
+	assert.Equal(t, "no response within 10ms", health.Error)
+	assert.Equal(t, 10*time.Millisecond, health.Latency)
+}
+
+func Test_probe_mount_returns_ssh_errors(t *testing.T) {
+	run := func(cmd string) (string, error) {
+		return "", errors.New("ssh: handshake failed")
+	}
+
+	health := &MountHealth{Name: "share"}
+	err := probeMount(health, probedHostFolder, run, time.Second)
+
+	assert.EqualError(t, err, "ssh: handshake failed")
+	assert.Equal(t, "", health.State)
+}
+
+func Test_probe_sync_status(t *testing.T) {
+	now := time.Now()
+	pendingSince := now.Add(-2 * time.Second)
+	running := func(pid int) bool { return pid == 42 }
+
+	var testCases = []struct {
+		status        *SyncStatus
+		expectedState string
+		expectedError string
+	}{
+		{nil, HealthNotMounted, ""},
+		{&SyncStatus{PID: 42, State: SyncStateFailed, Heartbeat: now, Error: "connection lost"}, HealthStale, "connection lost"},
+		{&SyncStatus{PID: 7, State: SyncStateWatching, Heartbeat: now}, HealthStale, "sync daemon is not running"},
+		{&SyncStatus{PID: 42, State: SyncStateWatching, Heartbeat: now.Add(-time.Minute)}, HealthStale, "sync daemon missed its heartbeat for 1m0s"},
+		{&SyncStatus{PID: 42, State: SyncStateWatching, Heartbeat: now, PendingSince: &pendingSince}, HealthHealthy, ""},
+	}
+
+	for _, testCase := range testCases {
+		health := &MountHealth{Name: "workspace"}
+		probeSyncStatus(health, testCase.status, running, now)
+		assert.Equal(t, testCase.expectedState, health.State)
+		assert.Equal(t, testCase.expectedError, health.Error)
+	}
+
+	health := &MountHealth{Name: "workspace"}
+	probeSyncStatus(health, testCases[4].status, running, now)
+	assert.Equal(t, 2*time.Second, health.Latency)
+}
+
+func Test_probe_all_reports_probe_errors_as_stale(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-probe-all-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	allInstancesConfig, err := minishiftConfig.NewAllInstancesConfig(filepath.Join(testDir, "allinstances.json"))
+	assert.NoError(t, err)
+	instanceConfig, err := minishiftConfig.NewInstanceConfig(filepath.Join(testDir, "minishift.json"))
+	assert.NoError(t, err)
+	synced := config.HostFolderConfig{Name: "project", Type: SYNC.String(), Options: map[string]string{config.MountPoint: "/mnt/sda1/project"}}
+	allInstancesConfig.HostFolders = []config.HostFolderConfig{probedHostFolder}
+	instanceConfig.HostFolders = []config.HostFolderConfig{synced}
+
+	// the manager looks up host folders in the global configurations
+	origInstanceConfig, origAllInstancesConfig := minishiftConfig.InstanceConfig, minishiftConfig.AllInstancesConfig
+	minishiftConfig.InstanceConfig, minishiftConfig.AllInstancesConfig = instanceConfig, allInstancesConfig
+	defer func() {
+		minishiftConfig.InstanceConfig, minishiftConfig.AllInstancesConfig = origInstanceConfig, origAllInstancesConfig
+	}()
+
+	manager, err := NewManager(instanceConfig, allInstancesConfig)
+	assert.NoError(t, err)
+	// the mount check of the sshfs host folder fails, since the driver cannot provide the SSH host
+	driver := &tests.MockDriver{CurrentState: state.Running, HostError: true}
+	healths, err := manager.ProbeAll(driver, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []*MountHealth{
+		{Name: "share", Type: SSHFS.String(), MountPoint: "/mnt/sda1/share", State: HealthStale, Error: "Error getting host."},
+		{Name: "project", Type: SYNC.String(), MountPoint: "/mnt/sda1/project", State: HealthNotMounted},
+	}, healths)
+
+	_, err = manager.ProbeAll(&tests.MockDriver{CurrentState: state.Stopped}, time.Second)
+	assert.EqualError(t, err, "host is in the wrong state")
+}
@@ -29,15 +29,23 @@ const (
 
 	// CIFS defines the constant to be used for the CIFS host folder type.
 	CIFS
+
+	// NFS defines the constant to be used for the NFS host folder type.
+	NFS
+
+	// SYNC defines the constant to be used for host folders which are copied into the VM and kept in sync.
+	SYNC
 )
 
 func (t Type) String() string {
 	names := [...]string{
 		"sshfs",
-		"cifs"}
+		"cifs",
+		"nfs",
+		"sync"}
 
 	// prevent panicking
-	if t < SSHFS || t > CIFS {
+	if t < SSHFS || t > SYNC {
 		return "unknown"
 	}
 	return names[t]
@@ -34,6 +34,8 @@ type MountInfo struct {
 	Source     string
 	MountPoint string
 	Mounted    bool
+	// SyncStatus is the status of the sync daemon of sync host folders, nil for other types or if it was not started
+	SyncStatus *SyncStatus
 }
 
 // Manager is the central point for all operations around managing hostfolders.
@@ -110,7 +112,7 @@ func (m *Manager) List(driver drivers.Driver) ([]MountInfo, error) {
 		switch hostFolder.Type {
 		case CIFS.String():
 			source = hostFolder.Options[config.UncPath]
-		case SSHFS.String():
+		case SSHFS.String(), NFS.String(), SYNC.String():
 			source = hostFolder.Options[config.Source]
 		}
 
@@ -132,6 +134,13 @@ func (m *Manager) List(driver drivers.Driver) ([]MountInfo, error) {
 			MountPoint: hostFolder.MountPoint(),
 			Mounted:    mounted,
 		}
+		if syncHostFolder, ok := m.hostFolderForConfig(&hostFolder).(*SyncHostFolder); ok {
+			status, err := syncHostFolder.Status()
+			if err != nil {
+				glog.Errorf("Unable to read the sync status of '%s': %v", hostFolder.Name, err)
+			}
+			mount.SyncStatus = status
+		}
 
 		mounts = append(mounts, mount)
 	}
@@ -239,6 +248,11 @@ func (m *Manager) hostFolderForConfig(config *config.HostFolderConfig) HostFolde
 		return NewCifsHostFolder(*config)
 	case SSHFS.String():
 		return NewSSHFSHostFolder(*config, m.allInstancesConfig)
+	case NFS.String():
+		return NewNfsHostFolder(*config, m.allInstancesConfig)
+	case SYNC.String():
+		instanceOnly := m.getHostFolderConfig(config.Name, m.instanceConfig.HostFolders) != nil
+		return NewSyncHostFolder(*config, m.allInstancesConfig, instanceOnly)
 	default:
 		return nil
 	}
@@ -283,6 +297,11 @@ func (m *Manager) removeFromHostFolders(name string, hostfolders []config.HostFo
 }
 
 func (m *Manager) isHostFolderMounted(driver drivers.Driver, hostFolderConfig config.HostFolderConfig) (bool, error) {
+	// sync host folders are not mounted, they are considered mounted while they are kept in sync
+	if syncHostFolder, ok := m.hostFolderForConfig(&hostFolderConfig).(*SyncHostFolder); ok {
+		return syncHostFolder.IsSyncing(), nil
+	}
+
 	cmd := "cat /proc/mounts"
 	procMounts, err := drivers.RunSSHCommandFromDriver(driver, cmd)
 	if err != nil {
@@ -0,0 +1,114 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/docker/machine/libmachine/drivers"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+)
+
+// DefaultWatchInterval is the default interval in which the mount watcher probes the host folders.
+const DefaultWatchInterval = 30 * time.Second
+
+// MountWatcher probes the host folders of an instance and remounts the stale ones. Every probe with a stale result
+// and every remount is logged.
+type MountWatcher struct {
+	Timeout time.Duration
+	Logger  *log.Logger
+
+	// states are the health states of the last check, changes are logged
+	states map[string]string
+}
+
+func NewMountWatcher(timeout time.Duration, logger *log.Logger) *MountWatcher {
+	return &MountWatcher{Timeout: timeout, Logger: logger, states: make(map[string]string)}
+}
+
+// Check probes all host folders once and remounts the stale ones. It returns the number of remounted host folders.
+func (w *MountWatcher) Check(manager *Manager, driver drivers.Driver) int {
+	healths, err := manager.ProbeAll(driver, w.Timeout)
+	if err != nil {
+		w.Logger.Printf("Unable to probe host folders: %v", err)
+		return 0
+	}
+
+	remounted := 0
+	for _, health := range healths {
+		previous := w.states[health.Name]
+		w.states[health.Name] = health.State
+		if !health.IsStale() {
+			if previous != "" && previous != health.State {
+				w.Logger.Printf("Host folder '%s' is %s", health.Name, health.State)
+			}
+			continue
+		}
+
+		w.Logger.Printf("Host folder '%s' mounted at '%s' is stale: %s. Remounting", health.Name, health.MountPoint, health.Error)
+		if err := manager.Remount(driver, health.Name); err != nil {
+			w.Logger.Printf("Remounting host folder '%s' failed: %v", health.Name, err)
+			continue
+		}
+		w.Logger.Printf("Remounted host folder '%s'", health.Name)
+		w.states[health.Name] = HealthHealthy
+		remounted++
+	}
+	return remounted
+}
+
+// EnsureMountWatcherRunning starts the mount watcher daemon of the specified profile, unless it is already running.
+// The PID of the daemon is returned.
+func EnsureMountWatcherRunning(instanceConfig *minishiftConfig.InstanceConfigType, profile string) (int, error) {
+	if isProcessRunning(instanceConfig.HostFolderWatcherPID) {
+		return instanceConfig.HostFolderWatcherPID, nil
+	}
+
+	watcherCmd, err := createDaemonCommand("hostfolder-watcher", "--profile", profile)
+	if err != nil {
+		return 0, err
+	}
+	if err := watcherCmd.Start(); err != nil {
+		return 0, err
+	}
+
+	instanceConfig.HostFolderWatcherPID = watcherCmd.Process.Pid
+	return instanceConfig.HostFolderWatcherPID, instanceConfig.Write()
+}
+
+// StopMountWatcher stops the mount watcher daemon of the instance. The PID of the stopped daemon is returned, 0 if
+// it was not running.
+func StopMountWatcher(instanceConfig *minishiftConfig.InstanceConfigType) (int, error) {
+	pid := instanceConfig.HostFolderWatcherPID
+	if !isProcessRunning(pid) {
+		return 0, nil
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return 0, fmt.Errorf("unable to get the host folder watcher process using PID %d: %s", pid, err)
+	}
+	if err := process.Kill(); err != nil {
+		return 0, err
+	}
+
+	instanceConfig.HostFolderWatcherPID = 0
+	return pid, instanceConfig.Write()
+}
@@ -0,0 +1,145 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfs
+
+// Program numbers and versions of the NFS version 3 (RFC 1813) and MOUNT version 3 protocols.
+const (
+	NfsProgram   = 100003
+	NfsVersion   = 3
+	MountProgram = 100005
+	MountVersion = 3
+
+	// MaxTransferSize is the maximum number of bytes read or written by a single call.
+	MaxTransferSize = 1024 * 1024
+	// MaxHandleSize is the maximum size of a NFS file handle.
+	MaxHandleSize = 64
+	// MaxNameLength is the maximum length of a file name.
+	MaxNameLength = 255
+	// MaxPathLength is the maximum length of a path passed to the MOUNT protocol.
+	MaxPathLength = 1024
+)
+
+// Procedures of the MOUNT protocol.
+const (
+	MountProcNull    = 0
+	MountProcMnt     = 1
+	MountProcDump    = 2
+	MountProcUmnt    = 3
+	MountProcUmntAll = 4
+	MountProcExport  = 5
+)
+
+// MountStat is the status of a MOUNT call.
+type MountStat uint32
+
+const (
+	MountOK          MountStat = 0
+	MountErrNoEnt    MountStat = 2
+	MountErrAcces    MountStat = 13
+	MountErrNotDir   MountStat = 20
+	MountErrInval    MountStat = 22
+	MountErrNotSupp  MountStat = 10004
+	MountErrSrvFault MountStat = 10006
+)
+
+// Procedures of the NFS protocol.
+const (
+	ProcNull        = 0
+	ProcGetAttr     = 1
+	ProcSetAttr     = 2
+	ProcLookup      = 3
+	ProcAccess      = 4
+	ProcReadLink    = 5
+	ProcRead        = 6
+	ProcWrite       = 7
+	ProcCreate      = 8
+	ProcMkdir       = 9
+	ProcSymlink     = 10
+	ProcMknod       = 11
+	ProcRemove      = 12
+	ProcRmdir       = 13
+	ProcRename      = 14
+	ProcLink        = 15
+	ProcReadDir     = 16
+	ProcReadDirPlus = 17
+	ProcFsStat      = 18
+	ProcFsInfo      = 19
+	ProcPathConf    = 20
+	ProcCommit      = 21
+)
+
+// Stat is the status of a NFS call.
+type Stat uint32
+
+const (
+	OK             Stat = 0
+	ErrPerm        Stat = 1
+	ErrNoEnt       Stat = 2
+	ErrIO          Stat = 5
+	ErrAcces       Stat = 13
+	ErrExist       Stat = 17
+	ErrXDev        Stat = 18
+	ErrNotDir      Stat = 20
+	ErrIsDir       Stat = 21
+	ErrInval       Stat = 22
+	ErrFBig        Stat = 27
+	ErrNoSpc       Stat = 28
+	ErrRoFs        Stat = 30
+	ErrNameTooLong Stat = 63
+	ErrNotEmpty    Stat = 66
+	ErrStale       Stat = 70
+	ErrBadHandle   Stat = 10001
+	ErrNotSupp     Stat = 10004
+	ErrServerFault Stat = 10006
+)
+
+// File types of the NFS protocol.
+const (
+	TypeRegular   = 1
+	TypeDirectory = 2
+)
+
+// Access permissions checked by the ACCESS procedure.
+const (
+	AccessRead    = 0x01
+	AccessLookup  = 0x02
+	AccessModify  = 0x04
+	AccessExtend  = 0x08
+	AccessDelete  = 0x10
+	AccessExecute = 0x20
+)
+
+// Stability of writes.
+const (
+	Unstable = 0
+	DataSync = 1
+	FileSync = 2
+)
+
+// Modes of the CREATE procedure.
+const (
+	CreateUnchecked = 0
+	CreateGuarded   = 1
+	CreateExclusive = 2
+)
+
+// Time settings of the SETATTR procedure.
+const (
+	DontChange      = 0
+	SetToServerTime = 1
+	SetToClientTime = 2
+)
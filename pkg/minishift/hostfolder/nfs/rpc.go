@@ -0,0 +1,274 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/golang/glog"
+)
+
+const (
+	rpcVersion = 2
+
+	msgCall  = 0
+	msgReply = 1
+
+	replyAccepted = 0
+	replyDenied   = 1
+
+	rejectRPCMismatch = 0
+
+	authNone = 0
+	authSys  = 1
+
+	// maxRecordSize limits the size of a call, the largest calls are writes of wtmax bytes
+	maxRecordSize = 2*MaxTransferSize + 4096
+	lastFragment  = 1 << 31
+
+	// maxConcurrentCalls is the number of calls of a single connection processed concurrently
+	maxConcurrentCalls = 16
+)
+
+// AcceptStat is the status of an accepted call.
+type AcceptStat uint32
+
+const (
+	Success      AcceptStat = 0
+	ProgUnavail  AcceptStat = 1
+	ProgMismatch AcceptStat = 2
+	ProcUnavail  AcceptStat = 3
+	GarbageArgs  AcceptStat = 4
+	SystemErr    AcceptStat = 5
+)
+
+// Call is a decoded ONC RPC (RFC 5531) call.
+type Call struct {
+	Xid     uint32
+	Program uint32
+	Version uint32
+	Proc    uint32
+	// UID and GID are the credentials of the caller if it uses AUTH_SYS, 0 otherwise
+	UID uint32
+	GID uint32
+}
+
+// Program handles the calls of a RPC program. Handle decodes the arguments, executes the procedure and encodes the
+// result. If the returned status is not Success, the result is discarded.
+type Program interface {
+	// Version returns the single version of the program supported.
+	Version() uint32
+
+	Handle(call *Call, args *Reader, result *Writer) AcceptStat
+}
+
+// Server serves RPC programs over TCP using the record marking standard.
+type Server struct {
+	programs map[uint32]Program
+
+	// Accept decides whether a connection from the specified address is served. All connections are served if nil.
+	Accept func(addr net.Addr) bool
+}
+
+// NewServer creates a server for the specified programs, keyed by their program number.
+func NewServer(programs map[uint32]Program) *Server {
+	return &Server{programs: programs}
+}
+
+// Serve accepts connections until the listener fails. Each connection is served in its own goroutine.
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				glog.Errorf("Failed to accept incoming connection: %v", err)
+				continue
+			}
+			return err
+		}
+
+		if s.Accept != nil && !s.Accept(conn.RemoteAddr()) {
+			glog.Errorf("Rejecting connection from %v", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn serves the calls of a single connection until it is closed. Calls are processed concurrently, the
+// replies are written in the order in which the calls complete.
+func (s *Server) ServeConn(conn io.ReadWriteCloser) {
+	defer conn.Close()
+
+	var writeMutex sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxConcurrentCalls)
+	for {
+		record, err := readRecord(conn)
+		if err != nil {
+			if err != io.EOF {
+				glog.Errorf("Failed to read RPC call: %v", err)
+			}
+			break
+		}
+
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			reply := s.handleRecord(record)
+			if reply == nil {
+				return
+			}
+			writeMutex.Lock()
+			defer writeMutex.Unlock()
+			if err := writeRecord(conn, reply); err != nil {
+				glog.Errorf("Failed to write RPC reply: %v", err)
+				conn.Close()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// handleRecord decodes a call and returns the encoded reply. nil is returned for records which are not calls.
+func (s *Server) handleRecord(record []byte) []byte {
+	args := NewReader(record)
+	call := &Call{Xid: args.Uint32()}
+	if msgType := args.Uint32(); args.Err() != nil || msgType != msgCall {
+		return nil
+	}
+
+	reply := &Writer{}
+	reply.Uint32(call.Xid)
+	reply.Uint32(msgReply)
+
+	if version := args.Uint32(); version != rpcVersion {
+		reply.Uint32(replyDenied)
+		reply.Uint32(rejectRPCMismatch)
+		reply.Uint32(rpcVersion)
+		reply.Uint32(rpcVersion)
+		return reply.Bytes()
+	}
+	call.Program = args.Uint32()
+	call.Version = args.Uint32()
+	call.Proc = args.Uint32()
+	call.UID, call.GID = readCredentials(args)
+	// the verifier is not checked, neither AUTH_NONE nor AUTH_SYS use it
+	args.Uint32()
+	args.Opaque(400)
+
+	reply.Uint32(replyAccepted)
+	reply.Uint32(authNone)
+	reply.Opaque(nil)
+
+	if args.Err() != nil {
+		reply.Uint32(uint32(GarbageArgs))
+		return reply.Bytes()
+	}
+
+	program, ok := s.programs[call.Program]
+	if !ok {
+		reply.Uint32(uint32(ProgUnavail))
+		return reply.Bytes()
+	}
+	if call.Version != program.Version() {
+		reply.Uint32(uint32(ProgMismatch))
+		reply.Uint32(program.Version())
+		reply.Uint32(program.Version())
+		return reply.Bytes()
+	}
+
+	result := &Writer{}
+	status := program.Handle(call, args, result)
+	if status == Success && args.Err() != nil {
+		status = GarbageArgs
+	}
+	reply.Uint32(uint32(status))
+	if status == Success {
+		reply.data = append(reply.data, result.Bytes()...)
+	}
+	return reply.Bytes()
+}
+
+// readCredentials decodes the credentials of a call. Only the UID and GID of AUTH_SYS credentials are used.
+func readCredentials(args *Reader) (uint32, uint32) {
+	flavor := args.Uint32()
+	body := args.Opaque(400)
+	if flavor != authSys || args.Err() != nil {
+		return 0, 0
+	}
+
+	credentials := NewReader(body)
+	credentials.Uint32()    // stamp
+	credentials.String(255) // machine name
+	uid := credentials.Uint32()
+	gid := credentials.Uint32()
+	if credentials.Err() != nil {
+		return 0, 0
+	}
+	return uid, gid
+}
+
+// readRecord reads a record consisting of one or more fragments.
+func readRecord(reader io.Reader) ([]byte, error) {
+	var record []byte
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			if err == io.ErrUnexpectedEOF && len(record) > 0 {
+				return nil, errors.New("connection closed within RPC record")
+			}
+			return nil, err
+		}
+
+		value := binary.BigEndian.Uint32(header[:])
+		length := int(value &^ lastFragment)
+		if len(record)+length > maxRecordSize {
+			return nil, fmt.Errorf("RPC record exceeds the maximum size of %d bytes", maxRecordSize)
+		}
+
+		fragment := make([]byte, length)
+		if _, err := io.ReadFull(reader, fragment); err != nil {
+			return nil, err
+		}
+		record = append(record, fragment...)
+
+		if value&lastFragment != 0 {
+			return record, nil
+		}
+	}
+}
+
+// writeRecord writes a record as a single fragment.
+func writeRecord(writer io.Writer, record []byte) error {
+	data := make([]byte, 4+len(record))
+	binary.BigEndian.PutUint32(data, uint32(len(record))|lastFragment)
+	copy(data[4:], record)
+	_, err := writer.Write(data)
+	return err
+}
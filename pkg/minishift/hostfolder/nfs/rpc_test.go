@@ -0,0 +1,141 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfs
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type echoProgram struct{}
+
+func (p echoProgram) Version() uint32 {
+	return 1
+}
+
+func (p echoProgram) Handle(call *Call, args *Reader, result *Writer) AcceptStat {
+	if call.Proc != 1 {
+		return ProcUnavail
+	}
+	result.Uint32(call.UID)
+	result.Uint32(call.GID)
+	result.String(args.String(16))
+	return Success
+}
+
+func Test_xdr_round_trip(t *testing.T) {
+	writer := &Writer{}
+	writer.Uint32(42)
+	writer.Uint64(1 << 40)
+	writer.Bool(true)
+	writer.Opaque([]byte{1, 2, 3})
+	writer.FixedOpaque([]byte{4, 5})
+	writer.String("hello")
+	assert.Equal(t, 0, len(writer.Bytes())%4, "XDR data must be padded to a multiple of 4 bytes")
+
+	reader := NewReader(writer.Bytes())
+	assert.Equal(t, uint32(42), reader.Uint32())
+	assert.Equal(t, uint64(1<<40), reader.Uint64())
+	assert.True(t, reader.Bool())
+	assert.Equal(t, []byte{1, 2, 3}, reader.Opaque(3))
+	assert.Equal(t, []byte{4, 5}, reader.FixedOpaque(2))
+	assert.Equal(t, "hello", reader.String(5))
+	assert.NoError(t, reader.Err())
+
+	reader.Uint32()
+	assert.Error(t, reader.Err(), "reading beyond the data must fail")
+}
+
+func Test_xdr_rejects_oversized_opaque(t *testing.T) {
+	writer := &Writer{}
+	writer.String("too long")
+
+	reader := NewReader(writer.Bytes())
+	reader.String(4)
+	assert.Error(t, reader.Err())
+}
+
+func Test_record_with_multiple_fragments(t *testing.T) {
+	var buffer bytes.Buffer
+	buffer.Write([]byte{0, 0, 0, 2, 'a', 'b'})
+	buffer.Write([]byte{0x80, 0, 0, 1, 'c'})
+
+	record, err := readRecord(&buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("abc"), record)
+}
+
+func Test_server_dispatches_calls(t *testing.T) {
+	server := NewServer(map[uint32]Program{200000: echoProgram{}})
+	client, conn := net.Pipe()
+	defer client.Close()
+	go server.ServeConn(conn)
+
+	reply := call(t, client, 7, 200000, 1, 1, "ping")
+	assert.Equal(t, uint32(Success), reply.Uint32())
+	assert.Equal(t, uint32(1000), reply.Uint32(), "the uid of the AUTH_SYS credentials is passed to the program")
+	assert.Equal(t, uint32(50), reply.Uint32())
+	assert.Equal(t, "ping", reply.String(16))
+	assert.NoError(t, reply.Err())
+
+	reply = call(t, client, 8, 200000, 1, 9, "")
+	assert.Equal(t, uint32(ProcUnavail), reply.Uint32())
+
+	reply = call(t, client, 9, 200000, 2, 1, "ping")
+	assert.Equal(t, uint32(ProgMismatch), reply.Uint32())
+	assert.Equal(t, uint32(1), reply.Uint32())
+
+	reply = call(t, client, 10, 300000, 1, 1, "ping")
+	assert.Equal(t, uint32(ProgUnavail), reply.Uint32())
+}
+
+// call sends a call with AUTH_SYS credentials and returns the reader positioned at the accept status of the reply.
+func call(t *testing.T, conn net.Conn, xid uint32, program uint32, version uint32, proc uint32, arg string) *Reader {
+	credentials := &Writer{}
+	credentials.Uint32(0)
+	credentials.String("minishift")
+	credentials.Uint32(1000)
+	credentials.Uint32(50)
+	credentials.Uint32(0)
+
+	request := &Writer{}
+	request.Uint32(xid)
+	request.Uint32(msgCall)
+	request.Uint32(rpcVersion)
+	request.Uint32(program)
+	request.Uint32(version)
+	request.Uint32(proc)
+	request.Uint32(authSys)
+	request.Opaque(credentials.Bytes())
+	request.Uint32(authNone)
+	request.Opaque(nil)
+	request.String(arg)
+	assert.NoError(t, writeRecord(conn, request.Bytes()))
+
+	record, err := readRecord(conn)
+	assert.NoError(t, err)
+	reply := NewReader(record)
+	assert.Equal(t, xid, reply.Uint32())
+	assert.Equal(t, uint32(msgReply), reply.Uint32())
+	assert.Equal(t, uint32(replyAccepted), reply.Uint32())
+	reply.Uint32()
+	reply.Opaque(400)
+	return reply
+}
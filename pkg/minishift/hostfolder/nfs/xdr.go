@@ -0,0 +1,159 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// errShortBuffer is returned when the XDR encoded arguments of a call end unexpectedly.
+var errShortBuffer = errors.New("XDR data too short")
+
+// Reader decodes the XDR (RFC 4506) encoded arguments of a call. The first error is kept and returned by Err, all
+// following reads return zero values, so that the arguments can be decoded without checking every single read.
+type Reader struct {
+	data []byte
+	err  error
+}
+
+// NewReader creates a Reader for the specified XDR encoded data.
+func NewReader(data []byte) *Reader {
+	return &Reader{data: data}
+}
+
+// Err returns the first error which occurred while decoding.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+func (r *Reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data) {
+		r.err = errShortBuffer
+		return nil
+	}
+	next := r.data[:n]
+	r.data = r.data[n:]
+	return next
+}
+
+// Uint32 decodes an unsigned integer.
+func (r *Reader) Uint32() uint32 {
+	data := r.next(4)
+	if data == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(data)
+}
+
+// Uint64 decodes an unsigned hyper integer.
+func (r *Reader) Uint64() uint64 {
+	data := r.next(8)
+	if data == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// Bool decodes a boolean.
+func (r *Reader) Bool() bool {
+	return r.Uint32() != 0
+}
+
+// Opaque decodes variable length opaque data of at most max bytes.
+func (r *Reader) Opaque(max int) []byte {
+	length := r.Uint32()
+	if r.err != nil {
+		return nil
+	}
+	if int64(length) > int64(max) {
+		r.err = fmt.Errorf("XDR opaque data of %d bytes exceeds the maximum of %d bytes", length, max)
+		return nil
+	}
+	return r.FixedOpaque(int(length))
+}
+
+// FixedOpaque decodes fixed length opaque data.
+func (r *Reader) FixedOpaque(length int) []byte {
+	data := r.next(length)
+	r.next(padding(length))
+	return data
+}
+
+// String decodes a string of at most max bytes.
+func (r *Reader) String(max int) string {
+	return string(r.Opaque(max))
+}
+
+// Writer encodes XDR data.
+type Writer struct {
+	data []byte
+}
+
+// Bytes returns the encoded data.
+func (w *Writer) Bytes() []byte {
+	return w.data
+}
+
+// Uint32 encodes an unsigned integer.
+func (w *Writer) Uint32(value uint32) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], value)
+	w.data = append(w.data, buf[:]...)
+}
+
+// Uint64 encodes an unsigned hyper integer.
+func (w *Writer) Uint64(value uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], value)
+	w.data = append(w.data, buf[:]...)
+}
+
+// Bool encodes a boolean.
+func (w *Writer) Bool(value bool) {
+	if value {
+		w.Uint32(1)
+	} else {
+		w.Uint32(0)
+	}
+}
+
+// Opaque encodes variable length opaque data.
+func (w *Writer) Opaque(data []byte) {
+	w.Uint32(uint32(len(data)))
+	w.FixedOpaque(data)
+}
+
+// FixedOpaque encodes fixed length opaque data.
+func (w *Writer) FixedOpaque(data []byte) {
+	w.data = append(w.data, data...)
+	w.data = append(w.data, make([]byte, padding(len(data)))...)
+}
+
+// String encodes a string.
+func (w *Writer) String(value string) {
+	w.Opaque([]byte(value))
+}
+
+// padding returns the number of bytes needed to align data of the specified length to four bytes.
+func padding(length int) int {
+	return (4 - length%4) % 4
+}
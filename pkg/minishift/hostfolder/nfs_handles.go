@@ -0,0 +1,156 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/rand"
+	"encoding/binary"
+	"strings"
+	"sync"
+
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/nfs"
+)
+
+const (
+	nfsHandleSize = 16
+
+	// nfsMaxHandles is the number of handles kept by the server. Once exceeded, the least recently used handles are
+	// dropped, clients using them get a stale handle error and look the path up again.
+	nfsMaxHandles = 100000
+)
+
+// nfsHandles maps the file handles of the NFS server to the virtual paths of the exported roots. A handle consists of
+// a random prefix identifying the running server and the id of the path. Handles issued by a previous run of the
+// server are therefore stale. The id of a path is used as its file id as well.
+type nfsHandles struct {
+	mutex  sync.Mutex
+	prefix []byte
+	// entries are the handles by id, their elements are ordered from the most to the least recently used
+	entries map[uint64]*list.Element
+	ids     map[string]uint64
+	lru     *list.List
+	next    uint64
+	max     int
+}
+
+// nfsHandle is a virtual path and its id.
+type nfsHandle struct {
+	id          uint64
+	virtualPath string
+}
+
+func newNfsHandles() *nfsHandles {
+	prefix := make([]byte, 8)
+	rand.Read(prefix)
+	return &nfsHandles{
+		prefix:  prefix,
+		entries: make(map[uint64]*list.Element),
+		ids:     make(map[string]uint64),
+		lru:     list.New(),
+		next:    1,
+		max:     nfsMaxHandles,
+	}
+}
+
+// handle returns the handle and the file id of the specified virtual path.
+func (h *nfsHandles) handle(virtualPath string) ([]byte, uint64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	id, ok := h.ids[virtualPath]
+	if ok {
+		h.lru.MoveToFront(h.entries[id])
+	} else {
+		id = h.next
+		h.next++
+		h.ids[virtualPath] = id
+		h.entries[id] = h.lru.PushFront(&nfsHandle{id: id, virtualPath: virtualPath})
+		for h.lru.Len() > h.max {
+			h.removeElement(h.lru.Back())
+		}
+	}
+
+	handle := make([]byte, nfsHandleSize)
+	copy(handle, h.prefix)
+	binary.BigEndian.PutUint64(handle[8:], id)
+	return handle, id
+}
+
+// path returns the virtual path and the file id of the specified handle.
+func (h *nfsHandles) path(handle []byte) (string, uint64, nfs.Stat) {
+	if len(handle) != nfsHandleSize {
+		return "", 0, nfs.ErrBadHandle
+	}
+	if !bytes.Equal(handle[:8], h.prefix) {
+		return "", 0, nfs.ErrStale
+	}
+
+	id := binary.BigEndian.Uint64(handle[8:])
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	element, ok := h.entries[id]
+	if !ok {
+		return "", 0, nfs.ErrStale
+	}
+	h.lru.MoveToFront(element)
+	return element.Value.(*nfsHandle).virtualPath, id, nfs.OK
+}
+
+// remove drops the handle of the removed path.
+func (h *nfsHandles) remove(virtualPath string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if id, ok := h.ids[virtualPath]; ok {
+		h.removeElement(h.entries[id])
+	}
+}
+
+// rename moves the handles of the renamed path and of all paths below it to the new path, so that the handles
+// held by clients stay valid.
+func (h *nfsHandles) rename(oldPath string, newPath string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// a handle of the target path refers to the replaced file
+	if id, ok := h.ids[newPath]; ok {
+		h.removeElement(h.entries[id])
+	}
+
+	moved := make(map[string]uint64)
+	for virtualPath, id := range h.ids {
+		if virtualPath == oldPath || strings.HasPrefix(virtualPath, oldPath+"/") {
+			moved[virtualPath] = id
+		}
+	}
+	for virtualPath := range moved {
+		delete(h.ids, virtualPath)
+	}
+	for virtualPath, id := range moved {
+		movedPath := newPath + strings.TrimPrefix(virtualPath, oldPath)
+		h.ids[movedPath] = id
+		h.entries[id].Value.(*nfsHandle).virtualPath = movedPath
+	}
+}
+
+func (h *nfsHandles) removeElement(element *list.Element) {
+	entry := h.lru.Remove(element).(*nfsHandle)
+	delete(h.entries, entry.id)
+	delete(h.ids, entry.virtualPath)
+}
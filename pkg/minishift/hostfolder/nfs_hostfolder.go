@@ -0,0 +1,181 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/golang/glog"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/minishift/minishift/pkg/util"
+)
+
+var (
+	NfsPort = 2023
+)
+
+// NfsHostFolder mounts a host folder via NFS version 3. The host folder is served by the nfsd daemon of minishift,
+// which only accepts connections of the VMs registered as its clients.
+type NfsHostFolder struct {
+	config       config.HostFolderConfig
+	globalConfig *minishiftConfig.GlobalConfigType
+}
+
+func NewNfsHostFolder(config config.HostFolderConfig, globalConfig *minishiftConfig.GlobalConfigType) HostFolder {
+	return &NfsHostFolder{config: config, globalConfig: globalConfig}
+}
+
+func (h *NfsHostFolder) Config() config.HostFolderConfig {
+	return h.config
+}
+
+func (h *NfsHostFolder) Mount(driver drivers.Driver) error {
+	vmIP, err := driver.GetIP()
+	if err != nil {
+		return fmt.Errorf("unable to determine the IP of the VM: %s", err)
+	}
+	if err := h.allowClient(vmIP); err != nil {
+		return err
+	}
+
+	ip, err := hostOnlyIP(vmIP)
+	if err != nil {
+		return err
+	}
+	if err := h.ensureNfsdDaemonRunning(ip); err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf("sudo mkdir -p %s", h.config.MountPoint())
+	if _, err := drivers.RunSSHCommandFromDriver(driver, cmd); err != nil {
+		return fmt.Errorf("error occured while creating mountpoint. %s", err)
+	}
+
+	// the daemon serves the mount protocol on the same port and does not implement the lock manager
+	options := fmt.Sprintf("vers=3,proto=tcp,mountproto=tcp,port=%d,mountport=%d,nolock,noacl,hard", NfsPort, NfsPort)
+	if h.config.IsReadOnly() {
+		options = fmt.Sprintf("%s,ro", options)
+	}
+	if minishiftConfig.InstanceStateConfig != nil && minishiftConfig.InstanceStateConfig.IsRHELBased {
+		options = fmt.Sprintf("%s,context=system_u:object_r:svirt_sandbox_file_t:s0", options)
+	}
+	if extraOptions := strings.TrimSpace(h.config.Option(config.ExtraOptions)); extraOptions != "" {
+		options = fmt.Sprintf("%s,%s", options, extraOptions)
+	}
+
+	mount := func() error {
+		cmd := fmt.Sprintf("sudo mount -t nfs -o %s %s:%s %s", options, ip, NfsPath(h.config), h.config.MountPoint())
+		if glog.V(2) {
+			fmt.Println(cmd)
+		}
+
+		if _, err := drivers.RunSSHCommandFromDriver(driver, cmd); err != nil {
+			return fmt.Errorf("error occured while mounting host folder: %s", err)
+		}
+		return nil
+	}
+
+	if err := util.Retry(3, mount); err != nil {
+		errMsg := fmt.Sprintf("\nNote: Make sure that your network and firewall settings on the host allows port %d to be opened and that the VM provides mount.nfs\n\n", NfsPort)
+		return fmt.Errorf("%s%s", errMsg, err)
+	}
+
+	return nil
+}
+
+func (h *NfsHostFolder) Umount(driver drivers.Driver) error {
+	cmd := fmt.Sprintf("sudo umount %s", h.config.MountPoint())
+
+	if _, err := drivers.RunSSHCommandFromDriver(driver, cmd); err != nil {
+		return fmt.Errorf("error during umounting of host folder: %s", err)
+	}
+
+	return nil
+}
+
+// allowClient registers the IP of the VM as client of the nfsd daemon, replacing the previous IP of the VM. The daemon
+// reads its clients for every connection, so the VM can connect to an already running daemon.
+func (h *NfsHostFolder) allowClient(ip string) error {
+	if h.globalConfig.NfsdClients == nil {
+		h.globalConfig.NfsdClients = make(map[string]string)
+	}
+	if h.globalConfig.NfsdClients[constants.ProfileName] == ip {
+		return nil
+	}
+	h.globalConfig.NfsdClients[constants.ProfileName] = ip
+	return h.globalConfig.Write()
+}
+
+// RemoveNfsdClient unregisters the VM of the specified profile as client of the nfsd daemon. It needs to be called when
+// the VM is deleted, since its IP might be leased to another machine afterwards.
+func RemoveNfsdClient(globalConfig *minishiftConfig.GlobalConfigType, profile string) error {
+	if _, ok := globalConfig.NfsdClients[profile]; !ok {
+		return nil
+	}
+	delete(globalConfig.NfsdClients, profile)
+	return globalConfig.Write()
+}
+
+// hostOnlyIP returns the IP of the host interface on the network of the VM, which is the only address the nfsd daemon
+// listens on.
+func hostOnlyIP(vmIP string) (string, error) {
+	ip := net.ParseIP(vmIP)
+	if ip == nil {
+		return "", fmt.Errorf("invalid IP of the VM: '%s'", vmIP)
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", fmt.Errorf("unable to list the network interfaces of the host: %s", err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.Contains(ip) {
+			return ipNet.IP.String(), nil
+		}
+	}
+	return "", fmt.Errorf("unable to find the host interface on the network of the VM with IP %s", vmIP)
+}
+
+func (h *NfsHostFolder) ensureNfsdDaemonRunning(address string) error {
+	if isProcessRunning(h.globalConfig.NfsdPID) {
+		if h.globalConfig.NfsdAddress != address {
+			return fmt.Errorf("the NFS daemon is listening on %s instead of %s. Stop it using 'minishift services stop nfsd' and try again", h.globalConfig.NfsdAddress, address)
+		}
+		if glog.V(2) {
+			fmt.Println(fmt.Sprintf("nfsd running with pid %d", h.globalConfig.NfsdPID))
+		}
+		return nil
+	}
+
+	nfsdCmd, err := createDaemonCommand("nfsd", "--address", address)
+	if err != nil {
+		return err
+	}
+
+	if err := nfsdCmd.Start(); err != nil {
+		return err
+	}
+
+	h.globalConfig.NfsdPID = nfsdCmd.Process.Pid
+	h.globalConfig.NfsdAddress = address
+	return h.globalConfig.Write()
+}
@@ -0,0 +1,58 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_nfsd_clients_are_registered_by_profile(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-nfsd-clients-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	globalConfig, err := minishiftConfig.NewAllInstancesConfig(filepath.Join(testDir, "config.json"))
+	assert.NoError(t, err)
+	hostFolder := &NfsHostFolder{globalConfig: globalConfig}
+
+	assert.NoError(t, hostFolder.allowClient("192.168.99.100"))
+	assert.NoError(t, hostFolder.allowClient("192.168.99.101"))
+	assert.Equal(t, map[string]string{constants.ProfileName: "192.168.99.101"}, globalConfig.NfsdClients, "the previous IP of the VM must be replaced")
+
+	assert.NoError(t, RemoveNfsdClient(globalConfig, constants.ProfileName))
+	assert.Empty(t, globalConfig.NfsdClients)
+
+	persisted, err := minishiftConfig.NewAllInstancesConfig(globalConfig.FilePath)
+	assert.NoError(t, err)
+	assert.Empty(t, persisted.NfsdClients)
+}
+
+func Test_host_only_ip_is_on_the_network_of_the_vm(t *testing.T) {
+	ip, err := hostOnlyIP("127.0.0.2")
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", ip)
+
+	_, err = hostOnlyIP("not-an-ip")
+	assert.Error(t, err)
+}
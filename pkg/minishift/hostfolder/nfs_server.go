@@ -0,0 +1,950 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/nfs"
+)
+
+const (
+	// the size of a directory entry of READDIR without its name and of READDIRPLUS including attributes and handle
+	readDirEntrySize     = 24
+	readDirPlusEntrySize = readDirEntrySize + 88 + 24
+
+	// the free space of the host is not determined, large values are reported instead
+	reportedFsBytes = 1 << 40
+	reportedFsFiles = 1 << 30
+)
+
+// NfsServer serves the NFS host folders via NFS version 3. Like the SFTP file system, each host folder is exported
+// under its name and jailed to its source. Write access to read-only host folders is rejected.
+type NfsServer struct {
+	mutex           sync.RWMutex
+	roots           exportedRoots
+	loadHostFolders func() []config.HostFolderConfig
+
+	handles  *nfsHandles
+	verifier []byte
+}
+
+// NewNfsServer creates a NFS server for the host folders returned by loadHostFolders. The host folders are loaded
+// again on every Reload.
+func NewNfsServer(loadHostFolders func() []config.HostFolderConfig) *NfsServer {
+	verifier := make([]byte, 8)
+	binary.BigEndian.PutUint64(verifier, uint64(time.Now().UnixNano()))
+
+	server := &NfsServer{loadHostFolders: loadHostFolders, handles: newNfsHandles(), verifier: verifier}
+	server.Reload()
+	return server
+}
+
+// NfsPath returns the path under which the specified host folder is exported by the NFS server.
+func NfsPath(hostFolder config.HostFolderConfig) string {
+	return exportedPath(hostFolder)
+}
+
+// Reload loads the exported host folders again.
+func (s *NfsServer) Reload() {
+	roots := newExportedRoots(s.loadHostFolders(), NFS)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.roots = roots
+}
+
+// RPCServer returns a RPC server serving the NFS and MOUNT programs of this server.
+func (s *NfsServer) RPCServer() *nfs.Server {
+	return nfs.NewServer(map[uint32]nfs.Program{
+		nfs.NfsProgram:   nfsProgram{s},
+		nfs.MountProgram: mountProgram{s},
+	})
+}
+
+func (s *NfsServer) currentRoots() exportedRoots {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.roots
+}
+
+// nfsFile is a file or directory referenced by a handle or a directory handle and a name.
+type nfsFile struct {
+	virtualPath string
+	id          uint64
+	root        *exportedRoot
+	hostPath    string
+}
+
+// resolveHandle resolves the file referenced by the specified handle.
+func (s *NfsServer) resolveHandle(handle []byte) (*nfsFile, nfs.Stat) {
+	virtualPath, id, stat := s.handles.path(handle)
+	if stat != nfs.OK {
+		return nil, stat
+	}
+
+	root, hostPath, err := s.currentRoots().resolve(virtualPath)
+	if err != nil || root == nil {
+		return nil, nfs.ErrStale
+	}
+	return &nfsFile{virtualPath: virtualPath, id: id, root: root, hostPath: hostPath}, nfs.OK
+}
+
+// resolveName resolves the entry with the specified name in the directory referenced by the handle.
+func (s *NfsServer) resolveName(dir *nfsFile, name string) (*nfsFile, nfs.Stat) {
+	if name == "" || strings.ContainsAny(name, "/\\\x00") {
+		return nil, nfs.ErrInval
+	}
+	if len(name) > nfs.MaxNameLength {
+		return nil, nfs.ErrNameTooLong
+	}
+
+	virtualPath := path.Join(dir.virtualPath, name)
+	// the parent of the root of a host folder is the root itself, so that the host folder cannot be left
+	if dir.hostPath == dir.root.source && name == ".." {
+		virtualPath = dir.virtualPath
+	}
+
+	root, hostPath, err := s.currentRoots().resolve(virtualPath)
+	if err != nil {
+		return nil, nfsStat(err)
+	}
+	_, id := s.handles.handle(virtualPath)
+	return &nfsFile{virtualPath: virtualPath, id: id, root: root, hostPath: hostPath}, nfs.OK
+}
+
+func (f *nfsFile) handle(s *NfsServer) []byte {
+	handle, _ := s.handles.handle(f.virtualPath)
+	return handle
+}
+
+func (f *nfsFile) stat() (os.FileInfo, error) {
+	return f.root.stat(f.hostPath)
+}
+
+func (f *nfsFile) writable() nfs.Stat {
+	if f.root.readOnly {
+		return nfs.ErrRoFs
+	}
+	return nfs.OK
+}
+
+// nfsProgram implements the NFS version 3 protocol.
+type nfsProgram struct {
+	*NfsServer
+}
+
+func (p nfsProgram) Version() uint32 {
+	return nfs.NfsVersion
+}
+
+func (p nfsProgram) Handle(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) nfs.AcceptStat {
+	switch call.Proc {
+	case nfs.ProcNull:
+	case nfs.ProcGetAttr:
+		p.getAttr(call, args, result)
+	case nfs.ProcSetAttr:
+		p.setAttr(call, args, result)
+	case nfs.ProcLookup:
+		p.lookup(call, args, result)
+	case nfs.ProcAccess:
+		p.access(call, args, result)
+	case nfs.ProcRead:
+		p.read(call, args, result)
+	case nfs.ProcWrite:
+		p.write(call, args, result)
+	case nfs.ProcCreate:
+		p.create(call, args, result)
+	case nfs.ProcMkdir:
+		p.mkdir(call, args, result)
+	case nfs.ProcRemove, nfs.ProcRmdir:
+		p.remove(call, args, result)
+	case nfs.ProcRename:
+		p.rename(call, args, result)
+	case nfs.ProcReadDir, nfs.ProcReadDirPlus:
+		p.readDir(call, args, result)
+	case nfs.ProcFsStat:
+		p.fsStat(call, args, result)
+	case nfs.ProcFsInfo:
+		p.fsInfo(call, args, result)
+	case nfs.ProcPathConf:
+		p.pathConf(call, args, result)
+	case nfs.ProcCommit:
+		p.commit(call, args, result)
+	case nfs.ProcReadLink:
+		// symbolic links are resolved by the server, clients only see their targets
+		result.Uint32(uint32(nfs.ErrNotSupp))
+		result.Bool(false)
+	case nfs.ProcSymlink, nfs.ProcMknod:
+		result.Uint32(uint32(nfs.ErrNotSupp))
+		writeEmptyWcc(result)
+	case nfs.ProcLink:
+		result.Uint32(uint32(nfs.ErrNotSupp))
+		result.Bool(false)
+		writeEmptyWcc(result)
+	default:
+		return nfs.ProcUnavail
+	}
+	return nfs.Success
+}
+
+func (p nfsProgram) getAttr(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	file, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		return
+	}
+
+	info, err := file.stat()
+	if err != nil {
+		if os.IsNotExist(err) {
+			result.Uint32(uint32(nfs.ErrStale))
+		} else {
+			result.Uint32(uint32(nfsStat(err)))
+		}
+		return
+	}
+	result.Uint32(uint32(nfs.OK))
+	writeAttributes(result, call, file, info)
+}
+
+func (p nfsProgram) setAttr(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	file, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	attributes := readSetAttributes(args)
+	// the guard of the ctime is not checked
+	if args.Bool() {
+		args.Uint32()
+		args.Uint32()
+	}
+	if stat == nfs.OK {
+		stat = file.writable()
+	}
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		writeEmptyWcc(result)
+		return
+	}
+
+	result.Uint32(uint32(nfsStat(attributes.apply(file.hostPath))))
+	writeWcc(result, call, file)
+}
+
+func (p nfsProgram) lookup(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	dir, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	name := args.String(nfs.MaxNameLength + 1)
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		result.Bool(false)
+		return
+	}
+
+	file, stat := p.resolveName(dir, name)
+	var info os.FileInfo
+	if stat == nfs.OK {
+		var err error
+		if info, err = file.stat(); err != nil {
+			stat = nfsStat(err)
+		}
+	}
+	result.Uint32(uint32(stat))
+	if stat != nfs.OK {
+		writePostOpAttributes(result, call, dir)
+		return
+	}
+	result.Opaque(file.handle(p.NfsServer))
+	result.Bool(true)
+	writeAttributes(result, call, file, info)
+	writePostOpAttributes(result, call, dir)
+}
+
+func (p nfsProgram) access(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	file, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	requested := args.Uint32()
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		result.Bool(false)
+		return
+	}
+
+	// the actual permissions are checked by the host when the files are accessed
+	granted := requested
+	if file.root.readOnly {
+		granted &^= nfs.AccessModify | nfs.AccessExtend | nfs.AccessDelete
+	}
+	result.Uint32(uint32(nfs.OK))
+	writePostOpAttributes(result, call, file)
+	result.Uint32(granted)
+}
+
+func (p nfsProgram) read(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	file, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	offset := args.Uint64()
+	count := args.Uint32()
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		result.Bool(false)
+		return
+	}
+	if count > nfs.MaxTransferSize {
+		count = nfs.MaxTransferSize
+	}
+
+	data, eof, err := readAt(file.hostPath, int64(offset), int(count))
+	if err != nil {
+		result.Uint32(uint32(nfsStat(err)))
+		writePostOpAttributes(result, call, file)
+		return
+	}
+	result.Uint32(uint32(nfs.OK))
+	writePostOpAttributes(result, call, file)
+	result.Uint32(uint32(len(data)))
+	result.Bool(eof)
+	result.Opaque(data)
+}
+
+func (p nfsProgram) write(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	file, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	offset := args.Uint64()
+	args.Uint32() // count, the length of the data is used instead
+	stable := args.Uint32()
+	data := args.Opaque(nfs.MaxTransferSize)
+	if stat == nfs.OK {
+		stat = file.writable()
+	}
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		writeEmptyWcc(result)
+		return
+	}
+
+	if err := writeAt(file.hostPath, int64(offset), data, stable != nfs.Unstable); err != nil {
+		result.Uint32(uint32(nfsStat(err)))
+		writeWcc(result, call, file)
+		return
+	}
+	if stable != nfs.Unstable {
+		stable = nfs.FileSync
+	}
+	result.Uint32(uint32(nfs.OK))
+	writeWcc(result, call, file)
+	result.Uint32(uint32(len(data)))
+	result.Uint32(stable)
+	result.FixedOpaque(p.verifier)
+}
+
+func (p nfsProgram) create(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	dir, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	name := args.String(nfs.MaxNameLength + 1)
+	mode := args.Uint32()
+	attributes := &setAttributes{}
+	if mode == nfs.CreateExclusive {
+		// the verifier is not stored, exclusive creates fail for existing files like guarded creates
+		args.FixedOpaque(8)
+	} else {
+		attributes = readSetAttributes(args)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE
+	if mode != nfs.CreateUnchecked {
+		flags |= os.O_EXCL
+	}
+	p.createEntry(call, dir, stat, name, result, func(hostPath string) error {
+		permissions := os.FileMode(0644)
+		if attributes.mode != nil {
+			permissions = os.FileMode(*attributes.mode) & os.ModePerm
+		}
+		created, err := os.OpenFile(hostPath, flags, permissions)
+		if err != nil {
+			return err
+		}
+		created.Close()
+		return attributes.apply(hostPath)
+	})
+}
+
+func (p nfsProgram) mkdir(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	dir, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	name := args.String(nfs.MaxNameLength + 1)
+	attributes := readSetAttributes(args)
+
+	p.createEntry(call, dir, stat, name, result, func(hostPath string) error {
+		permissions := os.FileMode(0755)
+		if attributes.mode != nil {
+			permissions = os.FileMode(*attributes.mode) & os.ModePerm
+		}
+		return os.Mkdir(hostPath, permissions)
+	})
+}
+
+// createEntry creates a file or directory with the specified name and writes the result of CREATE or MKDIR.
+func (p nfsProgram) createEntry(call *nfs.Call, dir *nfsFile, stat nfs.Stat, name string, result *nfs.Writer, create func(hostPath string) error) {
+	if stat == nfs.OK {
+		stat = dir.writable()
+	}
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		writeEmptyWcc(result)
+		return
+	}
+
+	file, stat := p.resolveName(dir, name)
+	if stat == nfs.OK && (name == "." || name == "..") {
+		stat = nfs.ErrExist
+	}
+	if stat == nfs.OK {
+		stat = nfsStat(create(file.hostPath))
+	}
+	result.Uint32(uint32(stat))
+	if stat != nfs.OK {
+		writeWcc(result, call, dir)
+		return
+	}
+	result.Bool(true)
+	result.Opaque(file.handle(p.NfsServer))
+	writePostOpAttributes(result, call, file)
+	writeWcc(result, call, dir)
+}
+
+func (p nfsProgram) remove(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	dir, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	name := args.String(nfs.MaxNameLength + 1)
+	if stat == nfs.OK {
+		stat = dir.writable()
+	}
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		writeEmptyWcc(result)
+		return
+	}
+
+	file, stat := p.resolveName(dir, name)
+	if stat == nfs.OK && (name == "." || name == "..") {
+		stat = nfs.ErrInval
+	}
+	if stat == nfs.OK {
+		if stat = removeEntry(file.hostPath, call.Proc == nfs.ProcRmdir); stat == nfs.OK {
+			p.handles.remove(file.virtualPath)
+		}
+	}
+	result.Uint32(uint32(stat))
+	writeWcc(result, call, dir)
+}
+
+func (p nfsProgram) rename(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	fromDir, fromStat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	fromName := args.String(nfs.MaxNameLength + 1)
+	toDir, toStat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	toName := args.String(nfs.MaxNameLength + 1)
+
+	stat := fromStat
+	if stat == nfs.OK {
+		stat = toStat
+	}
+	if stat == nfs.OK {
+		stat = fromDir.writable()
+	}
+	if stat == nfs.OK && toDir.root != fromDir.root {
+		stat = nfs.ErrXDev
+	}
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		writeEmptyWcc(result)
+		writeEmptyWcc(result)
+		return
+	}
+
+	from, stat := p.resolveName(fromDir, fromName)
+	var to *nfsFile
+	if stat == nfs.OK {
+		to, stat = p.resolveName(toDir, toName)
+	}
+	if stat == nfs.OK && (isDotName(fromName) || isDotName(toName)) {
+		stat = nfs.ErrInval
+	}
+	if stat == nfs.OK {
+		if stat = nfsStat(os.Rename(from.hostPath, to.hostPath)); stat == nfs.OK {
+			p.handles.rename(from.virtualPath, to.virtualPath)
+		}
+	}
+	result.Uint32(uint32(stat))
+	writeWcc(result, call, fromDir)
+	writeWcc(result, call, toDir)
+}
+
+func (p nfsProgram) readDir(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	plus := call.Proc == nfs.ProcReadDirPlus
+	dir, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	cookie := args.Uint64()
+	args.FixedOpaque(8) // cookie verifier, the entries are listed in a stable order
+	maxCount := args.Uint32()
+	if plus {
+		// dircount limits the names and cookies only, maxcount the whole reply
+		maxCount = args.Uint32()
+	}
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		result.Bool(false)
+		return
+	}
+
+	entries, err := dir.root.list(dir.hostPath)
+	if err != nil {
+		result.Uint32(uint32(nfsStat(err)))
+		writePostOpAttributes(result, call, dir)
+		return
+	}
+	if cookie > uint64(len(entries)) {
+		result.Uint32(uint32(nfs.ErrInval))
+		writePostOpAttributes(result, call, dir)
+		return
+	}
+
+	result.Uint32(uint32(nfs.OK))
+	writePostOpAttributes(result, call, dir)
+	result.FixedOpaque(make([]byte, 8))
+
+	entrySize := readDirEntrySize
+	if plus {
+		entrySize = readDirPlusEntrySize
+	}
+	// the status, attributes and verifier of the reply take about 128 bytes
+	size := 128
+	index := int(cookie)
+	for ; index < len(entries); index++ {
+		entry := entries[index]
+		size += entrySize + len(entry.Name()) + 3
+		if size > int(maxCount) && index > int(cookie) {
+			break
+		}
+
+		virtualPath := path.Join(dir.virtualPath, entry.Name())
+		handle, id := p.handles.handle(virtualPath)
+		result.Bool(true)
+		result.Uint64(id)
+		result.String(entry.Name())
+		result.Uint64(uint64(index + 1))
+		if plus {
+			result.Bool(true)
+			writeAttributes(result, call, &nfsFile{virtualPath: virtualPath, id: id, root: dir.root}, entry)
+			result.Bool(true)
+			result.Opaque(handle)
+		}
+	}
+	result.Bool(false)
+	result.Bool(index == len(entries))
+}
+
+func (p nfsProgram) fsStat(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	file, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		result.Bool(false)
+		return
+	}
+
+	result.Uint32(uint32(nfs.OK))
+	writePostOpAttributes(result, call, file)
+	for i := 0; i < 3; i++ {
+		result.Uint64(reportedFsBytes)
+	}
+	for i := 0; i < 3; i++ {
+		result.Uint64(reportedFsFiles)
+	}
+	result.Uint32(0)
+}
+
+func (p nfsProgram) fsInfo(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	file, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		result.Bool(false)
+		return
+	}
+
+	result.Uint32(uint32(nfs.OK))
+	writePostOpAttributes(result, call, file)
+	result.Uint32(nfs.MaxTransferSize) // rtmax
+	result.Uint32(nfs.MaxTransferSize) // rtpref
+	result.Uint32(4096)                // rtmult
+	result.Uint32(nfs.MaxTransferSize) // wtmax
+	result.Uint32(nfs.MaxTransferSize) // wtpref
+	result.Uint32(4096)                // wtmult
+	result.Uint32(64 * 1024)           // dtpref
+	result.Uint64(1 << 62)             // maxfilesize
+	result.Uint32(0)                   // time delta
+	result.Uint32(1)
+	result.Uint32(0x0008 | 0x0010) // FSF3_HOMOGENEOUS | FSF3_CANSETTIME
+}
+
+func (p nfsProgram) pathConf(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	file, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		result.Bool(false)
+		return
+	}
+
+	result.Uint32(uint32(nfs.OK))
+	writePostOpAttributes(result, call, file)
+	result.Uint32(1)                 // linkmax
+	result.Uint32(nfs.MaxNameLength) // name_max
+	result.Bool(true)                // no_trunc
+	result.Bool(true)                // chown_restricted
+	result.Bool(runtime.GOOS == "darwin" || runtime.GOOS == "windows")
+	result.Bool(true) // case_preserving
+}
+
+func (p nfsProgram) commit(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) {
+	file, stat := p.resolveHandle(args.Opaque(nfs.MaxHandleSize))
+	args.Uint64() // offset
+	args.Uint32() // count
+	if stat == nfs.OK {
+		stat = file.writable()
+	}
+	if stat != nfs.OK {
+		result.Uint32(uint32(stat))
+		writeEmptyWcc(result)
+		return
+	}
+
+	stat = nfsStat(syncFile(file.hostPath))
+	result.Uint32(uint32(stat))
+	writeWcc(result, call, file)
+	if stat == nfs.OK {
+		result.FixedOpaque(p.verifier)
+	}
+}
+
+// mountProgram implements the MOUNT version 3 protocol, which provides the handles of the exported host folders.
+type mountProgram struct {
+	*NfsServer
+}
+
+func (p mountProgram) Version() uint32 {
+	return nfs.MountVersion
+}
+
+func (p mountProgram) Handle(call *nfs.Call, args *nfs.Reader, result *nfs.Writer) nfs.AcceptStat {
+	switch call.Proc {
+	case nfs.MountProcNull, nfs.MountProcUmntAll:
+	case nfs.MountProcMnt:
+		p.mount(args.String(nfs.MaxPathLength), result)
+	case nfs.MountProcDump:
+		// mounts are not tracked
+		result.Bool(false)
+	case nfs.MountProcUmnt:
+		args.String(nfs.MaxPathLength)
+	case nfs.MountProcExport:
+		for _, info := range p.currentRoots().list() {
+			result.Bool(true)
+			result.String("/" + info.Name())
+			result.Bool(false)
+		}
+		result.Bool(false)
+	default:
+		return nfs.ProcUnavail
+	}
+	return nfs.Success
+}
+
+func (p mountProgram) mount(dirPath string, result *nfs.Writer) {
+	// host folders added since the server was started can be mounted
+	p.Reload()
+
+	root, hostPath, err := p.currentRoots().resolve(dirPath)
+	if err == nil && root == nil {
+		err = os.ErrPermission
+	}
+	var info os.FileInfo
+	if err == nil {
+		info, err = os.Stat(hostPath)
+	}
+	switch {
+	case err == nil && !info.IsDir():
+		result.Uint32(uint32(nfs.MountErrNotDir))
+		return
+	case os.IsNotExist(err):
+		result.Uint32(uint32(nfs.MountErrNoEnt))
+		return
+	case os.IsPermission(err):
+		result.Uint32(uint32(nfs.MountErrAcces))
+		return
+	case err != nil:
+		result.Uint32(uint32(nfs.MountErrSrvFault))
+		return
+	}
+
+	handle, _ := p.handles.handle(path.Clean("/" + dirPath))
+	result.Uint32(uint32(nfs.MountOK))
+	result.Opaque(handle)
+	result.Uint32(1)
+	result.Uint32(1) // AUTH_SYS
+}
+
+// setAttributes are the attributes set by SETATTR, CREATE and MKDIR. The owner of files is not changed, the server
+// runs as the user owning the host folders.
+type setAttributes struct {
+	mode  *uint32
+	size  *uint64
+	atime *time.Time
+	mtime *time.Time
+}
+
+func readSetAttributes(args *nfs.Reader) *setAttributes {
+	attributes := &setAttributes{}
+	if args.Bool() {
+		mode := args.Uint32()
+		attributes.mode = &mode
+	}
+	if args.Bool() {
+		args.Uint32() // uid
+	}
+	if args.Bool() {
+		args.Uint32() // gid
+	}
+	if args.Bool() {
+		size := args.Uint64()
+		attributes.size = &size
+	}
+	attributes.atime = readSetTime(args)
+	attributes.mtime = readSetTime(args)
+	return attributes
+}
+
+func readSetTime(args *nfs.Reader) *time.Time {
+	switch args.Uint32() {
+	case nfs.SetToServerTime:
+		now := time.Now()
+		return &now
+	case nfs.SetToClientTime:
+		seconds := args.Uint32()
+		nanoSeconds := args.Uint32()
+		clientTime := time.Unix(int64(seconds), int64(nanoSeconds))
+		return &clientTime
+	}
+	return nil
+}
+
+func (a *setAttributes) apply(hostPath string) error {
+	if a.size != nil {
+		if err := os.Truncate(hostPath, int64(*a.size)); err != nil {
+			return err
+		}
+	}
+	if a.mode != nil {
+		if err := os.Chmod(hostPath, os.FileMode(*a.mode)&os.ModePerm); err != nil {
+			return err
+		}
+	}
+	if a.atime != nil || a.mtime != nil {
+		info, err := os.Stat(hostPath)
+		if err != nil {
+			return err
+		}
+		// the access time is not available in a portable way, the modification time is used instead
+		atime, mtime := info.ModTime(), info.ModTime()
+		if a.atime != nil {
+			atime = *a.atime
+		}
+		if a.mtime != nil {
+			mtime = *a.mtime
+		}
+		if err := os.Chtimes(hostPath, atime, mtime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeAttributes encodes the fattr3 of a file. Files are reported as owned by the caller.
+func writeAttributes(result *nfs.Writer, call *nfs.Call, file *nfsFile, info os.FileInfo) {
+	if info.IsDir() {
+		result.Uint32(nfs.TypeDirectory)
+	} else {
+		result.Uint32(nfs.TypeRegular)
+	}
+	result.Uint32(uint32(info.Mode().Perm()))
+	if info.IsDir() {
+		result.Uint32(2)
+	} else {
+		result.Uint32(1)
+	}
+	result.Uint32(call.UID)
+	result.Uint32(call.GID)
+	result.Uint64(uint64(info.Size()))
+	result.Uint64(uint64(info.Size()))
+	result.Uint32(0) // rdev
+	result.Uint32(0)
+	result.Uint64(fsid(file.root))
+	result.Uint64(file.id)
+	modTime := info.ModTime()
+	for i := 0; i < 3; i++ {
+		result.Uint32(uint32(modTime.Unix()))
+		result.Uint32(uint32(modTime.Nanosecond()))
+	}
+}
+
+// writePostOpAttributes encodes the post_op_attr of a file, the attributes are omitted if the file cannot be read.
+func writePostOpAttributes(result *nfs.Writer, call *nfs.Call, file *nfsFile) {
+	info, err := file.stat()
+	if err != nil {
+		result.Bool(false)
+		return
+	}
+	result.Bool(true)
+	writeAttributes(result, call, file, info)
+}
+
+// writeWcc encodes the wcc_data of a file. The attributes before the operation are not reported.
+func writeWcc(result *nfs.Writer, call *nfs.Call, file *nfsFile) {
+	result.Bool(false)
+	writePostOpAttributes(result, call, file)
+}
+
+func writeEmptyWcc(result *nfs.Writer) {
+	result.Bool(false)
+	result.Bool(false)
+}
+
+// fsid returns a file system id per host folder, so that clients do not share caches between host folders.
+func fsid(root *exportedRoot) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(root.name))
+	return hash.Sum64()
+}
+
+func isDotName(name string) bool {
+	return name == "." || name == ".."
+}
+
+func readAt(hostPath string, offset int64, count int) ([]byte, bool, error) {
+	file, err := os.Open(hostPath)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	data := make([]byte, count)
+	n, err := file.ReadAt(data, offset)
+	if err == io.EOF {
+		return data[:n], true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	return data[:n], offset+int64(n) >= info.Size(), nil
+}
+
+func writeAt(hostPath string, offset int64, data []byte, sync bool) error {
+	file, err := os.OpenFile(hostPath, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.WriteAt(data, offset); err != nil {
+		file.Close()
+		return err
+	}
+	if sync {
+		if err := file.Sync(); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
+
+func syncFile(hostPath string) error {
+	file, err := os.OpenFile(hostPath, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
+
+func removeEntry(hostPath string, directory bool) nfs.Stat {
+	info, err := os.Stat(hostPath)
+	if err != nil {
+		return nfsStat(err)
+	}
+	if directory && !info.IsDir() {
+		return nfs.ErrNotDir
+	}
+	if !directory && info.IsDir() {
+		return nfs.ErrIsDir
+	}
+	return nfsStat(os.Remove(hostPath))
+}
+
+// nfsStat maps errors of the exported roots and of file operations to NFS status codes.
+func nfsStat(err error) nfs.Stat {
+	switch {
+	case err == nil:
+		return nfs.OK
+	case err == errReadOnly:
+		return nfs.ErrRoFs
+	case os.IsNotExist(err):
+		return nfs.ErrNoEnt
+	case os.IsExist(err):
+		return nfs.ErrExist
+	case os.IsPermission(err):
+		return nfs.ErrAcces
+	}
+
+	switch e := err.(type) {
+	case *os.PathError:
+		err = e.Err
+	case *os.LinkError:
+		err = e.Err
+	case *os.SyscallError:
+		err = e.Err
+	}
+	switch err {
+	case syscall.ENOTEMPTY:
+		return nfs.ErrNotEmpty
+	case syscall.ENOTDIR:
+		return nfs.ErrNotDir
+	case syscall.EISDIR:
+		return nfs.ErrIsDir
+	case syscall.ENOSPC:
+		return nfs.ErrNoSpc
+	case syscall.ENAMETOOLONG:
+		return nfs.ErrNameTooLong
+	case syscall.EXDEV:
+		return nfs.ErrXDev
+	case syscall.EINVAL:
+		return nfs.ErrInval
+	case syscall.EFBIG:
+		return nfs.ErrFBig
+	}
+	return nfs.ErrIO
+}
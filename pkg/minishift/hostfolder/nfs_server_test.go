@@ -0,0 +1,312 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/nfs"
+	"github.com/stretchr/testify/assert"
+)
+
+// attributesSize is the size of an encoded fattr3
+const attributesSize = 84
+
+func nfsHostFolder(name string, source string, readOnly bool) config.HostFolderConfig {
+	hostFolder := sshfsHostFolder(name, source, readOnly)
+	hostFolder.Type = NFS.String()
+	return hostFolder
+}
+
+func setupNfsServer(t *testing.T, hostFolders func(testDir string) []config.HostFolderConfig) (*NfsServer, string, func()) {
+	testDir, err := ioutil.TempDir("", "minishift-test-nfs-")
+	assert.NoError(t, err)
+	assert.NoError(t, os.MkdirAll(filepath.Join(testDir, "source"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(testDir, "source", "file"), []byte("content"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(testDir, "outside"), []byte("outside"), 0644))
+
+	configs := hostFolders(testDir)
+	server := NewNfsServer(func() []config.HostFolderConfig { return configs })
+	return server, testDir, func() { os.RemoveAll(testDir) }
+}
+
+func callProgram(program nfs.Program, proc uint32, args func(*nfs.Writer)) *nfs.Reader {
+	request := &nfs.Writer{}
+	if args != nil {
+		args(request)
+	}
+	result := &nfs.Writer{}
+	program.Handle(&nfs.Call{Proc: proc, UID: 1000, GID: 50}, nfs.NewReader(request.Bytes()), result)
+	return nfs.NewReader(result.Bytes())
+}
+
+func mountNfs(server *NfsServer, dirPath string) (nfs.MountStat, []byte) {
+	reply := callProgram(mountProgram{server}, nfs.MountProcMnt, func(w *nfs.Writer) { w.String(dirPath) })
+	stat := nfs.MountStat(reply.Uint32())
+	if stat != nfs.MountOK {
+		return stat, nil
+	}
+	return stat, reply.Opaque(nfs.MaxHandleSize)
+}
+
+func lookupNfs(server *NfsServer, dir []byte, name string) (nfs.Stat, []byte) {
+	reply := callProgram(nfsProgram{server}, nfs.ProcLookup, func(w *nfs.Writer) {
+		w.Opaque(dir)
+		w.String(name)
+	})
+	stat := nfs.Stat(reply.Uint32())
+	if stat != nfs.OK {
+		return stat, nil
+	}
+	return stat, reply.Opaque(nfs.MaxHandleSize)
+}
+
+func readNfs(server *NfsServer, file []byte) (nfs.Stat, string) {
+	reply := callProgram(nfsProgram{server}, nfs.ProcRead, func(w *nfs.Writer) {
+		w.Opaque(file)
+		w.Uint64(0)
+		w.Uint32(1024)
+	})
+	stat := nfs.Stat(reply.Uint32())
+	if stat != nfs.OK {
+		return stat, ""
+	}
+	if reply.Bool() {
+		reply.FixedOpaque(attributesSize)
+	}
+	reply.Uint32() // count
+	reply.Bool()   // eof
+	return stat, string(reply.Opaque(1024))
+}
+
+func writeNfs(server *NfsServer, file []byte, data string) nfs.Stat {
+	reply := callProgram(nfsProgram{server}, nfs.ProcWrite, func(w *nfs.Writer) {
+		w.Opaque(file)
+		w.Uint64(0)
+		w.Uint32(uint32(len(data)))
+		w.Uint32(nfs.FileSync)
+		w.Opaque([]byte(data))
+	})
+	return nfs.Stat(reply.Uint32())
+}
+
+func Test_nfs_server_exports_only_nfs_host_folders(t *testing.T) {
+	server, _, teardown := setupNfsServer(t, func(testDir string) []config.HostFolderConfig {
+		return []config.HostFolderConfig{
+			nfsHostFolder("data", filepath.Join(testDir, "source"), false),
+			sshfsHostFolder("other", filepath.Join(testDir, "source"), false),
+		}
+	})
+	defer teardown()
+
+	stat, handle := mountNfs(server, "/data")
+	assert.Equal(t, nfs.MountOK, stat)
+	assert.NotEmpty(t, handle)
+
+	stat, _ = mountNfs(server, "/other")
+	assert.Equal(t, nfs.MountErrNoEnt, stat, "sshfs host folders must not be exported via NFS")
+
+	stat, _ = mountNfs(server, "/")
+	assert.Equal(t, nfs.MountErrAcces, stat, "the virtual root must not be mountable")
+
+	stat, _ = mountNfs(server, "/data/file")
+	assert.Equal(t, nfs.MountErrNotDir, stat)
+}
+
+func Test_nfs_server_lookup_and_read(t *testing.T) {
+	server, testDir, teardown := setupNfsServer(t, func(testDir string) []config.HostFolderConfig {
+		return []config.HostFolderConfig{nfsHostFolder("data", filepath.Join(testDir, "source"), false)}
+	})
+	defer teardown()
+
+	_, root := mountNfs(server, "/data")
+	stat, file := lookupNfs(server, root, "file")
+	assert.Equal(t, nfs.OK, stat)
+
+	stat, content := readNfs(server, file)
+	assert.Equal(t, nfs.OK, stat)
+	assert.Equal(t, "content", content)
+
+	stat, parent := lookupNfs(server, root, "..")
+	assert.Equal(t, nfs.OK, stat)
+	assert.Equal(t, root, parent, "the parent of a host folder must be the host folder itself")
+
+	stat, _ = lookupNfs(server, root, "missing")
+	assert.Equal(t, nfs.ErrNoEnt, stat)
+
+	stat, _ = lookupNfs(server, root, "../outside")
+	assert.Equal(t, nfs.ErrInval, stat, "names must not contain path separators")
+
+	if runtime.GOOS != "windows" {
+		assert.NoError(t, os.Symlink(filepath.Join(testDir, "outside"), filepath.Join(testDir, "source", "escape")))
+		stat, _ = lookupNfs(server, root, "escape")
+		assert.NotEqual(t, nfs.OK, stat, "symlinks leaving the host folder must not be followed")
+	}
+
+	stat, _ = readNfs(server, []byte("not a handle"))
+	assert.Equal(t, nfs.ErrBadHandle, stat)
+}
+
+func Test_nfs_server_write_access(t *testing.T) {
+	server, testDir, teardown := setupNfsServer(t, func(testDir string) []config.HostFolderConfig {
+		return []config.HostFolderConfig{
+			nfsHostFolder("rw", filepath.Join(testDir, "source"), false),
+			nfsHostFolder("ro", filepath.Join(testDir, "source"), true),
+		}
+	})
+	defer teardown()
+
+	_, readOnlyRoot := mountNfs(server, "/ro")
+	_, readOnlyFile := lookupNfs(server, readOnlyRoot, "file")
+	assert.Equal(t, nfs.ErrRoFs, writeNfs(server, readOnlyFile, "changed"))
+
+	_, root := mountNfs(server, "/rw")
+	_, file := lookupNfs(server, root, "file")
+	assert.Equal(t, nfs.OK, writeNfs(server, file, "changed"))
+	content, err := ioutil.ReadFile(filepath.Join(testDir, "source", "file"))
+	assert.NoError(t, err)
+	assert.Equal(t, "changed", string(content))
+
+	reply := callProgram(nfsProgram{server}, nfs.ProcMkdir, func(w *nfs.Writer) {
+		w.Opaque(readOnlyRoot)
+		w.String("dir")
+		w.Bool(false)
+		w.Bool(false)
+		w.Bool(false)
+		w.Bool(false)
+		w.Uint32(nfs.DontChange)
+		w.Uint32(nfs.DontChange)
+	})
+	assert.Equal(t, nfs.ErrRoFs, nfs.Stat(reply.Uint32()))
+	_, err = os.Stat(filepath.Join(testDir, "source", "dir"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_nfs_server_handles_survive_rename(t *testing.T) {
+	server, testDir, teardown := setupNfsServer(t, func(testDir string) []config.HostFolderConfig {
+		return []config.HostFolderConfig{nfsHostFolder("data", filepath.Join(testDir, "source"), false)}
+	})
+	defer teardown()
+
+	_, root := mountNfs(server, "/data")
+	_, file := lookupNfs(server, root, "file")
+
+	reply := callProgram(nfsProgram{server}, nfs.ProcRename, func(w *nfs.Writer) {
+		w.Opaque(root)
+		w.String("file")
+		w.Opaque(root)
+		w.String("renamed")
+	})
+	assert.Equal(t, nfs.OK, nfs.Stat(reply.Uint32()))
+	_, err := os.Stat(filepath.Join(testDir, "source", "renamed"))
+	assert.NoError(t, err)
+
+	stat, content := readNfs(server, file)
+	assert.Equal(t, nfs.OK, stat, "the handle must reference the renamed file")
+	assert.Equal(t, "content", content)
+
+	stat, _ = lookupNfs(server, root, "file")
+	assert.Equal(t, nfs.ErrNoEnt, stat)
+}
+
+func Test_nfs_server_read_dir_continues_at_cookie(t *testing.T) {
+	server, testDir, teardown := setupNfsServer(t, func(testDir string) []config.HostFolderConfig {
+		return []config.HostFolderConfig{nfsHostFolder("data", filepath.Join(testDir, "source"), false)}
+	})
+	defer teardown()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(testDir, "source", "second"), []byte{}, 0644))
+
+	_, root := mountNfs(server, "/data")
+	readDir := func(cookie uint64) ([]string, uint64, bool) {
+		reply := callProgram(nfsProgram{server}, nfs.ProcReadDir, func(w *nfs.Writer) {
+			w.Opaque(root)
+			w.Uint64(cookie)
+			w.FixedOpaque(make([]byte, 8))
+			w.Uint32(1) // only a single entry fits
+		})
+		assert.Equal(t, nfs.OK, nfs.Stat(reply.Uint32()))
+		if reply.Bool() {
+			reply.FixedOpaque(attributesSize)
+		}
+		reply.FixedOpaque(8)
+
+		var names []string
+		for reply.Bool() {
+			reply.Uint64()
+			names = append(names, reply.String(nfs.MaxNameLength))
+			cookie = reply.Uint64()
+		}
+		eof := reply.Bool()
+		assert.NoError(t, reply.Err())
+		return names, cookie, eof
+	}
+
+	names, cookie, eof := readDir(0)
+	assert.Equal(t, []string{"file"}, names)
+	assert.False(t, eof)
+
+	names, _, eof = readDir(cookie)
+	assert.Equal(t, []string{"second"}, names)
+	assert.True(t, eof)
+}
+
+func Test_nfs_server_drops_handles_of_removed_files(t *testing.T) {
+	server, _, teardown := setupNfsServer(t, func(testDir string) []config.HostFolderConfig {
+		return []config.HostFolderConfig{nfsHostFolder("data", filepath.Join(testDir, "source"), false)}
+	})
+	defer teardown()
+
+	_, root := mountNfs(server, "/data")
+	_, file := lookupNfs(server, root, "file")
+
+	reply := callProgram(nfsProgram{server}, nfs.ProcRemove, func(w *nfs.Writer) {
+		w.Opaque(root)
+		w.String("file")
+	})
+	assert.Equal(t, nfs.OK, nfs.Stat(reply.Uint32()))
+
+	stat, _ := readNfs(server, file)
+	assert.Equal(t, nfs.ErrStale, stat)
+	assert.Equal(t, 1, server.handles.lru.Len(), "only the handle of the root should be left")
+}
+
+func Test_nfs_handles_evict_least_recently_used(t *testing.T) {
+	handles := newNfsHandles()
+	handles.max = 2
+
+	first, _ := handles.handle("/data/first")
+	second, _ := handles.handle("/data/second")
+	_, _, stat := handles.path(first)
+	assert.Equal(t, nfs.OK, stat)
+
+	handles.handle("/data/third")
+	assert.Equal(t, 2, handles.lru.Len())
+
+	virtualPath, _, stat := handles.path(first)
+	assert.Equal(t, nfs.OK, stat)
+	assert.Equal(t, "/data/first", virtualPath)
+	_, _, stat = handles.path(second)
+	assert.Equal(t, nfs.ErrStale, stat)
+
+	again, _ := handles.handle("/data/second")
+	assert.NotEqual(t, second, again, "an evicted path gets a new handle")
+}
@@ -0,0 +1,220 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/pkg/sftp"
+)
+
+// SftpFileSystem is the virtual file system served by the sftpd daemon. Each SSHFS host folder is exposed as a top
+// level directory named after the host folder, which is jailed to the source of the host folder. Write access to
+// host folders with the read-only option is rejected.
+type SftpFileSystem struct {
+	roots   exportedRoots
+	created time.Time
+}
+
+// NewSftpFileSystem creates a SFTP file system exposing the SSHFS host folders of the specified host folder configs.
+func NewSftpFileSystem(hostFolders []config.HostFolderConfig) *SftpFileSystem {
+	return &SftpFileSystem{roots: newExportedRoots(hostFolders, SSHFS), created: time.Now()}
+}
+
+// SftpPath returns the path under which the specified host folder is served by the SFTP file system.
+func SftpPath(hostFolder config.HostFolderConfig) string {
+	return exportedPath(hostFolder)
+}
+
+// Handlers returns the handlers for a sftp.RequestServer serving this file system.
+func (fs *SftpFileSystem) Handlers() sftp.Handlers {
+	return sftp.Handlers{FileGet: fs, FilePut: fs, FileCmd: fs, FileList: fs}
+}
+
+func (fs *SftpFileSystem) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	root, hostPath, err := fs.roots.resolve(r.Filepath)
+	if err != nil {
+		return nil, sftpError(err)
+	}
+	if root == nil {
+		return nil, sftp.ErrSshFxPermissionDenied
+	}
+	return os.Open(hostPath)
+}
+
+func (fs *SftpFileSystem) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	root, hostPath, err := fs.roots.resolveWritable(r.Filepath)
+	if err != nil {
+		return nil, sftpError(err)
+	}
+	if hostPath == root.source {
+		return nil, sftp.ErrSshFxPermissionDenied
+	}
+
+	// O_APPEND is not passed on, since the writes of the SFTP protocol are always done at an explicit offset
+	flags := r.Pflags()
+	osFlags := os.O_WRONLY
+	if flags.Read {
+		osFlags = os.O_RDWR
+	}
+	if flags.Creat {
+		osFlags |= os.O_CREATE
+	}
+	if flags.Trunc {
+		osFlags |= os.O_TRUNC
+	}
+	if flags.Excl {
+		osFlags |= os.O_EXCL
+	}
+	return os.OpenFile(hostPath, osFlags, 0644)
+}
+
+func (fs *SftpFileSystem) Filecmd(r *sftp.Request) error {
+	if r.Method == "Symlink" {
+		return sftp.ErrSshFxOpUnsupported
+	}
+
+	root, hostPath, err := fs.roots.resolveWritable(r.Filepath)
+	if err != nil {
+		return sftpError(err)
+	}
+	// the root of a host folder itself cannot be removed or renamed
+	if hostPath == root.source && r.Method != "Setstat" {
+		return sftp.ErrSshFxPermissionDenied
+	}
+
+	switch r.Method {
+	case "Setstat":
+		return setStat(hostPath, r)
+	case "Rename":
+		targetRoot, targetPath, err := fs.roots.resolveWritable(r.Target)
+		if err != nil {
+			return sftpError(err)
+		}
+		if targetRoot != root || targetPath == targetRoot.source {
+			return sftp.ErrSshFxPermissionDenied
+		}
+		return os.Rename(hostPath, targetPath)
+	case "Rmdir", "Remove":
+		return os.Remove(hostPath)
+	case "Mkdir":
+		return os.Mkdir(hostPath, 0755)
+	}
+	return sftp.ErrSshFxOpUnsupported
+}
+
+func (fs *SftpFileSystem) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	if r.Method == "Readlink" {
+		// symbolic links are resolved by the server, clients only see their targets
+		return nil, sftp.ErrSshFxOpUnsupported
+	}
+
+	root, hostPath, err := fs.roots.resolve(r.Filepath)
+	if err != nil {
+		return nil, sftpError(err)
+	}
+
+	switch r.Method {
+	case "Stat":
+		if root == nil {
+			return listerAt{virtualDirInfo{name: "/", modTime: fs.created}}, nil
+		}
+		info, err := root.stat(hostPath)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt{info}, nil
+	case "List":
+		if root == nil {
+			return listerAt(fs.roots.list()), nil
+		}
+		infos, err := root.list(hostPath)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt(infos), nil
+	}
+	return nil, sftp.ErrSshFxOpUnsupported
+}
+
+// sftpError maps the errors of the exported roots to the status codes of the SFTP protocol.
+func sftpError(err error) error {
+	switch err {
+	case os.ErrNotExist:
+		return sftp.ErrSshFxNoSuchFile
+	case os.ErrPermission, errReadOnly:
+		return sftp.ErrSshFxPermissionDenied
+	}
+	return err
+}
+
+func setStat(hostPath string, r *sftp.Request) error {
+	// for requests on an open handle the attributes are not passed on by the request server
+	if len(r.Attrs) == 0 {
+		return sftp.ErrSshFxOpUnsupported
+	}
+
+	flags := r.AttrFlags(r.Flags)
+	attrs := r.Attributes()
+	if flags.Size {
+		if err := os.Truncate(hostPath, int64(attrs.Size)); err != nil {
+			return err
+		}
+	}
+	if flags.Permissions {
+		if err := os.Chmod(hostPath, attrs.FileMode()&os.ModePerm); err != nil {
+			return err
+		}
+	}
+	if flags.Acmodtime {
+		if err := os.Chtimes(hostPath, time.Unix(int64(attrs.Atime), 0), time.Unix(int64(attrs.Mtime), 0)); err != nil {
+			return err
+		}
+	}
+	// the owner of files is not changed, the daemon runs as the user owning the host folders
+	return nil
+}
+
+// listerAt implements sftp.ListerAt for a fixed list of file infos.
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(infos []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(infos, l[offset:])
+	if n < len(infos) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// virtualDirInfo is the file info of the virtual root directory containing the host folders.
+type virtualDirInfo struct {
+	name    string
+	modTime time.Time
+}
+
+func (info virtualDirInfo) Name() string       { return info.name }
+func (info virtualDirInfo) Size() int64        { return 0 }
+func (info virtualDirInfo) Mode() os.FileMode  { return os.ModeDir | 0555 }
+func (info virtualDirInfo) ModTime() time.Time { return info.modTime }
+func (info virtualDirInfo) IsDir() bool        { return true }
+func (info virtualDirInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,214 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+)
+
+type pipeConn struct {
+	io.Reader
+	io.WriteCloser
+}
+
+func setupSftpClient(t *testing.T, hostFolders []config.HostFolderConfig) (*sftp.Client, func()) {
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+
+	server := sftp.NewRequestServer(pipeConn{serverReader, serverWriter}, NewSftpFileSystem(hostFolders).Handlers())
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientReader, clientWriter)
+	assert.NoError(t, err)
+	return client, func() {
+		server.Close()
+		client.Close()
+	}
+}
+
+func sshfsHostFolder(name string, source string, readOnly bool) config.HostFolderConfig {
+	options := map[string]string{config.Source: source, config.MountPoint: "/mnt/sda1/" + name}
+	if readOnly {
+		options[config.ReadOnly] = "true"
+	}
+	return config.HostFolderConfig{Name: name, Type: SSHFS.String(), Options: options}
+}
+
+func Test_sftp_file_system_exposes_only_host_folders(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-sftp-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	source := filepath.Join(testDir, "source")
+	assert.NoError(t, os.MkdirAll(source, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(source, "inside"), []byte("inside"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(testDir, "outside"), []byte("outside"), 0644))
+
+	cifs := config.HostFolderConfig{Name: "cifs", Type: CIFS.String(), Options: map[string]string{config.UncPath: "//127.0.0.1/share"}}
+	client, teardown := setupSftpClient(t, []config.HostFolderConfig{sshfsHostFolder("share", source, false), cifs})
+	defer teardown()
+
+	entries, err := client.ReadDir("/")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "share", entries[0].Name())
+	assert.True(t, entries[0].IsDir())
+
+	file, err := client.Open("/share/inside")
+	assert.NoError(t, err)
+	content, err := ioutil.ReadAll(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "inside", string(content))
+	file.Close()
+
+	// paths are cleaned, so that parent references stay within the host folder
+	_, err = client.Stat("/share/../../outside")
+	assert.Error(t, err)
+	_, err = client.Stat(filepath.ToSlash(filepath.Join(testDir, "outside")))
+	assert.Error(t, err)
+	_, err = client.Stat("/cifs")
+	assert.Error(t, err)
+
+	_, err = client.Create("/new-root-entry")
+	assert.Error(t, err)
+}
+
+func Test_sftp_file_system_rejects_symlinks_leaving_host_folder(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-sftp-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	source := filepath.Join(testDir, "source")
+	assert.NoError(t, os.MkdirAll(source, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(source, "inside"), []byte("inside"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(testDir, "outside"), []byte("outside"), 0644))
+	if err := os.Symlink(filepath.Join(testDir, "outside"), filepath.Join(source, "escape")); err != nil {
+		t.Skipf("Symbolic links are not supported: %v", err)
+	}
+	assert.NoError(t, os.Symlink(filepath.Join(source, "inside"), filepath.Join(source, "link")))
+
+	client, teardown := setupSftpClient(t, []config.HostFolderConfig{sshfsHostFolder("share", source, false)})
+	defer teardown()
+
+	// files are only opened by the request server on the first read
+	file, err := client.Open("/share/escape")
+	assert.NoError(t, err)
+	_, err = ioutil.ReadAll(file)
+	assert.Error(t, err)
+	file.Close()
+	_, err = client.Create("/share/escape")
+	assert.Error(t, err)
+
+	entries, err := client.ReadDir("/share")
+	assert.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+		assert.True(t, entry.Mode().IsRegular())
+	}
+	assert.Equal(t, []string{"inside", "link"}, names)
+
+	content, err := ioutil.ReadFile(filepath.Join(testDir, "outside"))
+	assert.NoError(t, err)
+	assert.Equal(t, "outside", string(content))
+}
+
+func Test_sftp_file_system_rejects_dangling_symlinks_leaving_host_folder(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-sftp-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	source := filepath.Join(testDir, "source")
+	assert.NoError(t, os.MkdirAll(source, 0755))
+	if err := os.Symlink(filepath.Join("..", "outside.txt"), filepath.Join(source, "link")); err != nil {
+		t.Skipf("Symbolic links are not supported: %v", err)
+	}
+	assert.NoError(t, os.Symlink(filepath.Join("..", "outside"), filepath.Join(source, "dir-link")))
+	assert.NoError(t, os.Symlink("created.txt", filepath.Join(source, "inside-link")))
+
+	client, teardown := setupSftpClient(t, []config.HostFolderConfig{sshfsHostFolder("share", source, false)})
+	defer teardown()
+
+	_, err = client.Create("/share/link")
+	assert.Error(t, err)
+	assert.Error(t, client.Mkdir("/share/dir-link"))
+	assert.False(t, pathExists(filepath.Join(testDir, "outside.txt")), "The target of the dangling link must not be created")
+	assert.False(t, pathExists(filepath.Join(testDir, "outside")), "The target of the dangling link must not be created")
+
+	// dangling links within the host folder can be written
+	file, err := client.Create("/share/inside-link")
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	assert.True(t, pathExists(filepath.Join(source, "created.txt")))
+}
+
+func Test_sftp_file_system_write_access(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-sftp-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	writable := filepath.Join(testDir, "writable")
+	readOnly := filepath.Join(testDir, "read-only")
+	assert.NoError(t, os.MkdirAll(writable, 0755))
+	assert.NoError(t, os.MkdirAll(readOnly, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(readOnly, "file"), []byte("content"), 0644))
+
+	client, teardown := setupSftpClient(t, []config.HostFolderConfig{
+		sshfsHostFolder("writable", writable, false),
+		sshfsHostFolder("read-only", readOnly, true),
+	})
+	defer teardown()
+
+	file, err := client.Create("/writable/file")
+	assert.NoError(t, err)
+	_, err = file.Write([]byte("written"))
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	content, err := ioutil.ReadFile(filepath.Join(writable, "file"))
+	assert.NoError(t, err)
+	assert.Equal(t, "written", string(content))
+
+	assert.NoError(t, client.Mkdir("/writable/dir"))
+	assert.NoError(t, client.Rename("/writable/file", "/writable/dir/file"))
+	assert.Error(t, client.Rename("/writable/dir/file", "/read-only/file2"))
+	assert.Error(t, client.Remove("/writable"))
+
+	_, err = client.Create("/read-only/new")
+	assert.Error(t, err)
+	assert.Error(t, client.Mkdir("/read-only/dir"))
+	assert.Error(t, client.Remove("/read-only/file"))
+	assert.Error(t, client.Symlink("/writable/dir/file", "/writable/link"))
+
+	info, err := client.Stat("/read-only/file")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0444), info.Mode().Perm())
+	_, err = os.Stat(filepath.Join(readOnly, "file"))
+	assert.NoError(t, err)
+}
+
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
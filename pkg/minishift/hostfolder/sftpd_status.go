@@ -0,0 +1,97 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	sftpdStatusFile = "sftpd-status.json"
+
+	// SftpdHeartbeatInterval is the interval in which the sftpd daemon updates its status file.
+	SftpdHeartbeatInterval = 10 * time.Second
+
+	// sftpdHeartbeatTimeout is the age after which the status of a daemon, which missed its heartbeats, is stale
+	sftpdHeartbeatTimeout = 3 * SftpdHeartbeatInterval
+)
+
+// SftpdStatus is the status the sftpd daemon writes periodically while it is running.
+type SftpdStatus struct {
+	PID               int       `json:"pid"`
+	Port              int       `json:"port"`
+	Started           time.Time `json:"started"`
+	Heartbeat         time.Time `json:"heartbeat"`
+	ActiveSessions    int64     `json:"activeSessions"`
+	TotalConnections  uint64    `json:"totalConnections"`
+	FailedConnections uint64    `json:"failedConnections"`
+}
+
+// IsAlive returns true if the daemon wrote its last heartbeat recently enough to be considered running.
+func (status *SftpdStatus) IsAlive(now time.Time) bool {
+	return now.Sub(status.Heartbeat) < sftpdHeartbeatTimeout
+}
+
+// String returns a short summary of the status as shown by 'minishift services list'.
+func (status *SftpdStatus) String() string {
+	return fmt.Sprintf("running, PID %d, port %d, %d active sessions, %d connections, %d failed", status.PID, status.Port,
+		status.ActiveSessions, status.TotalConnections, status.FailedConnections)
+}
+
+// SftpdStatusPath returns the path of the status file of the sftpd daemon, which is kept next to the all instances
+// configuration like the PID of the daemon.
+func SftpdStatusPath(allInstancesConfigPath string) string {
+	return filepath.Join(filepath.Dir(allInstancesConfigPath), sftpdStatusFile)
+}
+
+// WriteSftpdStatus writes the status file of the sftpd daemon. The file is replaced atomically, so that readers never
+// see a partially written status.
+func WriteSftpdStatus(path string, status *SftpdStatus) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile := path + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, path)
+}
+
+// ReadSftpdStatus reads the status file of the sftpd daemon. nil is returned without an error if the status file
+// does not exist.
+func ReadSftpdStatus(path string) (*SftpdStatus, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var status SftpdStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("invalid sftpd status file '%s': %s", path, err)
+	}
+	return &status, nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sftpd_status_round_trip(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-sftpd-status-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	statusPath := SftpdStatusPath(filepath.Join(testDir, "allinstances.json"))
+	assert.Equal(t, filepath.Join(testDir, "sftpd-status.json"), statusPath)
+
+	status, err := ReadSftpdStatus(statusPath)
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+
+	heartbeat := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	written := &SftpdStatus{PID: 42, Port: 2022, Started: heartbeat, Heartbeat: heartbeat, ActiveSessions: 2, TotalConnections: 5, FailedConnections: 1}
+	assert.NoError(t, WriteSftpdStatus(statusPath, written))
+
+	status, err = ReadSftpdStatus(statusPath)
+	assert.NoError(t, err)
+	assert.Equal(t, written, status)
+	assert.Equal(t, "running, PID 42, port 2022, 2 active sessions, 5 connections, 1 failed", status.String())
+
+	assert.True(t, status.IsAlive(heartbeat.Add(SftpdHeartbeatInterval)))
+	assert.False(t, status.IsAlive(heartbeat.Add(sftpdHeartbeatTimeout)))
+}
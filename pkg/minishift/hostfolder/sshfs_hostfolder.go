@@ -67,20 +67,26 @@ func (h *SSHFSHostFolder) Mount(driver drivers.Driver) error {
 		return err
 	}
 
-	ip, err := h.hostIP(driver)
+	ip, err := hostIP(driver)
 	if err != nil {
 		return err
 	}
 
+	// the sftpd daemon enforces read-only host folders, mounting them read-only lets writes fail early in the VM
+	extraOptions := h.config.Option(config.ExtraOptions)
+	if h.config.IsReadOnly() {
+		extraOptions = strings.TrimSpace(fmt.Sprintf("-o ro %s", extraOptions))
+	}
+
 	// Mount command seems to fail occasionally. Give it a couple of attempts
 	mount := func() (err error) {
 		cmd := fmt.Sprintf(
 			"sudo sshfs docker@%s:%s %s -o IdentityFile=%s -o 'StrictHostKeyChecking=no' -o reconnect -o allow_other -o idmap=none %s -p %d",
 			ip,
-			h.config.Option(config.Source),
+			SftpPath(h.config),
 			h.config.MountPoint(),
 			keyFile,
-			h.config.Option(config.ExtraOptions),
+			extraOptions,
 			SftpPort)
 
 		if glog.V(2) {
@@ -113,7 +119,8 @@ func (h *SSHFSHostFolder) Umount(driver drivers.Driver) error {
 	return nil
 }
 
-func (h *SSHFSHostFolder) hostIP(driver drivers.Driver) (string, error) {
+// hostIP returns the IP address under which the VM reaches the host, determined from the SSH connection of the host.
+func hostIP(driver drivers.Driver) (string, error) {
 	cmd := fmt.Sprint("sudo netstat -tapen | grep 'sshd: docker' | head -n1 | awk '{split($5, a, \":\"); print a[1]}'")
 
 	out, err := drivers.RunSSHCommandFromDriver(driver, cmd)
@@ -133,7 +140,7 @@ func (h *SSHFSHostFolder) ensureSFTPDDaemonRunning() error {
 		return nil
 	}
 
-	sftpCmd, err := createSftpCommand()
+	sftpCmd, err := createDaemonCommand("sftpd")
 	if err != nil {
 		return err
 	}
@@ -186,11 +193,16 @@ func (h *SSHFSHostFolder) cacheRSAKeys(driver drivers.Driver) error {
 }
 
 func (h *SSHFSHostFolder) isRunning() bool {
-	if h.globalConfig.SftpdPID <= 0 {
+	return isProcessRunning(h.globalConfig.SftpdPID)
+}
+
+// isProcessRunning returns whether the daemon with the specified pid is still running.
+func isProcessRunning(pid int) bool {
+	if pid <= 0 {
 		return false
 	}
 
-	process, err := goos.FindProcess(h.globalConfig.SftpdPID)
+	process, err := goos.FindProcess(pid)
 	if err != nil {
 		return false
 	}
@@ -209,7 +221,8 @@ func (h *SSHFSHostFolder) isRunning() bool {
 	}
 }
 
-func createSftpCommand() (*exec.Cmd, error) {
+// createDaemonCommand creates the command starting the specified minishift daemon as background process.
+func createDaemonCommand(daemon string, daemonArgs ...string) (*exec.Cmd, error) {
 	cmd, err := os.CurrentExecutable()
 	if err != nil {
 		return nil, err
@@ -217,7 +230,8 @@ func createSftpCommand() (*exec.Cmd, error) {
 
 	args := []string{
 		"daemon",
-		"sftpd"}
+		daemon}
+	args = append(args, daemonArgs...)
 	exportCmd := exec.Command(cmd, args...)
 	// don't inherit any file handles
 	exportCmd.Stderr = nil
@@ -0,0 +1,142 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/golang/glog"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/minishift/minishift/pkg/util/shell"
+)
+
+// SyncHostFolder keeps a copy of the host folder in the VM instead of mounting it. After an initial copy, a sync
+// daemon pushes the changes of the host folder into the VM and optionally pulls changes made in the VM back.
+type SyncHostFolder struct {
+	config       config.HostFolderConfig
+	globalConfig *minishiftConfig.GlobalConfigType
+	// instanceOnly is true if the host folder is defined for the current instance only
+	instanceOnly bool
+}
+
+func NewSyncHostFolder(config config.HostFolderConfig, globalConfig *minishiftConfig.GlobalConfigType, instanceOnly bool) HostFolder {
+	return &SyncHostFolder{config: config, globalConfig: globalConfig, instanceOnly: instanceOnly}
+}
+
+func (h *SyncHostFolder) Config() config.HostFolderConfig {
+	return h.config
+}
+
+func (h *SyncHostFolder) Mount(driver drivers.Driver) error {
+	cmd := fmt.Sprintf("sudo mkdir -p %s && sudo chown $(id -u):$(id -g) %s", shell.Quote(h.config.MountPoint()), shell.Quote(h.config.MountPoint()))
+	if _, err := drivers.RunSSHCommandFromDriver(driver, cmd); err != nil {
+		return fmt.Errorf("error occured while creating mountpoint. %s", err)
+	}
+
+	sshConfig, err := syncSSHConfig(driver)
+	if err != nil {
+		return err
+	}
+
+	h.stopSyncDaemon()
+
+	// the initial copy is done in the foreground, so that the files are available once the mount command returns
+	remote, err := dialSyncRemote(sshConfig)
+	if err != nil {
+		return err
+	}
+	err = newSyncer(h.config, remote, h.statusPath()).initialSync()
+	remote.close()
+	if err != nil {
+		return fmt.Errorf("error occured while copying host folder: %s", err)
+	}
+
+	syncCmd, err := createDaemonCommand("sync", h.config.Name,
+		"--profile", constants.ProfileName,
+		"--ssh-host", sshConfig.Host,
+		"--ssh-port", strconv.Itoa(sshConfig.Port),
+		"--ssh-user", sshConfig.User,
+		"--ssh-key", sshConfig.KeyPath)
+	if err != nil {
+		return err
+	}
+	if err := syncCmd.Start(); err != nil {
+		return err
+	}
+	if glog.V(2) {
+		fmt.Println(fmt.Sprintf("sync daemon for '%s' running with pid %d", h.config.Name, syncCmd.Process.Pid))
+	}
+	return nil
+}
+
+// Umount stops the synchronization. The copy of the host folder in the VM is kept.
+func (h *SyncHostFolder) Umount(driver drivers.Driver) error {
+	h.stopSyncDaemon()
+	return nil
+}
+
+// IsSyncing returns true if the sync daemon of the host folder is running.
+func (h *SyncHostFolder) IsSyncing() bool {
+	status, err := ReadSyncStatus(h.statusPath())
+	return err == nil && status != nil && isProcessRunning(status.PID)
+}
+
+// Status returns the status of the sync daemon of the host folder. nil is returned if the daemon was not started.
+func (h *SyncHostFolder) Status() (*SyncStatus, error) {
+	return ReadSyncStatus(h.statusPath())
+}
+
+func (h *SyncHostFolder) stopSyncDaemon() {
+	statusPath := h.statusPath()
+	status, err := ReadSyncStatus(statusPath)
+	if err != nil || status == nil {
+		return
+	}
+
+	if status.PID != os.Getpid() && isProcessRunning(status.PID) {
+		if process, err := os.FindProcess(status.PID); err == nil {
+			process.Kill()
+		}
+	}
+	os.Remove(statusPath)
+}
+
+func (h *SyncHostFolder) statusPath() string {
+	profile := ""
+	if h.instanceOnly {
+		profile = constants.ProfileName
+	}
+	return SyncStatusPath(h.globalConfig.FilePath, profile, h.config.Name)
+}
+
+// syncSSHConfig determines the connection details of the SSH server of the VM.
+func syncSSHConfig(driver drivers.Driver) (SyncSSHConfig, error) {
+	host, err := driver.GetSSHHostname()
+	if err != nil {
+		return SyncSSHConfig{}, err
+	}
+	port, err := driver.GetSSHPort()
+	if err != nil {
+		return SyncSSHConfig{}, err
+	}
+	return SyncSSHConfig{Host: host, Port: port, User: driver.GetSSHUsername(), KeyPath: driver.GetSSHKeyPath()}, nil
+}
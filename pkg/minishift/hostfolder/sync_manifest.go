@@ -0,0 +1,256 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minishift/minishift/pkg/util/shell"
+)
+
+// syncEntry describes a regular file of a sync host folder. Modification times are compared in seconds, since that
+// is the precision of tar archives.
+type syncEntry struct {
+	size    int64
+	modTime int64
+}
+
+// syncManifest maps the slash separated paths of the regular files of a sync host folder, relative to its root, to
+// their size and modification time.
+type syncManifest map[string]syncEntry
+
+// syncExcludes are the patterns of files and directories which are not synchronized. A pattern matches if it matches
+// any element of a relative path, eg 'node_modules' or '*.log', or the whole relative path, eg 'build/tmp'.
+type syncExcludes []string
+
+func (e syncExcludes) matches(relPath string) bool {
+	for _, pattern := range e {
+		if matched, _ := path.Match(pattern, relPath); matched {
+			return true
+		}
+		for _, element := range strings.Split(relPath, "/") {
+			if matched, _ := path.Match(pattern, element); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scanLocalManifest lists the regular files below source which are not excluded. Symbolic links are not synchronized.
+func scanLocalManifest(source string, excludes syncExcludes) (syncManifest, error) {
+	manifest := make(syncManifest)
+	err := filepath.Walk(source, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			// files removed while walking are synchronized by the next change event
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		relPath, err := filepath.Rel(source, file)
+		if err != nil || relPath == "." {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if excludes.matches(relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			manifest[relPath] = syncEntry{size: info.Size(), modTime: info.ModTime().Unix()}
+		}
+		return nil
+	})
+	return manifest, err
+}
+
+// remoteManifestCommand returns the command listing the regular files below the target directory in the VM. The
+// directory is created if it does not exist yet.
+func remoteManifestCommand(target string) string {
+	return fmt.Sprintf("mkdir -p %s && cd %s && find . -type f -exec stat -c '%%s %%Y %%n' {} +", shell.Quote(target), shell.Quote(target))
+}
+
+// parseRemoteManifest parses the output of the remote manifest command. Excluded files are omitted, so that files
+// created in the VM, eg installed dependencies, are neither deleted nor pulled back.
+func parseRemoteManifest(output string, excludes syncExcludes) syncManifest {
+	manifest := make(syncManifest)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(strings.TrimRight(line, "\r"), " ", 3)
+		if len(fields) != 3 || !strings.HasPrefix(fields[2], "./") {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		modTime, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		relPath := strings.TrimPrefix(fields[2], "./")
+		if !excludes.matches(relPath) {
+			manifest[relPath] = syncEntry{size: size, modTime: modTime}
+		}
+	}
+	return manifest
+}
+
+// diffManifests returns the files of from which are missing or differ in to, and the files of to missing in from.
+func diffManifests(from syncManifest, to syncManifest) ([]string, []string) {
+	var changed, deleted []string
+	for relPath, entry := range from {
+		if existing, ok := to[relPath]; !ok || existing != entry {
+			changed = append(changed, relPath)
+		}
+	}
+	for relPath := range to {
+		if _, ok := from[relPath]; !ok {
+			deleted = append(deleted, relPath)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(deleted)
+	return changed, deleted
+}
+
+// writeSyncArchive writes the specified files and directories below source as tar archive. Paths which no longer
+// exist are skipped, their deletion is handled separately.
+func writeSyncArchive(writer io.Writer, source string, relPaths []string) error {
+	archive := tar.NewWriter(writer)
+	for _, relPath := range relPaths {
+		file := filepath.Join(source, filepath.FromSlash(relPath))
+		info, err := os.Lstat(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			continue
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = relPath
+		// tar rounds modification times to seconds, manifests truncate them
+		header.ModTime = info.ModTime().Truncate(time.Second)
+		header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+		if info.IsDir() {
+			header.Name += "/"
+			if err := archive.WriteHeader(header); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := writeArchiveFile(archive, header, file); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func writeArchiveFile(archive *tar.Writer, header *tar.Header, file string) error {
+	content, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	// a file growing while it is archived is truncated to the size of its header, the next change event syncs it
+	if _, err := io.CopyN(archive, content, header.Size); err != nil {
+		return err
+	}
+	return nil
+}
+
+// extractSyncArchive extracts the regular files and directories of a tar archive below source and returns the
+// extracted files. Entries which would be written outside of source, also via symbolic links, are rejected.
+func extractSyncArchive(reader io.Reader, source string) (syncManifest, error) {
+	extracted := make(syncManifest)
+	root := &exportedRoot{source: source}
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return extracted, nil
+		}
+		if err != nil {
+			return extracted, err
+		}
+
+		relPath := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if relPath == "." || path.IsAbs(relPath) || relPath == ".." || strings.HasPrefix(relPath, "../") {
+			return extracted, fmt.Errorf("invalid path '%s' in archive", header.Name)
+		}
+		file := filepath.Join(source, filepath.FromSlash(relPath))
+		// symbolic links of the host folder must not redirect files outside of it
+		if !root.contains(file) {
+			return extracted, fmt.Errorf("path '%s' of archive leaves the host folder", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(file, 0755); err != nil {
+				return extracted, err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := extractArchiveFile(archive, header, file); err != nil {
+				return extracted, err
+			}
+			extracted[relPath] = syncEntry{size: header.Size, modTime: header.ModTime.Unix()}
+		}
+	}
+}
+
+func extractArchiveFile(archive io.Reader, header *tar.Header, file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	content, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(header.Mode)&os.ModePerm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(content, archive); err != nil {
+		content.Close()
+		return err
+	}
+	if err := content.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(file, time.Now(), header.ModTime)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const syncDialTimeout = 30 * time.Second
+
+// syncRemote runs shell commands in the VM.
+type syncRemote interface {
+	// run runs the command, passing stdin as its standard input and writing its standard output to stdout. Both may
+	// be nil.
+	run(cmd string, stdin io.Reader, stdout io.Writer) error
+}
+
+// SyncSSHConfig are the connection details of the SSH server of the VM, as provided by its driver.
+type SyncSSHConfig struct {
+	Host    string
+	Port    int
+	User    string
+	KeyPath string
+}
+
+// sshSyncRemote runs commands via a single SSH connection, so that each synchronization only opens a new session. The
+// connection is re-established once it is closed, for example because the VM was restarted or the host was suspended.
+type sshSyncRemote struct {
+	address      string
+	clientConfig *ssh.ClientConfig
+
+	mutex  sync.Mutex
+	client *ssh.Client
+}
+
+func dialSyncRemote(sshConfig SyncSSHConfig) (*sshSyncRemote, error) {
+	key, err := ioutil.ReadFile(sshConfig.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &sshSyncRemote{
+		address: net.JoinHostPort(sshConfig.Host, strconv.Itoa(sshConfig.Port)),
+		clientConfig: &ssh.ClientConfig{
+			User: sshConfig.User,
+			Auth: []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			// the VM generates its host key on first boot, like for 'minishift ssh' it is not verified
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+			Timeout:         syncDialTimeout,
+		},
+	}
+	if _, err := r.connection(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// connection returns the current connection, dialing a new one if the previous one was closed.
+func (r *sshSyncRemote) connection() (*ssh.Client, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.client != nil {
+		return r.client, nil
+	}
+
+	client, err := ssh.Dial("tcp", r.address, r.clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to %s: %s", r.address, err)
+	}
+	r.client = client
+	go func() {
+		client.Wait()
+		r.disconnected(client)
+	}()
+	return client, nil
+}
+
+// disconnected drops the specified connection, unless it was replaced already.
+func (r *sshSyncRemote) disconnected(client *ssh.Client) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.client == client {
+		r.client = nil
+	}
+	client.Close()
+}
+
+func (r *sshSyncRemote) run(cmd string, stdin io.Reader, stdout io.Writer) error {
+	client, err := r.connection()
+	if err != nil {
+		return err
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		// the connection might have been closed before its closing was noticed
+		r.disconnected(client)
+		if client, err = r.connection(); err != nil {
+			return err
+		}
+		if session, err = client.NewSession(); err != nil {
+			return err
+		}
+	}
+	defer session.Close()
+
+	var stderr bytes.Buffer
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = &stderr
+	if err := session.Run(cmd); err != nil {
+		return fmt.Errorf("command '%s' failed: %s %s", cmd, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func (r *sshSyncRemote) close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.client == nil {
+		return nil
+	}
+	client := r.client
+	r.client = nil
+	return client.Close()
+}
@@ -0,0 +1,139 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+// echoSSHServer accepts SSH connections and answers each command with its own text.
+type echoSSHServer struct {
+	listener net.Listener
+	config   *ssh.ServerConfig
+
+	mutex sync.Mutex
+	conns []net.Conn
+}
+
+func newEchoSSHServer(t *testing.T, keyPath string) *echoSSHServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	assert.NoError(t, ioutil.WriteFile(keyPath, keyPEM, 0600))
+	signer, err := ssh.NewSignerFromKey(key)
+	assert.NoError(t, err)
+
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	server := &echoSSHServer{listener: listener, config: config}
+	go server.serve()
+	return server
+}
+
+func (s *echoSSHServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		s.conns = append(s.conns, conn)
+		s.mutex.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *echoSSHServer) handle(conn net.Conn) {
+	_, channels, requests, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			defer channel.Close()
+			for request := range channelRequests {
+				if request.Type != "exec" {
+					request.Reply(false, nil)
+					continue
+				}
+				request.Reply(true, nil)
+				channel.Write(request.Payload[4:])
+				exitStatus := make([]byte, 4)
+				binary.BigEndian.PutUint32(exitStatus, 0)
+				channel.SendRequest("exit-status", false, exitStatus)
+				return
+			}
+		}()
+	}
+}
+
+// dropConnections closes the established connections, like a restart of the VM does.
+func (s *echoSSHServer) dropConnections() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func Test_ssh_sync_remote_reconnects_after_connection_is_closed(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-sync-remote-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	keyPath := filepath.Join(testDir, "id_rsa")
+	server := newEchoSSHServer(t, keyPath)
+	defer server.listener.Close()
+
+	address := server.listener.Addr().(*net.TCPAddr)
+	remote, err := dialSyncRemote(SyncSSHConfig{Host: "127.0.0.1", Port: address.Port, User: "docker", KeyPath: keyPath})
+	assert.NoError(t, err)
+	defer remote.close()
+
+	var out bytes.Buffer
+	assert.NoError(t, remote.run("echo first", nil, &out))
+	assert.Equal(t, "echo first", out.String())
+
+	server.dropConnections()
+
+	out.Reset()
+	assert.NoError(t, remote.run("echo second", nil, &out), "the remote must reconnect")
+	assert.Equal(t, "echo second", out.String())
+}
@@ -0,0 +1,135 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	syncStatusFilePattern = "sync-%s-status.json"
+	// syncStatusProfilesDir contains the status files of the host folders of single instances, one directory per profile
+	syncStatusProfilesDir = "sync-status"
+
+	// SyncHeartbeatInterval is the interval in which a sync daemon updates its status file.
+	SyncHeartbeatInterval = 10 * time.Second
+
+	// syncHeartbeatTimeout is the age after which the status of a daemon, which missed its heartbeats, is stale
+	syncHeartbeatTimeout = 3 * SyncHeartbeatInterval
+)
+
+// The states of a sync host folder.
+const (
+	SyncStateCopying  = "copying"
+	SyncStateWatching = "watching"
+	SyncStateFailed   = "failed"
+)
+
+// SyncStatus is the status a sync daemon writes whenever its state changes and periodically while it is running.
+type SyncStatus struct {
+	PID       int       `json:"pid"`
+	State     string    `json:"state"`
+	Heartbeat time.Time `json:"heartbeat"`
+	// LastSync is the time the last changes were pushed into or pulled from the VM
+	LastSync time.Time `json:"lastSync"`
+	// PendingSince is the time of the oldest change of the host folder which has not been pushed yet
+	PendingSince *time.Time `json:"pendingSince,omitempty"`
+	PushedFiles  uint64     `json:"pushedFiles"`
+	PulledFiles  uint64     `json:"pulledFiles"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// IsAlive returns true if the daemon wrote its last heartbeat recently enough to be considered running.
+func (status *SyncStatus) IsAlive(now time.Time) bool {
+	return now.Sub(status.Heartbeat) < syncHeartbeatTimeout
+}
+
+// Lag returns for how long changes of the host folder have been waiting to be pushed into the VM.
+func (status *SyncStatus) Lag(now time.Time) time.Duration {
+	if status.PendingSince == nil {
+		return 0
+	}
+	return now.Sub(*status.PendingSince)
+}
+
+// Summary returns the status as shown by 'minishift hostfolder list'.
+func (status *SyncStatus) Summary(now time.Time) string {
+	switch {
+	case status == nil:
+		return "not syncing"
+	case status.State == SyncStateFailed:
+		return fmt.Sprintf("failed: %s", status.Error)
+	case !status.IsAlive(now):
+		return fmt.Sprintf("not responding, last heartbeat %s ago", now.Sub(status.Heartbeat).Round(time.Second))
+	case status.State == SyncStateCopying:
+		return "copying"
+	}
+	return fmt.Sprintf("watching, lag %s", status.Lag(now).Round(time.Second))
+}
+
+// SyncStatusPath returns the path of the status file of the sync daemon of the specified host folder, which is kept
+// next to the all instances configuration like the status of the sftpd daemon. Host folders of a single instance are
+// identified by the profile, since host folders of different profiles can have the same name. An empty profile
+// denotes a host folder of all instances.
+func SyncStatusPath(allInstancesConfigPath string, profile string, name string) string {
+	statusDir := filepath.Dir(allInstancesConfigPath)
+	if profile != "" {
+		statusDir = filepath.Join(statusDir, syncStatusProfilesDir, profile)
+	}
+	return filepath.Join(statusDir, fmt.Sprintf(syncStatusFilePattern, name))
+}
+
+// WriteSyncStatus writes the status file of a sync daemon. The file is replaced atomically, so that readers never see
+// a partially written status.
+func WriteSyncStatus(path string, status *SyncStatus) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmpFile := path + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, path)
+}
+
+// ReadSyncStatus reads the status file of a sync daemon. nil is returned without an error if the status file does
+// not exist.
+func ReadSyncStatus(path string) (*SyncStatus, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var status SyncStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("invalid sync status file '%s': %s", path, err)
+	}
+	return &status, nil
+}
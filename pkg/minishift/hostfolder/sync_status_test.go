@@ -0,0 +1,79 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sync_status_summary(t *testing.T) {
+	now := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	pendingSince := now.Add(-3 * time.Second)
+
+	var notStarted *SyncStatus
+	assert.Equal(t, "not syncing", notStarted.Summary(now))
+
+	status := &SyncStatus{PID: 42, State: SyncStateWatching, Heartbeat: now}
+	assert.Equal(t, "watching, lag 0s", status.Summary(now))
+
+	status.PendingSince = &pendingSince
+	assert.Equal(t, 3*time.Second, status.Lag(now))
+	assert.Equal(t, "watching, lag 3s", status.Summary(now))
+
+	status.State = SyncStateCopying
+	assert.Equal(t, "copying", status.Summary(now))
+
+	assert.Equal(t, "not responding, last heartbeat 5m0s ago", status.Summary(now.Add(5*time.Minute)))
+
+	status.State, status.Error = SyncStateFailed, "connection refused"
+	assert.Equal(t, "failed: connection refused", status.Summary(now.Add(5*time.Minute)))
+}
+
+func Test_sync_status_of_instance_host_folders_is_kept_per_profile(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-sync-status-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	allInstancesConfig, err := minishiftConfig.NewAllInstancesConfig(filepath.Join(testDir, "allinstances.json"))
+	assert.NoError(t, err)
+	instanceConfig, err := minishiftConfig.NewInstanceConfig(filepath.Join(testDir, "minishift.json"))
+	assert.NoError(t, err)
+	shared := config.HostFolderConfig{Name: "shared", Type: SYNC.String()}
+	project := config.HostFolderConfig{Name: "project", Type: SYNC.String()}
+	allInstancesConfig.HostFolders = []config.HostFolderConfig{shared}
+	instanceConfig.HostFolders = []config.HostFolderConfig{project}
+	manager, err := NewManager(instanceConfig, allInstancesConfig)
+	assert.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(testDir, "sync-shared-status.json"), manager.hostFolderForConfig(&shared).(*SyncHostFolder).statusPath())
+	projectStatusPath := manager.hostFolderForConfig(&project).(*SyncHostFolder).statusPath()
+	assert.Equal(t, filepath.Join(testDir, syncStatusProfilesDir, constants.ProfileName, "sync-project-status.json"), projectStatusPath)
+
+	assert.NoError(t, WriteSyncStatus(projectStatusPath, &SyncStatus{PID: 42, State: SyncStateWatching}))
+	status, err := ReadSyncStatus(projectStatusPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, status.PID)
+}
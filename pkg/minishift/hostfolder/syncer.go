@@ -0,0 +1,407 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang/glog"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/minishift/minishift/pkg/util/shell"
+)
+
+const (
+	// syncDebounceInterval is the time the syncer waits for further changes before pushing them, so that a build
+	// writing many files results in few transfers
+	syncDebounceInterval = 300 * time.Millisecond
+
+	// SyncPullInterval is the interval in which changes in the VM are pulled back, if enabled.
+	SyncPullInterval = 5 * time.Second
+
+	// syncCommandBatchSize limits the number of paths passed to a single rm or tar command in the VM
+	syncCommandBatchSize = 200
+)
+
+// syncer synchronizes a host folder with a copy in the VM. Changes of the host folder are pushed, changes in the VM
+// are only pulled back if enabled. All methods must be called from the same goroutine.
+type syncer struct {
+	source     string
+	target     string
+	excludes   syncExcludes
+	pullBack   bool
+	remote     syncRemote
+	statusPath string
+	status     SyncStatus
+
+	// pulled are the files pulled from the VM, their change events must not push them back
+	pulled syncManifest
+	// pending are the changed paths of the host folder which have not been pushed yet
+	pending map[string]bool
+}
+
+func newSyncer(hostFolder config.HostFolderConfig, remote syncRemote, statusPath string) *syncer {
+	return &syncer{
+		source:     hostFolder.Option(config.Source),
+		target:     hostFolder.MountPoint(),
+		excludes:   syncExcludes(hostFolder.ExcludePatterns()),
+		pullBack:   hostFolder.IsPullBack(),
+		remote:     remote,
+		statusPath: statusPath,
+		status:     SyncStatus{PID: os.Getpid()},
+		pulled:     make(syncManifest),
+		pending:    make(map[string]bool),
+	}
+}
+
+// initialSync pushes all files which are missing or differ in the VM and deletes the files which no longer exist on
+// the host. Excluded files in the VM are kept.
+func (s *syncer) initialSync() error {
+	s.setState(SyncStateCopying, nil)
+
+	local, err := scanLocalManifest(s.source, s.excludes)
+	if err != nil {
+		return s.failed(err)
+	}
+	remote, err := s.remoteManifest()
+	if err != nil {
+		return s.failed(err)
+	}
+
+	changed, deleted := diffManifests(local, remote)
+	if err := s.deleteRemote(deleted); err != nil {
+		return s.failed(err)
+	}
+	if err := s.pushFiles(changed); err != nil {
+		return s.failed(err)
+	}
+
+	s.status.PushedFiles += uint64(len(changed))
+	s.status.LastSync = time.Now()
+	s.setState(SyncStateWatching, nil)
+	return nil
+}
+
+// changed records a changed path of the host folder, which is pushed by the next call of pushPending.
+func (s *syncer) changed(relPath string) {
+	if relPath == "." || s.excludes.matches(relPath) {
+		return
+	}
+	if len(s.pending) == 0 {
+		now := time.Now()
+		s.status.PendingSince = &now
+	}
+	s.pending[relPath] = true
+}
+
+// pushPending pushes the pending changes. Paths which no longer exist on the host are deleted in the VM. The changes
+// stay pending if the push fails, so that it is retried.
+func (s *syncer) pushPending() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	var existing, deleted []string
+	for relPath := range s.pending {
+		info, err := os.Lstat(filepath.Join(s.source, filepath.FromSlash(relPath)))
+		switch {
+		case os.IsNotExist(err):
+			deleted = append(deleted, relPath)
+		case err != nil:
+			return s.failed(err)
+		case s.wasPulled(relPath, info):
+			// the change is the file pulled from the VM
+		default:
+			existing = append(existing, relPath)
+		}
+	}
+	sort.Strings(existing)
+	sort.Strings(deleted)
+
+	if err := s.deleteRemote(deleted); err != nil {
+		return s.failed(err)
+	}
+	if err := s.pushFiles(existing); err != nil {
+		return s.failed(err)
+	}
+
+	s.pending = make(map[string]bool)
+	s.status.PendingSince = nil
+	s.status.PushedFiles += uint64(len(existing))
+	s.status.LastSync = time.Now()
+	s.setState(SyncStateWatching, nil)
+	return nil
+}
+
+func (s *syncer) wasPulled(relPath string, info os.FileInfo) bool {
+	entry, ok := s.pulled[relPath]
+	if !ok {
+		return false
+	}
+	delete(s.pulled, relPath)
+	return info.Mode().IsRegular() && entry == syncEntry{size: info.Size(), modTime: info.ModTime().Unix()}
+}
+
+// pull copies the files which were created or modified in the VM to the host. Files with pending changes on the host
+// are not pulled, the host wins conflicts. Files deleted in the VM are not deleted on the host.
+func (s *syncer) pull() error {
+	local, err := scanLocalManifest(s.source, s.excludes)
+	if err != nil {
+		return s.failed(err)
+	}
+	remote, err := s.remoteManifest()
+	if err != nil {
+		return s.failed(err)
+	}
+
+	var newer []string
+	for relPath, entry := range remote {
+		existing, ok := local[relPath]
+		if s.pending[relPath] || (ok && (existing == entry || existing.modTime > entry.modTime)) {
+			continue
+		}
+		newer = append(newer, relPath)
+	}
+	sort.Strings(newer)
+
+	for _, batch := range syncBatches(newer) {
+		var archive bytes.Buffer
+		if err := s.remote.run(fmt.Sprintf("cd %s && tar -cf - -- %s", shell.Quote(s.target), quoteAll(batch)), nil, &archive); err != nil {
+			return s.failed(err)
+		}
+		extracted, err := extractSyncArchive(&archive, s.source)
+		for relPath, entry := range extracted {
+			s.pulled[relPath] = entry
+		}
+		s.status.PulledFiles += uint64(len(extracted))
+		if err != nil {
+			return s.failed(err)
+		}
+	}
+
+	s.status.LastSync = time.Now()
+	s.setState(SyncStateWatching, nil)
+	return nil
+}
+
+// watch pushes the changes of the host folder until stop is closed. Changes in the VM are pulled periodically if
+// pull back is enabled. Failed synchronizations are retried with the next change or heartbeat.
+func (s *syncer) watch(watcher *fsnotify.Watcher, stop <-chan struct{}) {
+	debounce := time.NewTimer(syncDebounceInterval)
+	debounce.Stop()
+	heartbeat := time.NewTicker(SyncHeartbeatInterval)
+	defer heartbeat.Stop()
+	var pullTicks <-chan time.Time
+	if s.pullBack {
+		pullTicker := time.NewTicker(SyncPullInterval)
+		defer pullTicker.Stop()
+		pullTicks = pullTicker.C
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case event := <-watcher.Events:
+			s.handleEvent(watcher, event)
+			debounce.Reset(syncDebounceInterval)
+		case err := <-watcher.Errors:
+			// events might have been lost, the next initial sync of a remount catches up
+			glog.Errorf("Error watching '%s': %v", s.source, err)
+		case <-debounce.C:
+			if err := s.pushPending(); err != nil {
+				glog.Errorf("Failed to push changes of '%s': %v", s.source, err)
+			}
+		case <-pullTicks:
+			if len(s.pending) == 0 {
+				if err := s.pull(); err != nil {
+					glog.Errorf("Failed to pull changes into '%s': %v", s.source, err)
+				}
+			}
+		case <-heartbeat.C:
+			if err := s.pushPending(); err != nil {
+				glog.Errorf("Failed to push changes of '%s': %v", s.source, err)
+			}
+			s.writeStatus()
+		}
+	}
+}
+
+func (s *syncer) handleEvent(watcher *fsnotify.Watcher, event fsnotify.Event) {
+	relPath, err := filepath.Rel(s.source, event.Name)
+	if err != nil {
+		return
+	}
+	relPath = filepath.ToSlash(relPath)
+	s.changed(relPath)
+
+	// the files of a created directory might have been written before its watch was added
+	if event.Op&fsnotify.Create == fsnotify.Create {
+		if info, err := os.Lstat(event.Name); err == nil && info.IsDir() && !s.excludes.matches(relPath) {
+			if err := s.addWatches(watcher, event.Name); err != nil {
+				glog.Errorf("Failed to watch '%s': %v", event.Name, err)
+			}
+		}
+	}
+}
+
+// addWatches watches the specified directory and all its directories which are not excluded. The contents of the
+// directories are recorded as changed.
+func (s *syncer) addWatches(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		relPath, err := filepath.Rel(s.source, file)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if relPath != "." && s.excludes.matches(relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if file != dir {
+			s.changed(relPath)
+		}
+		if info.IsDir() {
+			return watcher.Add(file)
+		}
+		return nil
+	})
+}
+
+func (s *syncer) remoteManifest() (syncManifest, error) {
+	var output bytes.Buffer
+	if err := s.remote.run(remoteManifestCommand(s.target), nil, &output); err != nil {
+		return nil, err
+	}
+	return parseRemoteManifest(output.String(), s.excludes), nil
+}
+
+func (s *syncer) pushFiles(relPaths []string) error {
+	for _, batch := range syncBatches(relPaths) {
+		reader, writer := io.Pipe()
+		go func(batch []string) {
+			writer.CloseWithError(writeSyncArchive(writer, s.source, batch))
+		}(batch)
+
+		err := s.remote.run(fmt.Sprintf("mkdir -p %s && cd %s && tar -xf -", shell.Quote(s.target), shell.Quote(s.target)), reader, nil)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syncer) deleteRemote(relPaths []string) error {
+	for _, batch := range syncBatches(relPaths) {
+		if err := s.remote.run(fmt.Sprintf("cd %s && rm -rf -- %s", shell.Quote(s.target), quoteAll(batch)), nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syncer) failed(err error) error {
+	s.setState(SyncStateFailed, err)
+	return err
+}
+
+func (s *syncer) setState(state string, err error) {
+	s.status.State = state
+	s.status.Error = ""
+	if err != nil {
+		s.status.Error = err.Error()
+	}
+	s.writeStatus()
+}
+
+func (s *syncer) writeStatus() {
+	if s.statusPath == "" {
+		return
+	}
+	s.status.Heartbeat = time.Now()
+	if err := WriteSyncStatus(s.statusPath, &s.status); err != nil {
+		glog.Errorf("Failed to write sync status: %v", err)
+	}
+}
+
+// syncBatches splits paths into batches of at most syncCommandBatchSize paths.
+func syncBatches(relPaths []string) [][]string {
+	var batches [][]string
+	for start := 0; start < len(relPaths); start += syncCommandBatchSize {
+		end := start + syncCommandBatchSize
+		if end > len(relPaths) {
+			end = len(relPaths)
+		}
+		batches = append(batches, relPaths[start:end])
+	}
+	return batches
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = shell.Quote(value)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// RunSync synchronizes the specified sync host folder with the VM until stop is closed. The host folder is watched
+// before the initial synchronization, so that no change gets lost.
+func RunSync(hostFolder config.HostFolderConfig, sshConfig SyncSSHConfig, statusPath string, stop <-chan struct{}) error {
+	remote, err := dialSyncRemote(sshConfig)
+	if err != nil {
+		return err
+	}
+	defer remote.close()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	s := newSyncer(hostFolder, remote, statusPath)
+	if err := s.addWatches(watcher, s.source); err != nil {
+		return s.failed(err)
+	}
+	// the initial synchronization covers everything recorded while adding the watches
+	s.pending = make(map[string]bool)
+	s.status.PendingSince = nil
+	if err := s.initialSync(); err != nil {
+		return err
+	}
+
+	s.watch(watcher, stop)
+	return nil
+}
@@ -0,0 +1,262 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// localSyncRemote runs the commands of the syncer in a local shell, the target directory stands in for the VM.
+type localSyncRemote struct{}
+
+func (r localSyncRemote) run(cmd string, stdin io.Reader, stdout io.Writer) error {
+	var stderr bytes.Buffer
+	shell := exec.Command("sh", "-c", cmd)
+	shell.Stdin = stdin
+	shell.Stdout = stdout
+	shell.Stderr = &stderr
+	if err := shell.Run(); err != nil {
+		return fmt.Errorf("%s: %s", err, stderr.String())
+	}
+	return nil
+}
+
+func setupSyncer(t *testing.T, options map[string]string) (*syncer, string, string, func()) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the VM side of the syncer requires a POSIX shell")
+	}
+
+	testDir, err := ioutil.TempDir("", "minishift-test-sync-")
+	assert.NoError(t, err)
+	source := filepath.Join(testDir, "source")
+	target := filepath.Join(testDir, "target")
+	assert.NoError(t, os.MkdirAll(filepath.Join(source, "src"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(source, "node_modules", "dep"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(source, "src", "main.go"), []byte("package main"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(source, "node_modules", "dep", "index.js"), []byte("dep"), 0644))
+
+	hostFolder := config.HostFolderConfig{
+		Name:    "workspace",
+		Type:    SYNC.String(),
+		Options: map[string]string{config.Source: source, config.MountPoint: target},
+	}
+	for key, value := range options {
+		hostFolder.Options[key] = value
+	}
+
+	s := newSyncer(hostFolder, localSyncRemote{}, filepath.Join(testDir, "status.json"))
+	return s, source, target, func() { os.RemoveAll(testDir) }
+}
+
+func Test_initial_sync_copies_files_except_excluded(t *testing.T) {
+	s, _, target, teardown := setupSyncer(t, map[string]string{config.Excludes: "node_modules"})
+	defer teardown()
+
+	// files removed on the host are deleted in the VM, excluded files created in the VM are kept
+	assert.NoError(t, os.MkdirAll(filepath.Join(target, "node_modules"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(target, "node_modules", "installed.js"), []byte("vm"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(target, "stale"), []byte("stale"), 0644))
+
+	assert.NoError(t, s.initialSync())
+
+	content, err := ioutil.ReadFile(filepath.Join(target, "src", "main.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package main", string(content))
+	_, err = os.Stat(filepath.Join(target, "node_modules", "dep"))
+	assert.True(t, os.IsNotExist(err), "excluded files must not be copied")
+	_, err = os.Stat(filepath.Join(target, "node_modules", "installed.js"))
+	assert.NoError(t, err, "excluded files in the VM must be kept")
+	_, err = os.Stat(filepath.Join(target, "stale"))
+	assert.True(t, os.IsNotExist(err), "files missing on the host must be deleted")
+
+	status, err := ReadSyncStatus(s.statusPath)
+	assert.NoError(t, err)
+	assert.Equal(t, SyncStateWatching, status.State)
+	assert.Equal(t, uint64(1), status.PushedFiles)
+}
+
+func Test_pending_changes_are_pushed(t *testing.T) {
+	s, source, target, teardown := setupSyncer(t, map[string]string{config.Excludes: "node_modules"})
+	defer teardown()
+	assert.NoError(t, s.initialSync())
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(source, "src", "main.go"), []byte("package changed"), 0644))
+	assert.NoError(t, os.Remove(filepath.Join(source, "src", "main.go")))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(source, "added"), []byte("added"), 0644))
+	s.changed("src/main.go")
+	s.changed("added")
+	s.changed("node_modules/dep/index.js")
+	assert.NotNil(t, s.status.PendingSince)
+	assert.Len(t, s.pending, 2, "changes of excluded files must be ignored")
+
+	assert.NoError(t, s.pushPending())
+	assert.Nil(t, s.status.PendingSince)
+	assert.Equal(t, time.Duration(0), s.status.Lag(time.Now()))
+
+	_, err := os.Stat(filepath.Join(target, "src", "main.go"))
+	assert.True(t, os.IsNotExist(err), "files deleted on the host must be deleted in the VM")
+	content, err := ioutil.ReadFile(filepath.Join(target, "added"))
+	assert.NoError(t, err)
+	assert.Equal(t, "added", string(content))
+}
+
+func Test_watch_pushes_changes_of_new_directories(t *testing.T) {
+	s, source, target, teardown := setupSyncer(t, nil)
+	defer teardown()
+
+	watcher, err := fsnotify.NewWatcher()
+	assert.NoError(t, err)
+	defer watcher.Close()
+	assert.NoError(t, s.addWatches(watcher, source))
+	assert.NoError(t, s.initialSync())
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		s.watch(watcher, stop)
+		close(done)
+	}()
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(source, "new", "nested"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(source, "new", "nested", "file"), []byte("new"), 0644))
+
+	var content []byte
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if content, err = ioutil.ReadFile(filepath.Join(target, "new", "nested", "file")); err == nil && string(content) == "new" {
+			break
+		}
+	}
+	close(stop)
+	<-done
+	assert.Equal(t, "new", string(content))
+}
+
+func Test_pull_back_copies_changes_made_in_vm(t *testing.T) {
+	s, source, target, teardown := setupSyncer(t, map[string]string{config.PullBack: "true"})
+	defer teardown()
+	assert.NoError(t, s.initialSync())
+
+	modTime := time.Now().Add(time.Minute)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(target, "generated.txt"), []byte("generated"), 0644))
+	assert.NoError(t, os.Chtimes(filepath.Join(target, "generated.txt"), modTime, modTime))
+
+	assert.NoError(t, s.pull())
+	content, err := ioutil.ReadFile(filepath.Join(source, "generated.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "generated", string(content))
+	assert.Equal(t, uint64(1), s.status.PulledFiles)
+
+	// the change event of the pulled file must not push it back
+	s.changed("generated.txt")
+	assert.NoError(t, s.pushPending())
+	assert.Equal(t, uint64(2), s.status.PushedFiles, "only the initial copy must have been pushed")
+}
+
+func Test_extract_rejects_paths_leaving_source(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links require privileges on Windows")
+	}
+
+	testDir, err := ioutil.TempDir("", "minishift-test-sync-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+	source := filepath.Join(testDir, "source")
+	assert.NoError(t, os.MkdirAll(filepath.Join(testDir, "outside"), 0755))
+	assert.NoError(t, os.MkdirAll(source, 0755))
+	assert.NoError(t, os.Symlink(filepath.Join(testDir, "outside"), filepath.Join(source, "link")))
+	assert.NoError(t, os.Symlink(filepath.Join("..", "dangling-target"), filepath.Join(source, "dangling")))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(testDir, "outside", "file"), []byte("outside"), 0644))
+
+	var archive bytes.Buffer
+	assert.NoError(t, writeSyncArchive(&archive, filepath.Join(testDir, "outside"), []string{"file"}))
+	data := archive.Bytes()
+
+	for _, name := range []string{"../escaped", "link/file", "dangling"} {
+		var renamed bytes.Buffer
+		assert.NoError(t, renameSingleEntryArchive(&renamed, data, name))
+		_, err := extractSyncArchive(&renamed, source)
+		assert.Error(t, err, "extracting '%s' must fail", name)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(testDir, "outside", "file"))
+	assert.NoError(t, err)
+	assert.Equal(t, "outside", string(content))
+	_, err = os.Lstat(filepath.Join(testDir, "dangling-target"))
+	assert.True(t, os.IsNotExist(err), "The target of the dangling link must not be created")
+}
+
+func Test_exclude_patterns(t *testing.T) {
+	excludes := syncExcludes{".git", "*.log", "build/tmp"}
+
+	assert.True(t, excludes.matches(".git"))
+	assert.True(t, excludes.matches("sub/.git/config"))
+	assert.True(t, excludes.matches("logs/server.log"))
+	assert.True(t, excludes.matches("build/tmp"))
+	assert.False(t, excludes.matches("build/classes"))
+	assert.False(t, excludes.matches(".gitignore"))
+}
+
+func Test_diff_manifests(t *testing.T) {
+	local := syncManifest{"same": {1, 1}, "modified": {2, 2}, "added": {3, 3}}
+	remote := syncManifest{"same": {1, 1}, "modified": {2, 1}, "deleted": {4, 4}}
+
+	changed, deleted := diffManifests(local, remote)
+	assert.Equal(t, []string{"added", "modified"}, changed)
+	assert.Equal(t, []string{"deleted"}, deleted)
+}
+
+func Test_parse_remote_manifest(t *testing.T) {
+	output := "12 1500000000 ./src/main.go\n3 1500000001 ./with space\n7 1500000002 ./.git/HEAD\ngarbage\n"
+
+	manifest := parseRemoteManifest(output, syncExcludes{".git"})
+	assert.Equal(t, syncManifest{
+		"src/main.go": {12, 1500000000},
+		"with space":  {3, 1500000001},
+	}, manifest)
+}
+
+// renameSingleEntryArchive writes the archive with its single entry renamed.
+func renameSingleEntryArchive(writer io.Writer, data []byte, name string) error {
+	reader := tar.NewReader(bytes.NewReader(data))
+	header, err := reader.Next()
+	if err != nil {
+		return err
+	}
+	header.Name = name
+
+	archive := tar.NewWriter(writer)
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := io.Copy(archive, reader); err != nil {
+		return err
+	}
+	return archive.Close()
+}
@@ -72,6 +72,17 @@ func (oc *OcRunner) Run(command string, stdOut io.Writer, stdErr io.Writer) int
 	return oc.Runner.Run(stdOut, stdErr, oc.OcPath, args...)
 }
 
+// InDir returns a copy of this runner which runs oc in the specified working directory, provided the underlying
+// runner is a util.RealRunner. Otherwise the runner is used as is.
+func (oc *OcRunner) InDir(dir string) *OcRunner {
+	runner := oc.Runner
+	if realRunner, ok := runner.(util.RealRunner); ok {
+		realRunner.Dir = dir
+		runner = realRunner
+	}
+	return &OcRunner{OcPath: oc.OcPath, KubeConfigPath: oc.KubeConfigPath, Runner: runner}
+}
+
 func (oc *OcRunner) RunAsUser(command string, stdOut io.Writer, stdErr io.Writer) int {
 	args := strings.Split(command, " ")
 	return oc.Runner.Run(stdOut, stdErr, oc.OcPath, args...)
@@ -20,9 +20,11 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func Ungzip(source, target string) error {
@@ -73,7 +75,10 @@ func Untar(tarball, targetDir string) error {
 		}
 
 		// the target location where the dir/file should be created
-		path := filepath.Join(targetDir, header.Name)
+		path, err := targetPath(targetDir, header.Name)
+		if err != nil {
+			return err
+		}
 
 		// check the file type
 		switch header.Typeflag {
@@ -117,7 +122,10 @@ func Unzip(archive, target string) error {
 	}
 
 	for _, file := range reader.File {
-		path := filepath.Join(target, file.Name)
+		path, err := targetPath(target, file.Name)
+		if err != nil {
+			return err
+		}
 		if file.FileInfo().IsDir() {
 			os.MkdirAll(path, file.Mode())
 			continue
@@ -142,3 +150,13 @@ func Unzip(archive, target string) error {
 
 	return nil
 }
+
+// targetPath returns the location of the archive entry name within targetDir. An error is returned if the entry
+// would end up outside of targetDir, eg via '../' path elements.
+func targetPath(targetDir, name string) (string, error) {
+	path := filepath.Join(targetDir, name)
+	if path != filepath.Clean(targetDir) && !strings.HasPrefix(path, filepath.Clean(targetDir)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("Archive entry '%s' points outside of the target directory", name)
+	}
+	return path, nil
+}
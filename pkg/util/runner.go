@@ -29,6 +29,8 @@ type Runner interface {
 
 type RealRunner struct {
 	Env []string
+	// Dir is the working directory of the executed commands. If empty, the working directory of the calling process is used.
+	Dir string
 }
 
 // the real runner for get the output as byte format
@@ -50,6 +52,7 @@ func (r RealRunner) Run(stdOut io.Writer, stdErr io.Writer, command string, args
 	cmd.Stdout = stdOut
 	cmd.Stderr = stdErr
 	cmd.Env = r.Env
+	cmd.Dir = r.Dir
 
 	err := cmd.Run()
 
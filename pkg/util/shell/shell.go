@@ -159,3 +159,8 @@ func GetPrefixSuffixDelimiterForUnSet(userShell string) (prefix, suffix, delimit
 
 	return
 }
+
+// Quote quotes value as a single word for a POSIX shell, for example the shell of the VM.
+func Quote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
@@ -111,3 +111,9 @@ REM 	@FOR /f "tokens=*" %i IN ('foo') DO @call %i
 
 	}
 }
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, "'/mnt/sda1/my share'", Quote("/mnt/sda1/my share"))
+	assert.Equal(t, `'it'\''s'`, Quote("it's"))
+	assert.Equal(t, "''", Quote(""))
+}
@@ -77,7 +77,10 @@ func RetryAfter(attempts int, callback func() error, d time.Duration) (err error
 		if _, ok := err.(*RetriableError); !ok {
 			return m.ToError()
 		}
-		time.Sleep(d)
+		// no need to wait after the last attempt
+		if i < attempts-1 {
+			time.Sleep(d)
+		}
 	}
 	return m.ToError()
 }
@@ -78,6 +78,14 @@ func TestRetry(t *testing.T) {
 	assert.Error(t, err, "Error should have been raised by retry")
 }
 
+func TestRetryAfterDoesNotWaitAfterLastAttempt(t *testing.T) {
+	f := errorGenerator(3, true)
+	start := time.Now()
+	err := RetryAfter(2, f, 200*time.Millisecond)
+	assert.Error(t, err, "Error should have been raised by retry")
+	assert.True(t, time.Since(start) < 400*time.Millisecond, "Retry should only wait between attempts")
+}
+
 func TestMultiError(t *testing.T) {
 	m := MultiError{}
 